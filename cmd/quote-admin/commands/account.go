@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/data/account"
+	"github.com/johanronkko/quote-service/internal/foundation/database"
+)
+
+// Account creates a customer account and prints its API key.
+func Account(cfg database.Config, name string) error {
+	if name == "" {
+		fmt.Println("help: account <name>")
+		return ErrHelp
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, apiKey, err := account.New(db).Create(ctx, account.NewAccount{Name: name})
+	if err != nil {
+		return fmt.Errorf("create account: %w", err)
+	}
+
+	fmt.Printf("account id: %s\n", info.ID)
+	fmt.Printf("api key: %s\n", apiKey)
+	return nil
+}
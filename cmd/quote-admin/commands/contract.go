@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/data/contract"
+	"github.com/johanronkko/quote-service/internal/foundation/database"
+)
+
+// Contract adds the next version of a contract read from a JSON file of the
+// format of contract.NewContract.
+func Contract(cfg database.Config, path string) error {
+	if path == "" {
+		fmt.Println("help: contract <file>")
+		return ErrHelp
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read contract: %w", err)
+	}
+	var nc contract.NewContract
+	if err := json.Unmarshal(data, &nc); err != nil {
+		return fmt.Errorf("decode contract: %w", err)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, err := contract.New(db).Create(ctx, nc)
+	if err != nil {
+		return fmt.Errorf("create contract: %w", err)
+	}
+
+	fmt.Printf("contract %s version %d added\n", info.ID, info.Version)
+	return nil
+}
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/data/promotion"
+	"github.com/johanronkko/quote-service/internal/foundation/database"
+)
+
+// PromotionAdd adds a promotion read from a JSON file of the format of
+// promotion.NewPromotion.
+func PromotionAdd(cfg database.Config, path string) error {
+	if path == "" {
+		fmt.Println("help: promotion-add <file>")
+		return ErrHelp
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read promotion: %w", err)
+	}
+	var np promotion.NewPromotion
+	if err := json.Unmarshal(data, &np); err != nil {
+		return fmt.Errorf("decode promotion: %w", err)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, err := promotion.New(db).Create(ctx, np)
+	if err != nil {
+		return fmt.Errorf("create promotion: %w", err)
+	}
+
+	fmt.Printf("promotion %s added\n", info.Code)
+	return nil
+}
+
+// PromotionList prints every promotion and how many times it has been
+// redeemed.
+func PromotionList(cfg database.Config) error {
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	promotions, err := promotion.New(db).Query(ctx)
+	if err != nil {
+		return fmt.Errorf("query promotions: %w", err)
+	}
+
+	for _, p := range promotions {
+		max := "unlimited"
+		if p.MaxRedemptions > 0 {
+			max = fmt.Sprint(p.MaxRedemptions)
+		}
+		fmt.Printf("%s: %v %s, %s to %s, redeemed %d of %s\n",
+			p.Code, p.Value, p.DiscountType,
+			p.ValidFrom.Format(time.RFC3339), p.ValidUntil.Format(time.RFC3339),
+			p.Redemptions, max)
+	}
+	return nil
+}
+
+// PromotionEnd ends the promotion with code now, so it can no longer be
+// redeemed.
+func PromotionEnd(cfg database.Config, code string) error {
+	if code == "" {
+		fmt.Println("help: promotion-end <code>")
+		return ErrHelp
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := promotion.New(db).End(ctx, code, time.Now()); err != nil {
+		return fmt.Errorf("end promotion: %w", err)
+	}
+
+	fmt.Printf("promotion %s ended\n", code)
+	return nil
+}
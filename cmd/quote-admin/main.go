@@ -82,9 +82,39 @@ func run(log *log.Logger) error {
 			return fmt.Errorf("seeding database: %w", err)
 		}
 
+	case "account":
+		if err := commands.Account(dbConfig, cfg.Args.Num(1)); err != nil {
+			return fmt.Errorf("adding account: %w", err)
+		}
+
+	case "contract":
+		if err := commands.Contract(dbConfig, cfg.Args.Num(1)); err != nil {
+			return fmt.Errorf("adding contract: %w", err)
+		}
+
+	case "promotion-add":
+		if err := commands.PromotionAdd(dbConfig, cfg.Args.Num(1)); err != nil {
+			return fmt.Errorf("adding promotion: %w", err)
+		}
+
+	case "promotion-list":
+		if err := commands.PromotionList(dbConfig); err != nil {
+			return fmt.Errorf("listing promotions: %w", err)
+		}
+
+	case "promotion-end":
+		if err := commands.PromotionEnd(dbConfig, cfg.Args.Num(1)); err != nil {
+			return fmt.Errorf("ending promotion: %w", err)
+		}
+
 	default:
 		fmt.Println("migrate: create the schema in the database")
 		fmt.Println("seed: add data to the database")
+		fmt.Println("account <name>: create a customer account and print its API key")
+		fmt.Println("contract <file>: add the next version of a contract from a JSON file")
+		fmt.Println("promotion-add <file>: add a promotion from a JSON file")
+		fmt.Println("promotion-list: list promotions and their redemptions")
+		fmt.Println("promotion-end <code>: end a promotion now")
 		return commands.ErrHelp
 	}
 
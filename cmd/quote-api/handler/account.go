@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/johanronkko/quote-service/internal/business/data/account"
+)
+
+// Account authenticates customer accounts.
+type Account interface {
+	// Authenticate returns the account with apiKey. Returns
+	// account.ErrUnauthenticated if no account has the key.
+	Authenticate(ctx context.Context, apiKey string) (account.Info, error)
+}
+
+// errAnonymous occurs when a request without an API key is made to an API
+// that requires one.
+var errAnonymous = errors.New("expected an API key in the Authorization header")
+
+// errForbidden occurs when a resource belongs to another account than the
+// account of the request.
+var errForbidden = errors.New("resource belongs to another account")
+
+// ctxKey is the type of the request context keys of the package.
+type ctxKey int
+
+// accountKey is the request context key of the authenticated account.
+const accountKey ctxKey = iota
+
+// authenticate authenticates requests with an API key in the Authorization
+// header, e.g. "Authorization: Bearer <key>", and adds the account to the
+// request context. Requests without an Authorization header are anonymous.
+func (h *Handler) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next(w, r)
+			return
+		}
+		apiKey := strings.TrimPrefix(header, "Bearer ")
+		if apiKey == header || apiKey == "" {
+			respond(w, r, http.StatusUnauthorized, fmt.Errorf("expected Authorization header of format: Bearer <key>"))
+			return
+		}
+		a, err := h.Account.Authenticate(r.Context(), apiKey)
+		if errors.Is(err, account.ErrUnauthenticated) {
+			respond(w, r, http.StatusUnauthorized, err)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), accountKey, a)))
+	}
+}
+
+// accountID returns the ID of the authenticated account of ctx, or an empty
+// string if the request is anonymous.
+func accountID(ctx context.Context) string {
+	a, _ := ctx.Value(accountKey).(account.Info)
+	return a.ID
+}
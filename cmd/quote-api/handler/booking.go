@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/johanronkko/quote-service/internal/business/carrier"
+	"github.com/johanronkko/quote-service/internal/business/data/booking"
+	"github.com/johanronkko/quote-service/internal/business/data/quote"
+	"github.com/johanronkko/quote-service/internal/business/validate"
+	"github.com/matryer/way"
+)
+
+// Booking manages the set of API's for booking access.
+type Booking interface {
+	// Query retrieves a list of the existing bookings of the account with
+	// accountID.
+	Query(ctx context.Context, accountID string) ([]booking.Info, error)
+	// QueryByID retrieves the booking with id. Returns booking.ErrNotFound if
+	// booking not found.
+	QueryByID(ctx context.Context, id string) (booking.Info, error)
+	// Create books the shipment of the accepted quote of nb. Returns
+	// booking.ErrAnonymous if nb has no account, quote.ErrNotFound if the
+	// quote does not exist, booking.ErrForbidden if it belongs to another
+	// account, quote.ErrInvalidTransition if it is not accepted or already
+	// booked, booking.ErrPending if it is being booked, and
+	// carrier.ErrUnavailable if the carrier that rated it fails to book it.
+	Create(ctx context.Context, nb booking.NewBooking) (booking.Info, error)
+}
+
+func (h *Handler) handleGetBooking() http.HandlerFunc {
+	type response struct {
+		Booking booking.Info `json:"booking"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := accountID(r.Context())
+		if account == "" {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		}
+		id := way.Param(r.Context(), "id")
+		if err := validate.CheckID(id); err != nil {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		}
+		b, err := h.Booking.QueryByID(r.Context(), id)
+		if err == booking.ErrNotFound {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		if b.AccountID != account {
+			respond(w, r, http.StatusForbidden, errForbidden)
+			return
+		}
+		respond(w, r, http.StatusOK, &response{b})
+	}
+}
+
+// handleListBookings lists the bookings of the account of the request.
+func (h *Handler) handleListBookings() http.HandlerFunc {
+	type response struct {
+		Bookings []booking.Info `json:"bookings"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := accountID(r.Context())
+		if account == "" {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		}
+		bs, err := h.Booking.Query(r.Context(), account)
+		if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		respond(w, r, http.StatusOK, &response{bs})
+	}
+}
+
+func (h *Handler) handleAddBooking() http.HandlerFunc {
+	type response struct {
+		Booking booking.Info `json:"booking"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := accountID(r.Context())
+		if account == "" {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		}
+		var nb booking.NewBooking
+		if err := decode(w, r, &nb); err != nil {
+			respond(w, r, http.StatusBadRequest, fmt.Errorf("could not decode JSON"))
+			return
+		}
+		var ferrors validate.FieldErrors
+		if err := validate.Check(nb); errors.As(err, &ferrors) {
+			respond(w, r, http.StatusBadRequest, ferrors)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		nb.AccountID = account
+		b, err := h.Booking.Create(r.Context(), nb)
+		if errors.Is(err, booking.ErrAnonymous) {
+			respond(w, r, http.StatusUnauthorized, err)
+			return
+		} else if errors.Is(err, quote.ErrNotFound) {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		} else if errors.Is(err, booking.ErrForbidden) {
+			respond(w, r, http.StatusForbidden, err)
+			return
+		} else if errors.Is(err, quote.ErrExpired) || errors.Is(err, quote.ErrInvalidTransition) || errors.Is(err, booking.ErrPending) {
+			respond(w, r, http.StatusConflict, err)
+			return
+		} else if errors.Is(err, carrier.ErrUnavailable) {
+			respond(w, r, http.StatusServiceUnavailable, fmt.Errorf("carrier unavailable"))
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		respond(w, r, http.StatusCreated, &response{b})
+	}
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/johanronkko/quote-service/internal/business/data/customer"
+	"github.com/johanronkko/quote-service/internal/business/validate"
+	"github.com/matryer/way"
+)
+
+// Customer manages the set of API's for the address books of accounts.
+type Customer interface {
+	// Query retrieves the page of saved customers selected by filter, and
+	// the cursor of the next page. Returns customer.ErrInvalidCursor if the
+	// cursor of filter is not valid.
+	Query(ctx context.Context, filter customer.Filter) ([]customer.Info, string, error)
+	// QueryByID retrieves the customer with id in the address book of the
+	// account with accountID. Returns customer.ErrNotFound if customer not
+	// found.
+	QueryByID(ctx context.Context, accountID, id string) (customer.Info, error)
+	// Create adds a customer to the address book of the account of nc.
+	// Returns customer.ErrDuplicate if a customer with the same email and
+	// address exists.
+	Create(ctx context.Context, nc customer.NewCustomer) (customer.Info, error)
+	// Update replaces the details of the customer with id in the address
+	// book of the account of nc. Returns customer.ErrNotFound if customer not
+	// found, and customer.ErrDuplicate if another customer has the same email
+	// and address.
+	Update(ctx context.Context, id string, nc customer.NewCustomer) (customer.Info, error)
+	// Delete removes the customer with id from the address book of the
+	// account with accountID. Returns customer.ErrNotFound if customer not
+	// found.
+	Delete(ctx context.Context, accountID, id string) error
+}
+
+func (h *Handler) handleGetCustomer() http.HandlerFunc {
+	type response struct {
+		Customer customer.Info `json:"customer"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := accountID(r.Context())
+		if account == "" {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		}
+		id := way.Param(r.Context(), "id")
+		if err := validate.CheckID(id); err != nil {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		}
+		c, err := h.Customer.QueryByID(r.Context(), account, id)
+		if err == customer.ErrNotFound {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		respond(w, r, http.StatusOK, &response{c})
+	}
+}
+
+func (h *Handler) handleListCustomers() http.HandlerFunc {
+	type response struct {
+		Customers  []customer.Info `json:"customers"`
+		NextCursor string          `json:"next_cursor,omitempty"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := accountID(r.Context())
+		if account == "" {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		}
+		filter := customer.Filter{AccountID: account, Cursor: r.URL.Query().Get("cursor")}
+		if v := r.URL.Query().Get("limit"); v != "" {
+			var err error
+			if filter.Limit, err = strconv.Atoi(v); err != nil {
+				respond(w, r, http.StatusBadRequest, validate.FieldErrors{{Field: "limit", Error: "limit must be an integer"}})
+				return
+			}
+		}
+		var ferrors validate.FieldErrors
+		if err := validate.Check(filter); errors.As(err, &ferrors) {
+			respond(w, r, http.StatusBadRequest, ferrors)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		cs, next, err := h.Customer.Query(r.Context(), filter)
+		if errors.Is(err, customer.ErrInvalidCursor) {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		respond(w, r, http.StatusOK, &response{cs, next})
+	}
+}
+
+func (h *Handler) handleAddCustomer() http.HandlerFunc {
+	type response struct {
+		Customer customer.Info `json:"customer"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := accountID(r.Context())
+		if account == "" {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		}
+		var nc customer.NewCustomer
+		if err := decode(w, r, &nc); err != nil {
+			respond(w, r, http.StatusBadRequest, fmt.Errorf("could not decode JSON"))
+			return
+		}
+		nc.AccountID = account
+		nc.Address = nc.Address.Normalize()
+		var ferrors validate.FieldErrors
+		if err := validate.Check(nc); errors.As(err, &ferrors) {
+			respond(w, r, http.StatusBadRequest, ferrors)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		c, err := h.Customer.Create(r.Context(), nc)
+		if errors.Is(err, customer.ErrDuplicate) {
+			respond(w, r, http.StatusConflict, err)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		respond(w, r, http.StatusCreated, &response{c})
+	}
+}
+
+func (h *Handler) handleUpdateCustomer() http.HandlerFunc {
+	type response struct {
+		Customer customer.Info `json:"customer"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := accountID(r.Context())
+		if account == "" {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		}
+		id := way.Param(r.Context(), "id")
+		if err := validate.CheckID(id); err != nil {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		}
+		var nc customer.NewCustomer
+		if err := decode(w, r, &nc); err != nil {
+			respond(w, r, http.StatusBadRequest, fmt.Errorf("could not decode JSON"))
+			return
+		}
+		nc.AccountID = account
+		nc.Address = nc.Address.Normalize()
+		var ferrors validate.FieldErrors
+		if err := validate.Check(nc); errors.As(err, &ferrors) {
+			respond(w, r, http.StatusBadRequest, ferrors)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		c, err := h.Customer.Update(r.Context(), id, nc)
+		if errors.Is(err, customer.ErrNotFound) {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		} else if errors.Is(err, customer.ErrDuplicate) {
+			respond(w, r, http.StatusConflict, err)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		respond(w, r, http.StatusOK, &response{c})
+	}
+}
+
+func (h *Handler) handleDeleteCustomer() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := accountID(r.Context())
+		if account == "" {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		}
+		id := way.Param(r.Context(), "id")
+		if err := validate.CheckID(id); err != nil {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		}
+		err := h.Customer.Delete(r.Context(), account, id)
+		if errors.Is(err, customer.ErrNotFound) {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		respond(w, r, http.StatusOK, nil)
+	}
+}
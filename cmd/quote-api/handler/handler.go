@@ -26,6 +26,9 @@ func New() *Handler {
 type Handler struct {
 	router *way.Router
 	Quote
+	Account
+	Booking
+	Customer
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
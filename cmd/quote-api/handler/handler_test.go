@@ -10,10 +10,20 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	. "github.com/johanronkko/quote-service/cmd/quote-api/handler"
+	"github.com/johanronkko/quote-service/internal/business/address"
+	"github.com/johanronkko/quote-service/internal/business/carrier"
+	"github.com/johanronkko/quote-service/internal/business/data/account"
+	"github.com/johanronkko/quote-service/internal/business/data/booking"
+	"github.com/johanronkko/quote-service/internal/business/data/customer"
+	"github.com/johanronkko/quote-service/internal/business/data/promotion"
 	"github.com/johanronkko/quote-service/internal/business/data/quote"
+	"github.com/johanronkko/quote-service/internal/business/exchange"
 	"github.com/johanronkko/quote-service/internal/business/mock"
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
 	"github.com/johanronkko/quote-service/internal/business/region"
 	"github.com/johanronkko/quote-service/internal/business/tests"
 	"github.com/johanronkko/quote-service/internal/business/validate"
@@ -42,7 +52,44 @@ type QuoteResponse struct {
 type QuotesResponse struct {
 	NoDataResponse
 	Data struct {
-		Quotes []quote.Info `json:"quotes"`
+		Quotes     []quote.Info `json:"quotes"`
+		NextCursor string       `json:"next_cursor"`
+	} `json:"data"`
+}
+
+type OffersResponse struct {
+	NoDataResponse
+	Data struct {
+		Offers []quote.Offer `json:"offers"`
+	} `json:"data"`
+}
+
+type BookingResponse struct {
+	NoDataResponse
+	Data struct {
+		Booking booking.Info `json:"booking"`
+	} `json:"data"`
+}
+
+type BookingsResponse struct {
+	NoDataResponse
+	Data struct {
+		Bookings []booking.Info `json:"bookings"`
+	} `json:"data"`
+}
+
+type CustomerResponse struct {
+	NoDataResponse
+	Data struct {
+		Customer customer.Info `json:"customer"`
+	} `json:"data"`
+}
+
+type CustomersResponse struct {
+	NoDataResponse
+	Data struct {
+		Customers  []customer.Info `json:"customers"`
+		NextCursor string          `json:"next_cursor"`
 	} `json:"data"`
 }
 
@@ -98,9 +145,11 @@ func TestHandleListQuotes(t *testing.T) {
 				// Setup handler.
 				h := New()
 				h.Quote = q
+				h.Account = acmeAccount()
 
 				// Make request.
 				r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/", nil)
+				r.Header.Set("Authorization", "Bearer acme-key")
 				w := httptest.NewRecorder()
 				h.ServeHTTP(w, r)
 
@@ -128,9 +177,11 @@ func TestHandleListQuotes(t *testing.T) {
 		// Setup handler.
 		h := New()
 		h.Quote = q
+		h.Account = acmeAccount()
 
 		// Make request.
 		r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/", nil)
+		r.Header.Set("Authorization", "Bearer acme-key")
 		w := httptest.NewRecorder()
 		h.ServeHTTP(w, r)
 
@@ -145,6 +196,140 @@ func TestHandleListQuotes(t *testing.T) {
 		is.Equal(*resp.Error, "internal server error")
 	})
 
+	t.Run("filtered page", func(t *testing.T) {
+		is := is.New(t)
+
+		// Mock.
+		q := &mock.Quote{}
+		q.QueryCall.Returns.Quotes = createTestQuotes(2)
+		q.QueryCall.Returns.NextCursor = "next"
+
+		// Setup handler.
+		h := New()
+		h.Quote = q
+		h.Account = acmeAccount()
+
+		// Make request.
+		r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/?from_country=se,no&min_weight=5&max_cost=10000&email=sven.svensson@test.com&created_from=2026-10-01&created_until=2026-10-19T12:00:00Z&sort=-total_cost&limit=2&cursor=prev", nil)
+		r.Header.Set("Authorization", "Bearer acme-key")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response HTTP headers.
+		is.Equal(w.Code, http.StatusOK)
+
+		// Assert mock.
+		minWeight, maxCost := 5, int64(10000)
+		createdFrom := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+		createdUntil := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+		is.Equal(q.QueryCall.Recieves.Filter, quote.Filter{
+			AccountID:        acmeID,
+			FromCountryCodes: []string{"SE", "NO"},
+			MinWeight:        &minWeight,
+			MaxCost:          &maxCost,
+			Email:            "sven.svensson@test.com",
+			CreatedFrom:      &createdFrom,
+			CreatedUntil:     &createdUntil,
+			Sort:             quote.SortTotalCost,
+			Descending:       true,
+			Limit:            2,
+			Cursor:           "prev",
+		})
+
+		// Assert response payload.
+		var resp QuotesResponse
+		decodePayload(is, w.Body, &resp)
+		is.Equal(len(resp.Data.Quotes), 2)
+		is.Equal(resp.Data.NextCursor, "next")
+	})
+
+	t.Run("bad filter", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Query string
+			Field string
+		}{
+			{"weight not integer", "min_weight=heavy", "min_weight"},
+			{"bad date", "created_from=yesterday", "created_from"},
+			{"unknown country", "to_country=XX", "to_country[0]"},
+			{"unknown sort", "sort=name", "sort"},
+			{"limit too large", "limit=501", "limit"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				// Setup handler.
+				h := New()
+				h.Quote = &mock.Quote{}
+				h.Account = acmeAccount()
+
+				// Make request.
+				r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/?"+tc.Query, nil)
+				r.Header.Set("Authorization", "Bearer acme-key")
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+
+				// Assert response HTTP headers.
+				is.Equal(w.Code, http.StatusBadRequest)
+
+				// Assert response payload.
+				var resp FieldErrorResponse
+				decodePayload(is, w.Body, &resp)
+				is.Equal(len(resp.FieldErrors), 1)
+				is.Equal(resp.FieldErrors[0].Field, tc.Field)
+			})
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		is := is.New(t)
+
+		// Mock services.
+		q := &mock.Quote{}
+		q.QueryCall.Returns.Err = quote.ErrInvalidCursor
+
+		// Setup handler.
+		h := New()
+		h.Quote = q
+		h.Account = acmeAccount()
+
+		// Make request.
+		r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/?cursor=banana", nil)
+		r.Header.Set("Authorization", "Bearer acme-key")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response HTTP headers.
+		is.Equal(w.Code, http.StatusBadRequest)
+
+		// Assert response payload.
+		var resp NoDataResponse
+		decodePayload(is, w.Body, &resp)
+		is.Equal(*resp.Error, quote.ErrInvalidCursor.Error())
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		is := is.New(t)
+
+		// Mock.
+		q := &mock.Quote{}
+
+		// Setup handler.
+		h := New()
+		h.Quote = q
+
+		// Make request.
+		r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/?email=sven.svensson@test.com", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusUnauthorized)
+		is.Equal(q.QueryCall.Recieves.Filter, quote.Filter{}) // Not queried.
+	})
+
 	t.Run("bad id format", func(t *testing.T) {
 		is := is.New(t)
 
@@ -156,9 +341,11 @@ func TestHandleListQuotes(t *testing.T) {
 		// Setup handler.
 		h := New()
 		h.Quote = q
+		h.Account = acmeAccount()
 
 		// Make request.
 		r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/"+quoteID, nil)
+		r.Header.Set("Authorization", "Bearer acme-key")
 		w := httptest.NewRecorder()
 		h.ServeHTTP(w, r)
 
@@ -174,6 +361,133 @@ func TestHandleListQuotes(t *testing.T) {
 	})
 }
 
+func TestHandleSearchQuotes(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		is := is.New(t)
+
+		// Mock.
+		q := &mock.Quote{}
+		q.SearchCall.Returns.Matches = []quote.Match{
+			{Quote: createTestQuotes(1)[0], Rank: 0.5, To: "<b>Sven</b> Svensson"},
+		}
+
+		// Setup handler.
+		h := New()
+		h.Quote = q
+		h.Account = acmeAccount()
+
+		// Make request.
+		r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/search?q=sven+g%C3%B6teborg&limit=5", nil)
+		r.Header.Set("Authorization", "Bearer acme-key")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response HTTP headers.
+		is.Equal(w.Code, http.StatusOK)
+
+		// Assert mock.
+		is.Equal(q.SearchCall.Recieves.AccountID, acmeID)
+		is.Equal(q.SearchCall.Recieves.Text, "sven göteborg")
+		is.Equal(q.SearchCall.Recieves.Limit, 5)
+
+		// Assert response payload.
+		var resp struct {
+			NoDataResponse
+			Data struct {
+				Matches []quote.Match `json:"matches"`
+			} `json:"data"`
+		}
+		decodePayload(is, w.Body, &resp)
+		is.Equal(resp.Code, http.StatusOK)
+		is.True(resp.Success)
+		is.Equal(resp.Data.Matches, q.SearchCall.Returns.Matches)
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Query string
+			Err   error
+			Field string
+		}{
+			{"no terms", "q=", quote.ErrEmptySearch, "q"},
+			{"limit not integer", "q=sven&limit=many", nil, "limit"},
+			{"limit too large", "q=sven&limit=101", nil, "limit"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				// Mock.
+				q := &mock.Quote{}
+				q.SearchCall.Returns.Err = tc.Err
+
+				// Setup handler.
+				h := New()
+				h.Quote = q
+				h.Account = acmeAccount()
+
+				// Make request.
+				r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/search?"+tc.Query, nil)
+				r.Header.Set("Authorization", "Bearer acme-key")
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+
+				// Assert response HTTP headers.
+				is.Equal(w.Code, http.StatusBadRequest)
+
+				// Assert response payload.
+				var resp FieldErrorResponse
+				decodePayload(is, w.Body, &resp)
+				is.Equal(resp.FieldErrors[0].Field, tc.Field)
+			})
+		}
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		is := is.New(t)
+
+		// Mock services.
+		q := &mock.Quote{}
+		q.SearchCall.Returns.Err = fmt.Errorf("some error")
+
+		// Setup handler.
+		h := New()
+		h.Quote = q
+		h.Account = acmeAccount()
+
+		// Make request.
+		r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/search?q=sven", nil)
+		r.Header.Set("Authorization", "Bearer acme-key")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response HTTP headers.
+		is.Equal(w.Code, http.StatusInternalServerError)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		is := is.New(t)
+
+		// Mock.
+		q := &mock.Quote{}
+
+		// Setup handler.
+		h := New()
+		h.Quote = q
+
+		// Make request.
+		r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/search?q=sven", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusUnauthorized)
+		is.Equal(q.SearchCall.Recieves.Text, "") // Not searched.
+	})
+}
+
 func TestHandleGetQuote(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		is := is.New(t)
@@ -183,13 +497,16 @@ func TestHandleGetQuote(t *testing.T) {
 		// Mock.
 		q := &mock.Quote{}
 		q.QueryByIDCall.Returns.Info = createTestQuote(quoteID)
+		q.QueryByIDCall.Returns.Info.AccountID = acmeID
 
 		// Setup handler.
 		h := New()
 		h.Quote = q
+		h.Account = acmeAccount()
 
 		// Make request.
 		r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/"+quoteID, nil)
+		r.Header.Set("Authorization", "Bearer acme-key")
 		w := httptest.NewRecorder()
 		h.ServeHTTP(w, r)
 
@@ -209,29 +526,41 @@ func TestHandleGetQuote(t *testing.T) {
 		cases := []struct {
 			Name string
 
-			QuoteID    string
-			ServiceErr error
-			ErrMsg     string
+			Owner         string
+			Authorization string
+			ServiceErr    error
+			ErrMsg        string
 
 			StatusCode int
 		}{
-			{"unknown error", validate.GenerateID(), errors.New("some error"), "internal server error", http.StatusInternalServerError},
-			{"quote not found", validate.GenerateID(), quote.ErrNotFound, quote.ErrNotFound.Error(), http.StatusBadRequest},
+			{"unknown error", acmeID, "Bearer acme-key", errors.New("some error"), "internal server error", http.StatusInternalServerError},
+			{"quote not found", acmeID, "Bearer acme-key", quote.ErrNotFound, quote.ErrNotFound.Error(), http.StatusBadRequest},
+			{"anonymous", acmeID, "", nil, "expected an API key in the Authorization header", http.StatusUnauthorized},
+			{"anonymous quote", "", "Bearer acme-key", nil, "resource belongs to another account", http.StatusForbidden},
+			{"quote of another account", validate.GenerateID(), "Bearer acme-key", nil, "resource belongs to another account", http.StatusForbidden},
 		}
 		for _, tc := range cases {
 			t.Run(tc.Name, func(t *testing.T) {
 				is := is.New(t)
 
+				quoteID := validate.GenerateID()
+
 				// Mock services.
 				q := &mock.Quote{}
+				q.QueryByIDCall.Returns.Info = createTestQuote(quoteID)
+				q.QueryByIDCall.Returns.Info.AccountID = tc.Owner
 				q.QueryByIDCall.Returns.Err = tc.ServiceErr
 
 				// Setup handler.
 				h := New()
 				h.Quote = q
+				h.Account = acmeAccount()
 
 				// Make request.
-				r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/"+tc.QuoteID, nil)
+				r := httptest.NewRequest(http.MethodGet, "/api.v1/quotes/"+quoteID, nil)
+				if tc.Authorization != "" {
+					r.Header.Set("Authorization", tc.Authorization)
+				}
 				w := httptest.NewRecorder()
 				h.ServeHTTP(w, r)
 
@@ -254,17 +583,30 @@ func TestHandleAddQuote(t *testing.T) {
 		is := is.New(t)
 
 		nq := createTestNewQuote()
-		nq.From.CountryCode = "US"
+		nq.From.Address.CountryCode = "US"
 		nq.Weight = 500
+		nq.Dimensions = &quote.Dimensions{Length: 120, Width: 80, Height: 100}
+		nq.ServiceLevel = pricing.ServiceExpress
+		nq.Currency = "EUR"
 
 		// Mock services.
 		q := &mock.Quote{}
 		q.CreateCall.Returns.Info = quote.Info{
-			ID:           validate.GenerateID(),
-			To:           nq.To,
-			From:         nq.From,
-			Weight:       nq.Weight,
-			ShipmentCost: 2.5 * 2000, // Outside EU * huge package
+			ID:               validate.GenerateID(),
+			To:               *nq.To,
+			From:             *nq.From,
+			Weight:           nq.Weight,
+			Parcels:          []quote.Parcel{{Weight: nq.Weight, Dimensions: nq.Dimensions, Quantity: 1, ChargeableWeight: nq.Weight}},
+			ChargeableWeight: nq.Weight,
+			ServiceLevel:     nq.ServiceLevel,
+			TransitDays:      1,
+			ShipmentCost:     money.New(1.5*2.5*2000_00, "SEK"), // Express * outside EU * huge package
+			Tax:              pricing.Tax{Amount: money.New(0, "SEK")},
+			TotalCost:        money.New(1.5*2.5*2000_00, "SEK"),
+			Converted: &quote.Conversion{
+				Cost: money.New(750_00, "EUR"),
+				Rate: exchange.Rate{From: "SEK", To: "EUR", Value: 0.1, Timestamp: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+			},
 		}
 
 		// Setup handler.
@@ -300,6 +642,13 @@ func TestHandleAddQuote(t *testing.T) {
 		}{
 			{"unknown error", errors.New("some error"), "internal server error", http.StatusInternalServerError},
 			{"unsupported country code", region.ErrUnsupportedCountryCode, region.ErrUnsupportedCountryCode.Error(), http.StatusBadRequest},
+			{"unsupported currency", exchange.ErrUnsupportedCurrency, exchange.ErrUnsupportedCurrency.Error(), http.StatusBadRequest},
+			{"volumetric weight too heavy", fmt.Errorf("calculating shipment cost: %w", pricing.ErrInvalidWeight), fmt.Errorf("calculating shipment cost: %w", pricing.ErrInvalidWeight).Error(), http.StatusBadRequest},
+			{"wrapped unsupported country code", fmt.Errorf("region translation: %w", region.ErrUnsupportedCountryCode), fmt.Errorf("region translation: %w", region.ErrUnsupportedCountryCode).Error(), http.StatusBadRequest},
+			{"invalid promo code", fmt.Errorf("redeeming promo code: %w", promotion.ErrInvalidCode), fmt.Errorf("redeeming promo code: %w", promotion.ErrInvalidCode).Error(), http.StatusBadRequest},
+			{"promo code limit reached", fmt.Errorf("redeeming promo code: %w", promotion.ErrLimitReached), fmt.Errorf("redeeming promo code: %w", promotion.ErrLimitReached).Error(), http.StatusBadRequest},
+			{"customer not found", fmt.Errorf("looking up receiver: %w", customer.ErrNotFound), fmt.Errorf("looking up receiver: %w", customer.ErrNotFound).Error(), http.StatusBadRequest},
+			{"anonymous customer reference", quote.ErrAnonymousCustomer, "expected an API key in the Authorization header", http.StatusUnauthorized},
 		}
 		for _, tc := range cases {
 			t.Run(tc.Name, func(t *testing.T) {
@@ -360,6 +709,52 @@ func TestHandleAddQuote(t *testing.T) {
 		is.True(resp.Error != nil)
 	})
 
+	t.Run("invalid parcels", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Weight     int
+			Dimensions *quote.Dimensions
+			Parcels    []quote.Parcel
+
+			Fields []string
+		}{
+			{"no weight or parcels", 0, nil, nil, []string{"weight", "parcels"}},
+			{"both weight and parcels", 5, nil, []quote.Parcel{{Weight: 5, Quantity: 1}}, []string{"weight"}},
+			{"both dimensions and parcels", 0, &quote.Dimensions{Length: 50, Width: 40, Height: 30}, []quote.Parcel{{Weight: 5, Quantity: 1}}, []string{"parcels"}},
+			{"bad parcel", 0, nil, []quote.Parcel{{Weight: 1001, Quantity: 0}}, []string{"parcels[0].weight", "parcels[0].quantity"}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				// Setup handler.
+				h := New()
+				h.Quote = &mock.Quote{}
+
+				// Make request.
+				nq := createTestNewQuote()
+				nq.Weight = tc.Weight
+				nq.Dimensions = tc.Dimensions
+				nq.Parcels = tc.Parcels
+				reqBody, err := json.Marshal(&nq)
+				is.NoErr(err)
+				r := httptest.NewRequest(http.MethodPost, "/api.v1/quotes/", bytes.NewBuffer(reqBody))
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+
+				// Assert response.
+				is.Equal(w.Code, http.StatusBadRequest)
+				var resp FieldErrorResponse
+				decodePayload(is, w.Body, &resp)
+				is.Equal(len(resp.FieldErrors), len(tc.Fields))
+				for i, field := range tc.Fields {
+					is.Equal(resp.FieldErrors[i].Field, field)
+				}
+			})
+		}
+	})
+
 	t.Run("invalid request fields", func(t *testing.T) {
 
 		// valdiate all fields at once
@@ -374,19 +769,26 @@ func TestHandleAddQuote(t *testing.T) {
 
 		// Make request.
 		nq := quote.NewQuote{
-			To: quote.Customer{
-				Name:        "",
-				Email:       "",
-				Address:     "",
-				CountryCode: "",
+			To: &quote.Customer{
+				Name:    "",
+				Email:   "",
+				Address: address.Address{},
 			},
-			From: quote.Customer{
-				Name:        "contains number 42",
-				Email:       "bad_email",
-				Address:     tests.GenRandomAlpha(101), // Too long.
-				CountryCode: "bad country code",
+			From: &quote.Customer{
+				Name:  "contains number 42",
+				Email: "bad_email",
+				Address: address.Address{
+					Lines:       []string{tests.GenRandomAlpha(101)}, // Too long.
+					PostalCode:  "4112",                              // Not Swedish.
+					City:        "Göteborg",
+					CountryCode: "SE",
+				},
+				VATNumber: "not a vat number",
 			},
-			Weight: 999999,
+			Weight:     999999,
+			Dimensions: &quote.Dimensions{Length: 0, Width: 301, Height: -1},
+			Currency:   "euro",
+			PromoCode:  "SPRING-25",
 		}
 		reqBody, err := json.Marshal(&nq)
 		is.NoErr(err)
@@ -402,51 +804,1045 @@ func TestHandleAddQuote(t *testing.T) {
 		decodePayload(is, w.Body, &resp)
 		is.Equal(resp.Code, http.StatusBadRequest)
 		is.True(!resp.Success)
-		is.Equal(len(resp.FieldErrors), 9) // All fields are invalid.
+		is.Equal(len(resp.FieldErrors), 16) // All fields are invalid.
+		fields := map[string]bool{}
+		for _, ferr := range resp.FieldErrors {
+			fields[ferr.Field] = true
+		}
+		is.True(fields["to.address.city"])          // Required in every country.
+		is.True(fields["from.address.postal_code"]) // Not a Swedish postal code.
+		is.True(fields["from.address.lines[0]"])
 	})
-}
 
-func createTestQuotes(numQuotes int) []quote.Info {
-	qs := []quote.Info{}
-	for i := 0; i < numQuotes; i++ {
-		qs = append(qs, createTestQuote(validate.GenerateID()))
-	}
-	return qs
-}
+	t.Run("customer references", func(t *testing.T) {
+		cases := []struct {
+			Name string
 
-func createTestQuote(id string) quote.Info {
-	return quote.Info{
-		ID:           id,
-		From:         createTestCustomer("John Doe", "US"),
-		To:           createTestCustomer("Sven Svensson", "SE"),
-		Weight:       500,
-		ShipmentCost: 1250,
-	}
-}
+			To           *quote.Customer
+			ToCustomerID string
+			From         *quote.Customer
 
-func createTestNewQuote() quote.NewQuote {
-	return quote.NewQuote{
-		To: quote.Customer{
-			Name:        "Sven Svensson",
-			Email:       "sven.svensson@test.com",
-			Address:     "Testgatan 42B, Göteborg 12345",
-			CountryCode: "SV",
-		},
-		From: quote.Customer{
-			Name:        "John Doe",
-			Email:       "john.doe@test.com",
-			Address:     "Teststreet 4242, Blaine 55434",
-			CountryCode: "US",
-		},
-		Weight: 500,
-	}
-}
+			StatusCode int
+		}{
+			{"saved receiver", nil, validate.GenerateID(), createTestNewQuote().From, http.StatusCreated},
+			{"receiver and saved receiver", createTestNewQuote().To, validate.GenerateID(), createTestNewQuote().From, http.StatusBadRequest},
+			{"no sender", createTestNewQuote().To, "", nil, http.StatusBadRequest},
+			{"malformed customer id", nil, "banana", createTestNewQuote().From, http.StatusBadRequest},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
 
-func createTestCustomer(name string, ccode string) quote.Customer {
-	return quote.Customer{
-		Name:        name,
-		Email:       "example@test.com",
-		Address:     "Vasagatan 5B, Göteborg 41124",
-		CountryCode: ccode,
-	}
+				// Mock services.
+				q := &mock.Quote{}
+
+				// Setup handler.
+				h := New()
+				h.Quote = q
+
+				// Make request.
+				nq := quote.NewQuote{To: tc.To, ToCustomerID: tc.ToCustomerID, From: tc.From, Weight: 500}
+				reqBody, err := json.Marshal(&nq)
+				is.NoErr(err)
+				r := httptest.NewRequest(http.MethodPost, "/api.v1/quotes/", bytes.NewBuffer(reqBody))
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+
+				// Assert response.
+				is.Equal(w.Code, tc.StatusCode)
+				if tc.StatusCode == http.StatusCreated {
+					is.Equal(q.CreateCall.Recieves.Nq, nq)
+				}
+			})
+		}
+	})
+
+	t.Run("untidy addresses", func(t *testing.T) {
+		is := is.New(t)
+
+		// Mock services.
+		q := &mock.Quote{}
+
+		// Setup handler.
+		h := New()
+		h.Quote = q
+
+		// Make request.
+		nq := createTestNewQuote()
+		untidy := nq
+		to := *nq.To
+		to.Address.Lines = []string{"  Testgatan   42B ", ""}
+		to.Address.CountryCode = "se"
+		untidy.To = &to
+		reqBody, err := json.Marshal(&untidy)
+		is.NoErr(err)
+		r := httptest.NewRequest(http.MethodPost, "/api.v1/quotes/", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusCreated) // Normalized before validation.
+		is.Equal(q.CreateCall.Recieves.Nq, nq)
+	})
+}
+
+func createTestQuotes(numQuotes int) []quote.Info {
+	qs := []quote.Info{}
+	for i := 0; i < numQuotes; i++ {
+		qs = append(qs, createTestQuote(validate.GenerateID()))
+	}
+	return qs
+}
+
+func TestHandleQuoteOffers(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		is := is.New(t)
+
+		// Mock services.
+		q := &mock.Quote{}
+		q.OffersCall.Returns.Offers = []quote.Offer{
+			{ServiceLevel: pricing.ServiceEconomy, TransitDays: 5, ShipmentCost: money.New(80_00, "SEK"), TotalCost: money.New(100_00, "SEK")},
+			{ServiceLevel: pricing.ServiceStandard, TransitDays: 3, ShipmentCost: money.New(100_00, "SEK"), TotalCost: money.New(125_00, "SEK")},
+			{ServiceLevel: pricing.ServiceExpress, TransitDays: 1, ShipmentCost: money.New(150_00, "SEK"), TotalCost: money.New(187_50, "SEK")},
+		}
+
+		// Setup handler.
+		h := New()
+		h.Quote = q
+
+		// Make request.
+		nq := createTestNewQuote()
+		reqBody, err := json.Marshal(&nq)
+		is.NoErr(err)
+		r := httptest.NewRequest(http.MethodPost, "/api.v1/quotes/offers", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response HTTP headers.
+		is.Equal(w.Code, http.StatusOK)
+
+		// Assert response payload.
+		var resp OffersResponse
+		decodePayload(is, w.Body, &resp)
+		is.Equal(resp.Code, http.StatusOK)
+		is.Equal(resp.Data.Offers, q.OffersCall.Returns.Offers)
+		is.Equal(q.OffersCall.Recieves.Nq, nq)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			ServiceErr error
+
+			StatusCode int
+		}{
+			{"unknown error", errors.New("some error"), http.StatusInternalServerError},
+			{"unsupported country code", fmt.Errorf("region translation: %w", region.ErrUnsupportedCountryCode), http.StatusBadRequest},
+			{"unsupported service level", fmt.Errorf("calculating shipment cost: %w", pricing.ErrUnsupportedServiceLevel), http.StatusBadRequest},
+			{"invalid promo code", fmt.Errorf("checking promo code: %w", promotion.ErrInvalidCode), http.StatusBadRequest},
+			{"anonymous customer reference", quote.ErrAnonymousCustomer, http.StatusUnauthorized},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				// Mock services.
+				q := &mock.Quote{}
+				q.OffersCall.Returns.Err = tc.ServiceErr
+
+				// Setup handler.
+				h := New()
+				h.Quote = q
+
+				// Make request.
+				nq := createTestNewQuote()
+				reqBody, err := json.Marshal(&nq)
+				is.NoErr(err)
+				r := httptest.NewRequest(http.MethodPost, "/api.v1/quotes/offers", bytes.NewBuffer(reqBody))
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+
+				// Assert response.
+				is.Equal(w.Code, tc.StatusCode)
+				var resp NoDataResponse
+				decodePayload(is, w.Body, &resp)
+				is.True(!resp.Success)
+			})
+		}
+	})
+
+	t.Run("invalid request fields", func(t *testing.T) {
+		is := is.New(t)
+
+		// Setup handler.
+		h := New()
+		h.Quote = &mock.Quote{}
+
+		// Make request.
+		nq := createTestNewQuote()
+		nq.ServiceLevel = "overnight"
+		reqBody, err := json.Marshal(&nq)
+		is.NoErr(err)
+		r := httptest.NewRequest(http.MethodPost, "/api.v1/quotes/offers", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusBadRequest)
+		var resp FieldErrorResponse
+		decodePayload(is, w.Body, &resp)
+		is.Equal(len(resp.FieldErrors), 1)
+		is.Equal(resp.FieldErrors[0].Field, "service_level")
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Authorization string
+		AccountErr    error
+
+		StatusCode int
+		AccountID  string
+	}{
+		{"anonymous", "", nil, http.StatusCreated, ""},
+		{"authenticated", "Bearer acme-key", nil, http.StatusCreated, acmeID},
+		{"unknown key", "Bearer banana", account.ErrUnauthenticated, http.StatusUnauthorized, ""},
+		{"not bearer", "Basic YWNtZTprZXk=", nil, http.StatusUnauthorized, ""},
+		{"empty key", "Bearer ", nil, http.StatusUnauthorized, ""},
+		{"unknown error", "Bearer acme-key", errors.New("some error"), http.StatusInternalServerError, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			// Mock services.
+			q := &mock.Quote{}
+			a := acmeAccount()
+			a.AuthenticateCall.Returns.Err = tc.AccountErr
+
+			// Setup handler.
+			h := New()
+			h.Quote = q
+			h.Account = a
+
+			// Make request.
+			nq := createTestNewQuote()
+			reqBody, err := json.Marshal(&nq)
+			is.NoErr(err)
+			r := httptest.NewRequest(http.MethodPost, "/api.v1/quotes", bytes.NewBuffer(reqBody))
+			if tc.Authorization != "" {
+				r.Header.Set("Authorization", tc.Authorization)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			// Assert response HTTP headers.
+			is.Equal(w.Code, tc.StatusCode)
+
+			// The quote is created on behalf of the authenticated account.
+			if tc.StatusCode == http.StatusCreated {
+				is.Equal(q.CreateCall.Recieves.Nq.AccountID, tc.AccountID)
+			}
+			if tc.Authorization == "Bearer acme-key" {
+				is.Equal(a.AuthenticateCall.Recieves.APIKey, "acme-key")
+			}
+		})
+	}
+}
+
+func TestHandleQuoteTransition(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Action        string
+		Owner         string
+		Authorization string
+		ServiceErr    error
+
+		StatusCode int
+	}{
+		{"accept", "accept", acmeID, "Bearer acme-key", nil, http.StatusOK},
+		{"reject", "reject", acmeID, "Bearer acme-key", nil, http.StatusOK},
+		{"anonymous request for account quote", "accept", acmeID, "", nil, http.StatusUnauthorized},
+		{"anonymous request for anonymous quote", "reject", "", "", nil, http.StatusUnauthorized},
+		{"account request for anonymous quote", "reject", "", "Bearer acme-key", nil, http.StatusForbidden},
+		{"quote of another account", "reject", validate.GenerateID(), "Bearer acme-key", nil, http.StatusForbidden},
+		{"quote not found", "accept", acmeID, "Bearer acme-key", quote.ErrNotFound, http.StatusBadRequest},
+		{"expired", "accept", acmeID, "Bearer acme-key", quote.ErrExpired, http.StatusConflict},
+		{"illegal transition", "reject", acmeID, "Bearer acme-key", fmt.Errorf("%w from accepted to rejected", quote.ErrInvalidTransition), http.StatusConflict},
+		{"unknown error", "reject", acmeID, "Bearer acme-key", errors.New("some error"), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			quoteID := validate.GenerateID()
+
+			// Mock services.
+			q := &mock.Quote{}
+			q.QueryByIDCall.Returns.Info = createTestQuote(quoteID)
+			q.QueryByIDCall.Returns.Info.AccountID = tc.Owner
+			q.AcceptCall.Returns.Info = createTestQuote(quoteID)
+			q.AcceptCall.Returns.Info.Status = quote.StatusAccepted
+			q.AcceptCall.Returns.Err = tc.ServiceErr
+			q.RejectCall.Returns.Info = createTestQuote(quoteID)
+			q.RejectCall.Returns.Info.Status = quote.StatusRejected
+			q.RejectCall.Returns.Err = tc.ServiceErr
+
+			// Setup handler.
+			h := New()
+			h.Quote = q
+			h.Account = acmeAccount()
+
+			// Make request.
+			r := httptest.NewRequest(http.MethodPost, "/api.v1/quotes/"+quoteID+"/"+tc.Action, nil)
+			if tc.Authorization != "" {
+				r.Header.Set("Authorization", tc.Authorization)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			// Assert response HTTP headers.
+			is.Equal(w.Code, tc.StatusCode)
+
+			// Assert response payload.
+			if tc.StatusCode != http.StatusOK {
+				var resp NoDataResponse
+				decodePayload(is, w.Body, &resp)
+				is.True(!resp.Success)
+				return
+			}
+			var resp QuoteResponse
+			decodePayload(is, w.Body, &resp)
+			is.True(resp.Success)
+			if tc.Action == "accept" {
+				is.Equal(q.AcceptCall.Recieves.ID, quoteID)
+				is.Equal(resp.Data.Quote.Status, quote.StatusAccepted)
+			} else {
+				is.Equal(q.RejectCall.Recieves.ID, quoteID)
+				is.Equal(resp.Data.Quote.Status, quote.StatusRejected)
+			}
+		})
+	}
+}
+
+func TestHandleAddBooking(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		is := is.New(t)
+
+		quoteID := validate.GenerateID()
+		pickup := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+		// Mock services.
+		b := &mock.Booking{}
+		b.CreateCall.Returns.Info = booking.Info{
+			ID:        validate.GenerateID(),
+			Reference: "BK-7F3K9Q2M",
+			QuoteID:   quoteID,
+			AccountID: acmeID,
+			From:      createTestCustomer("John Doe", "US"),
+			To:        createTestCustomer("Sven Svensson", "SE"),
+			Pickup:    booking.Window{From: pickup, Until: pickup.Add(8 * time.Hour)},
+			CreatedAt: pickup,
+		}
+
+		// Setup handler.
+		h := New()
+		h.Booking = b
+		h.Account = acmeAccount()
+
+		// Make request.
+		reqBody, err := json.Marshal(booking.NewBooking{QuoteID: quoteID})
+		is.NoErr(err)
+		r := httptest.NewRequest(http.MethodPost, "/api.v1/bookings", bytes.NewBuffer(reqBody))
+		r.Header.Set("Authorization", "Bearer acme-key")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusCreated)
+		var resp BookingResponse
+		decodePayload(is, w.Body, &resp)
+		is.True(resp.Success)
+		is.Equal(resp.Data.Booking, b.CreateCall.Returns.Info)
+		is.Equal(b.CreateCall.Recieves.Nb.QuoteID, quoteID)
+
+		// The quote is booked on behalf of the authenticated account.
+		is.Equal(b.CreateCall.Recieves.Nb.AccountID, acmeID)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			ServiceErr error
+
+			StatusCode int
+		}{
+			{"unknown error", errors.New("some error"), http.StatusInternalServerError},
+			{"quote not found", fmt.Errorf("looking up quote: %w", quote.ErrNotFound), http.StatusBadRequest},
+			{"quote not accepted", fmt.Errorf("booking quote: %w from issued to booked", quote.ErrInvalidTransition), http.StatusConflict},
+			{"quote expired", fmt.Errorf("booking quote: %w", quote.ErrExpired), http.StatusConflict},
+			{"quote of another account", booking.ErrForbidden, http.StatusForbidden},
+			{"quote being booked", booking.ErrPending, http.StatusConflict},
+			{"carrier unavailable", fmt.Errorf("booking with carrier: %w", carrier.ErrUnavailable), http.StatusServiceUnavailable},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				// Mock services.
+				b := &mock.Booking{}
+				b.CreateCall.Returns.Err = tc.ServiceErr
+
+				// Setup handler.
+				h := New()
+				h.Booking = b
+				h.Account = acmeAccount()
+
+				// Make request.
+				reqBody, err := json.Marshal(booking.NewBooking{QuoteID: validate.GenerateID()})
+				is.NoErr(err)
+				r := httptest.NewRequest(http.MethodPost, "/api.v1/bookings", bytes.NewBuffer(reqBody))
+				r.Header.Set("Authorization", "Bearer acme-key")
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+
+				// Assert response.
+				is.Equal(w.Code, tc.StatusCode)
+				var resp NoDataResponse
+				decodePayload(is, w.Body, &resp)
+				is.True(!resp.Success)
+			})
+		}
+	})
+
+	t.Run("invalid request fields", func(t *testing.T) {
+		is := is.New(t)
+
+		// Setup handler.
+		h := New()
+		h.Booking = &mock.Booking{}
+		h.Account = acmeAccount()
+
+		// Make request.
+		reqBody, err := json.Marshal(booking.NewBooking{QuoteID: "not a quote id"})
+		is.NoErr(err)
+		r := httptest.NewRequest(http.MethodPost, "/api.v1/bookings", bytes.NewBuffer(reqBody))
+		r.Header.Set("Authorization", "Bearer acme-key")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusBadRequest)
+		var resp FieldErrorResponse
+		decodePayload(is, w.Body, &resp)
+		is.Equal(len(resp.FieldErrors), 1)
+		is.Equal(resp.FieldErrors[0].Field, "quote_id")
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		is := is.New(t)
+
+		// Setup handler.
+		h := New()
+		b := &mock.Booking{}
+		h.Booking = b
+
+		// Make request.
+		reqBody, err := json.Marshal(booking.NewBooking{QuoteID: validate.GenerateID()})
+		is.NoErr(err)
+		r := httptest.NewRequest(http.MethodPost, "/api.v1/bookings", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusUnauthorized)
+		is.Equal(b.CreateCall.Recieves.Nb, booking.NewBooking{}) // Not booked.
+	})
+}
+
+func TestHandleGetBooking(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Owner         string
+		Authorization string
+		ServiceErr    error
+
+		StatusCode int
+	}{
+		{"own booking", acmeID, "Bearer acme-key", nil, http.StatusOK},
+		{"anonymous request for account booking", acmeID, "", nil, http.StatusUnauthorized},
+		{"booking of another account", validate.GenerateID(), "Bearer acme-key", nil, http.StatusForbidden},
+		{"booking not found", acmeID, "Bearer acme-key", booking.ErrNotFound, http.StatusBadRequest},
+		{"unknown error", acmeID, "Bearer acme-key", errors.New("some error"), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			bookingID := validate.GenerateID()
+
+			// Mock services.
+			b := &mock.Booking{}
+			b.QueryByIDCall.Returns.Info = booking.Info{ID: bookingID, Reference: "BK-7F3K9Q2M", QuoteID: validate.GenerateID(), AccountID: tc.Owner}
+			b.QueryByIDCall.Returns.Err = tc.ServiceErr
+
+			// Setup handler.
+			h := New()
+			h.Booking = b
+			h.Account = acmeAccount()
+
+			// Make request.
+			r := httptest.NewRequest(http.MethodGet, "/api.v1/bookings/"+bookingID, nil)
+			if tc.Authorization != "" {
+				r.Header.Set("Authorization", tc.Authorization)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			// Assert response.
+			is.Equal(w.Code, tc.StatusCode)
+			var resp BookingResponse
+			decodePayload(is, w.Body, &resp)
+			is.Equal(resp.Success, tc.StatusCode == http.StatusOK)
+			if tc.StatusCode == http.StatusOK {
+				is.Equal(resp.Data.Booking, b.QueryByIDCall.Returns.Info)
+				is.Equal(b.QueryByIDCall.Recieves.ID, bookingID)
+			}
+		})
+	}
+}
+
+func TestHandleListBookings(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		is := is.New(t)
+
+		// Mock services.
+		b := &mock.Booking{}
+		b.QueryCall.Returns.Bookings = []booking.Info{
+			{ID: validate.GenerateID(), Reference: "BK-7F3K9Q2M", QuoteID: validate.GenerateID(), AccountID: acmeID},
+			{ID: validate.GenerateID(), Reference: "BK-A2B3C4D5", QuoteID: validate.GenerateID(), AccountID: acmeID},
+		}
+
+		// Setup handler.
+		h := New()
+		h.Booking = b
+		h.Account = acmeAccount()
+
+		// Make request.
+		r := httptest.NewRequest(http.MethodGet, "/api.v1/bookings", nil)
+		r.Header.Set("Authorization", "Bearer acme-key")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusOK)
+		var resp BookingsResponse
+		decodePayload(is, w.Body, &resp)
+		is.Equal(resp.Data.Bookings, b.QueryCall.Returns.Bookings)
+		is.Equal(b.QueryCall.Recieves.AccountID, acmeID)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		is := is.New(t)
+
+		// Setup handler.
+		h := New()
+		h.Booking = &mock.Booking{}
+
+		// Make request.
+		r := httptest.NewRequest(http.MethodGet, "/api.v1/bookings", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusUnauthorized)
+		var resp NoDataResponse
+		decodePayload(is, w.Body, &resp)
+		is.True(!resp.Success)
+	})
+}
+
+func TestHandleAddCustomer(t *testing.T) {
+	t.Run("service result", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			ServiceErr error
+
+			StatusCode int
+		}{
+			{"ok", nil, http.StatusCreated},
+			{"duplicate", customer.ErrDuplicate, http.StatusConflict},
+			{"unknown error", errors.New("some error"), http.StatusInternalServerError},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				nc := createTestNewCustomer()
+
+				// Mock services.
+				c := &mock.Customer{}
+				c.CreateCall.Returns.Info = customer.Info{ID: validate.GenerateID(), AccountID: acmeID, Name: nc.Name}
+				c.CreateCall.Returns.Err = tc.ServiceErr
+
+				// Setup handler.
+				h := New()
+				h.Customer = c
+				h.Account = acmeAccount()
+
+				// Make request.
+				reqBody, err := json.Marshal(nc)
+				is.NoErr(err)
+				r := httptest.NewRequest(http.MethodPost, "/api.v1/customers", bytes.NewBuffer(reqBody))
+				r.Header.Set("Authorization", "Bearer acme-key")
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+
+				// Assert response.
+				is.Equal(w.Code, tc.StatusCode)
+				var resp CustomerResponse
+				decodePayload(is, w.Body, &resp)
+				is.Equal(resp.Success, tc.ServiceErr == nil)
+				nc.AccountID = acmeID
+				is.Equal(c.CreateCall.Recieves.Nc, nc)
+				if tc.ServiceErr == nil {
+					is.Equal(resp.Data.Customer, c.CreateCall.Returns.Info)
+				}
+			})
+		}
+	})
+
+	t.Run("invalid request fields", func(t *testing.T) {
+		is := is.New(t)
+
+		// Setup handler.
+		h := New()
+		h.Customer = &mock.Customer{}
+		h.Account = acmeAccount()
+
+		// Make request.
+		reqBody, err := json.Marshal(customer.NewCustomer{Name: "contains number 42", Email: "sven.svensson@test.com", Address: address.Address{Lines: []string{"Testgatan 42B"}, PostalCode: "411 24", City: "Göteborg", CountryCode: "SE"}})
+		is.NoErr(err)
+		r := httptest.NewRequest(http.MethodPost, "/api.v1/customers", bytes.NewBuffer(reqBody))
+		r.Header.Set("Authorization", "Bearer acme-key")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusBadRequest)
+		var resp FieldErrorResponse
+		decodePayload(is, w.Body, &resp)
+		is.Equal(len(resp.FieldErrors), 1)
+		is.Equal(resp.FieldErrors[0].Field, "name")
+	})
+
+	t.Run("untidy address", func(t *testing.T) {
+		is := is.New(t)
+
+		// Mock services.
+		c := &mock.Customer{}
+
+		// Setup handler.
+		h := New()
+		h.Customer = c
+		h.Account = acmeAccount()
+
+		// Make request.
+		nc := createTestNewCustomer()
+		untidy := nc
+		untidy.Address.Lines = []string{" Testgatan  42B"}
+		untidy.Address.CountryCode = "se"
+		reqBody, err := json.Marshal(untidy)
+		is.NoErr(err)
+		r := httptest.NewRequest(http.MethodPost, "/api.v1/customers", bytes.NewBuffer(reqBody))
+		r.Header.Set("Authorization", "Bearer acme-key")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusCreated) // Normalized before validation.
+		nc.AccountID = acmeID
+		is.Equal(c.CreateCall.Recieves.Nc, nc)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		is := is.New(t)
+
+		// Setup handler.
+		h := New()
+		h.Customer = &mock.Customer{}
+
+		// Make request.
+		reqBody, err := json.Marshal(createTestNewCustomer())
+		is.NoErr(err)
+		r := httptest.NewRequest(http.MethodPost, "/api.v1/customers", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusUnauthorized)
+		var resp NoDataResponse
+		decodePayload(is, w.Body, &resp)
+		is.True(!resp.Success)
+	})
+}
+
+func TestHandleUpdateCustomer(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		ID            string
+		Authorization string
+		ServiceErr    error
+
+		StatusCode int
+	}{
+		{"ok", validate.GenerateID(), "Bearer acme-key", nil, http.StatusOK},
+		{"customer not found", validate.GenerateID(), "Bearer acme-key", customer.ErrNotFound, http.StatusBadRequest},
+		{"duplicate", validate.GenerateID(), "Bearer acme-key", customer.ErrDuplicate, http.StatusConflict},
+		{"unknown error", validate.GenerateID(), "Bearer acme-key", errors.New("some error"), http.StatusInternalServerError},
+		{"malformed id", "banana", "Bearer acme-key", nil, http.StatusBadRequest},
+		{"anonymous", validate.GenerateID(), "", nil, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			// Mock services.
+			c := &mock.Customer{}
+			c.UpdateCall.Returns.Info = customer.Info{ID: tc.ID, Name: "Sven Svensson"}
+			c.UpdateCall.Returns.Err = tc.ServiceErr
+
+			// Setup handler.
+			h := New()
+			h.Customer = c
+			h.Account = acmeAccount()
+
+			// Make request.
+			reqBody, err := json.Marshal(createTestNewCustomer())
+			is.NoErr(err)
+			r := httptest.NewRequest(http.MethodPut, "/api.v1/customers/"+tc.ID, bytes.NewBuffer(reqBody))
+			if tc.Authorization != "" {
+				r.Header.Set("Authorization", tc.Authorization)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			// Assert response.
+			is.Equal(w.Code, tc.StatusCode)
+			var resp CustomerResponse
+			decodePayload(is, w.Body, &resp)
+			is.Equal(resp.Success, tc.StatusCode == http.StatusOK)
+			if resp.Success {
+				is.Equal(resp.Data.Customer, c.UpdateCall.Returns.Info)
+				is.Equal(c.UpdateCall.Recieves.ID, tc.ID)
+				is.Equal(c.UpdateCall.Recieves.Nc.AccountID, acmeID)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteCustomer(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Authorization string
+		ServiceErr    error
+
+		StatusCode int
+	}{
+		{"ok", "Bearer acme-key", nil, http.StatusOK},
+		{"customer not found", "Bearer acme-key", customer.ErrNotFound, http.StatusBadRequest},
+		{"unknown error", "Bearer acme-key", errors.New("some error"), http.StatusInternalServerError},
+		{"anonymous", "", nil, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			customerID := validate.GenerateID()
+
+			// Mock services.
+			c := &mock.Customer{}
+			c.DeleteCall.Returns.Err = tc.ServiceErr
+
+			// Setup handler.
+			h := New()
+			h.Customer = c
+			h.Account = acmeAccount()
+
+			// Make request.
+			r := httptest.NewRequest(http.MethodDelete, "/api.v1/customers/"+customerID, nil)
+			if tc.Authorization != "" {
+				r.Header.Set("Authorization", tc.Authorization)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			// Assert response.
+			is.Equal(w.Code, tc.StatusCode)
+			var resp NoDataResponse
+			decodePayload(is, w.Body, &resp)
+			is.Equal(resp.Success, tc.StatusCode == http.StatusOK)
+			if tc.Authorization != "" {
+				is.Equal(c.DeleteCall.Recieves.ID, customerID)
+				is.Equal(c.DeleteCall.Recieves.AccountID, acmeID)
+			}
+		})
+	}
+}
+
+func TestHandleGetCustomer(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Authorization string
+		ServiceErr    error
+
+		StatusCode int
+	}{
+		{"ok", "Bearer acme-key", nil, http.StatusOK},
+		{"customer not found", "Bearer acme-key", customer.ErrNotFound, http.StatusBadRequest},
+		{"unknown error", "Bearer acme-key", errors.New("some error"), http.StatusInternalServerError},
+		{"anonymous", "", nil, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			customerID := validate.GenerateID()
+
+			// Mock services.
+			c := &mock.Customer{}
+			c.QueryByIDCall.Returns.Info = customer.Info{ID: customerID, Name: "Sven Svensson"}
+			c.QueryByIDCall.Returns.Err = tc.ServiceErr
+
+			// Setup handler.
+			h := New()
+			h.Customer = c
+			h.Account = acmeAccount()
+
+			// Make request.
+			r := httptest.NewRequest(http.MethodGet, "/api.v1/customers/"+customerID, nil)
+			if tc.Authorization != "" {
+				r.Header.Set("Authorization", tc.Authorization)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			// Assert response.
+			is.Equal(w.Code, tc.StatusCode)
+			var resp CustomerResponse
+			decodePayload(is, w.Body, &resp)
+			is.Equal(resp.Success, tc.StatusCode == http.StatusOK)
+			if resp.Success {
+				is.Equal(resp.Data.Customer, c.QueryByIDCall.Returns.Info)
+				is.Equal(c.QueryByIDCall.Recieves.ID, customerID)
+				is.Equal(c.QueryByIDCall.Recieves.AccountID, acmeID)
+			}
+		})
+	}
+}
+
+func TestHandleListCustomers(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		is := is.New(t)
+
+		// Mock services.
+		c := &mock.Customer{}
+		c.QueryCall.Returns.Customers = []customer.Info{
+			{ID: validate.GenerateID(), AccountID: acmeID, Name: "John Doe"},
+			{ID: validate.GenerateID(), AccountID: acmeID, Name: "Sven Svensson"},
+		}
+		c.QueryCall.Returns.NextCursor = "next"
+
+		// Setup handler.
+		h := New()
+		h.Customer = c
+		h.Account = acmeAccount()
+
+		// Make request.
+		r := httptest.NewRequest(http.MethodGet, "/api.v1/customers?limit=2&cursor=prev", nil)
+		r.Header.Set("Authorization", "Bearer acme-key")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// Assert response.
+		is.Equal(w.Code, http.StatusOK)
+		var resp CustomersResponse
+		decodePayload(is, w.Body, &resp)
+		is.Equal(resp.Data.Customers, c.QueryCall.Returns.Customers)
+		is.Equal(resp.Data.NextCursor, "next")
+		is.Equal(c.QueryCall.Recieves.Filter, customer.Filter{AccountID: acmeID, Limit: 2, Cursor: "prev"})
+	})
+
+	t.Run("invalid query parameters", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Query string
+		}{
+			{"malformed limit", "limit=ten"},
+			{"limit too large", "limit=501"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				// Setup handler.
+				h := New()
+				h.Customer = &mock.Customer{}
+				h.Account = acmeAccount()
+
+				// Make request.
+				r := httptest.NewRequest(http.MethodGet, "/api.v1/customers?"+tc.Query, nil)
+				r.Header.Set("Authorization", "Bearer acme-key")
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+
+				// Assert response.
+				is.Equal(w.Code, http.StatusBadRequest)
+				var resp FieldErrorResponse
+				decodePayload(is, w.Body, &resp)
+				is.Equal(len(resp.FieldErrors), 1)
+				is.Equal(resp.FieldErrors[0].Field, "limit")
+			})
+		}
+	})
+
+	t.Run("service result", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Authorization string
+			ServiceErr    error
+
+			StatusCode int
+		}{
+			{"anonymous", "", nil, http.StatusUnauthorized},
+			{"invalid cursor", "Bearer acme-key", customer.ErrInvalidCursor, http.StatusBadRequest},
+			{"unknown error", "Bearer acme-key", errors.New("some error"), http.StatusInternalServerError},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				// Mock services.
+				c := &mock.Customer{}
+				c.QueryCall.Returns.Err = tc.ServiceErr
+
+				// Setup handler.
+				h := New()
+				h.Customer = c
+				h.Account = acmeAccount()
+
+				// Make request.
+				r := httptest.NewRequest(http.MethodGet, "/api.v1/customers?cursor=banana", nil)
+				if tc.Authorization != "" {
+					r.Header.Set("Authorization", tc.Authorization)
+				}
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+
+				// Assert response.
+				is.Equal(w.Code, tc.StatusCode)
+				var resp NoDataResponse
+				decodePayload(is, w.Body, &resp)
+				is.True(!resp.Success)
+			})
+		}
+	})
+}
+
+func createTestQuote(id string) quote.Info {
+	return quote.Info{
+		ID:               id,
+		From:             createTestCustomer("John Doe", "US"),
+		To:               createTestCustomer("Sven Svensson", "SE"),
+		Weight:           500,
+		Parcels:          []quote.Parcel{{Weight: 500, Quantity: 1, ChargeableWeight: 500}},
+		ChargeableWeight: 500,
+		ServiceLevel:     pricing.ServiceStandard,
+		TransitDays:      3,
+		ShipmentCost:     money.New(1250_00, "SEK"),
+		Tax:              pricing.Tax{Amount: money.New(0, "SEK")},
+		TotalCost:        money.New(1250_00, "SEK"),
+		Lane:             "outside_eu:nordic",
+		Breakdown: pricing.Breakdown{
+			{Kind: pricing.ItemBase, Description: "weight class 51-1000 kg", Amount: money.New(500_00, "SEK")},
+			{Kind: pricing.ItemFactor, Description: "region outside_eu", Factor: 2.5, Amount: money.New(750_00, "SEK")},
+			{Kind: pricing.ItemTax, Description: "VAT zero-rated international shipment", Amount: money.New(0, "SEK")},
+		},
+	}
+}
+
+func createTestNewQuote() quote.NewQuote {
+	return quote.NewQuote{
+		To: &quote.Customer{
+			Name:  "Sven Svensson",
+			Email: "sven.svensson@test.com",
+			Address: address.Address{
+				Lines:       []string{"Testgatan 42B"},
+				PostalCode:  "12345",
+				City:        "Göteborg",
+				CountryCode: "SE",
+			},
+		},
+		From: &quote.Customer{
+			Name:  "John Doe",
+			Email: "john.doe@test.com",
+			Address: address.Address{
+				Lines:       []string{"Teststreet 4242"},
+				PostalCode:  "55434",
+				City:        "Blaine",
+				Region:      "MN",
+				CountryCode: "US",
+			},
+		},
+		Weight: 500,
+	}
+}
+
+func createTestCustomer(name string, ccode string) quote.Customer {
+	return quote.Customer{
+		Name:  name,
+		Email: "example@test.com",
+		Address: address.Address{
+			Lines:       []string{"Vasagatan 5B"},
+			PostalCode:  "41124",
+			City:        "Göteborg",
+			CountryCode: ccode,
+		},
+	}
+}
+
+func createTestNewCustomer() customer.NewCustomer {
+	return customer.NewCustomer{
+		Name:  "Sven Svensson",
+		Email: "sven.svensson@test.com",
+		Address: address.Address{
+			Lines:       []string{"Testgatan 42B"},
+			PostalCode:  "12345",
+			City:        "Göteborg",
+			CountryCode: "SE",
+		},
+	}
+}
+
+// acmeID is the ID of the account authenticated by acmeAccount.
+const acmeID = "f8f4a8c2-3c1f-4e0b-9a57-0d2b8a8f0c11"
+
+// acmeAccount returns an account service authenticating every API key as the
+// account with acmeID.
+func acmeAccount() *mock.Account {
+	a := &mock.Account{}
+	a.AuthenticateCall.Returns.Info = account.Info{ID: acmeID, Name: "Acme AB"}
+	return a
 }
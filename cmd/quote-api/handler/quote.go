@@ -5,8 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/johanronkko/quote-service/internal/business/data/customer"
+	"github.com/johanronkko/quote-service/internal/business/data/promotion"
 	"github.com/johanronkko/quote-service/internal/business/data/quote"
+	"github.com/johanronkko/quote-service/internal/business/exchange"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
 	"github.com/johanronkko/quote-service/internal/business/region"
 	"github.com/johanronkko/quote-service/internal/business/validate"
 	"github.com/matryer/way"
@@ -14,13 +22,34 @@ import (
 
 // Quote manages the set of API's for quote access.
 type Quote interface {
-	// Query retrieves a list of existing quotes.
-	Query(ctx context.Context) ([]quote.Info, error)
+	// Query retrieves the page of the existing quotes of the account of
+	// filter selected by filter, and the cursor of the next page, empty on the
+	// last page. Returns quote.ErrInvalidCursor if the cursor of filter is not
+	// valid.
+	Query(ctx context.Context, filter quote.Filter) ([]quote.Info, string, error)
 	// QueryByID retrieves the quote with id. Returns quote.ErrNotFound if
 	// quote not found.
 	QueryByID(ctx context.Context, id string) (quote.Info, error)
-	// Create adds a quote to the system.
+	// Search finds at most limit quotes of the account with accountID with
+	// customers matching text, most relevant first. Returns
+	// quote.ErrEmptySearch if text has no terms.
+	Search(ctx context.Context, accountID, text string, limit int) ([]quote.Match, error)
+	// Create adds a quote to the system, priced under the contract of the
+	// account of nq if any. Returns quote.ErrAnonymousCustomer if nq
+	// references a customer but has no account, and customer.ErrNotFound if
+	// a customer referenced by nq does not exist.
 	Create(ctx context.Context, nq quote.NewQuote) (quote.Info, error)
+	// Offers prices a new quote at every offered service level without
+	// adding it to the system. Returns the same errors as Create.
+	Offers(ctx context.Context, nq quote.NewQuote) ([]quote.Offer, error)
+	// Accept accepts the issued quote with id. Returns quote.ErrExpired if
+	// the quote has expired and quote.ErrInvalidTransition if it is not
+	// issued.
+	Accept(ctx context.Context, id string) (quote.Info, error)
+	// Reject rejects the issued quote with id. Returns quote.ErrExpired if
+	// the quote has expired and quote.ErrInvalidTransition if it is not
+	// issued.
+	Reject(ctx context.Context, id string) (quote.Info, error)
 }
 
 func (h *Handler) handleGetQuote() http.HandlerFunc {
@@ -28,6 +57,11 @@ func (h *Handler) handleGetQuote() http.HandlerFunc {
 		Quote quote.Info `json:"quote"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
+		account := accountID(r.Context())
+		if account == "" {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		}
 		id := way.Param(r.Context(), "id")
 		if err := validate.CheckID(id); err != nil {
 			respond(w, r, http.StatusBadRequest, err)
@@ -41,25 +75,149 @@ func (h *Handler) handleGetQuote() http.HandlerFunc {
 			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
 			return
 		}
+		if q.AccountID != account {
+			respond(w, r, http.StatusForbidden, errForbidden)
+			return
+		}
 		respond(w, r, http.StatusOK, &response{q})
 	}
 }
 
 func (h *Handler) handleListQuotes() http.HandlerFunc {
 	type response struct {
-		Quotes []quote.Info `json:"quotes"`
+		Quotes     []quote.Info `json:"quotes"`
+		NextCursor string       `json:"next_cursor,omitempty"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
-		qs, err := h.Quote.Query(r.Context())
-		if err != nil {
+		account := accountID(r.Context())
+		if account == "" {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		}
+		filter, err := parseFilter(r.URL.Query())
+		var ferrors validate.FieldErrors
+		if errors.As(err, &ferrors) {
+			respond(w, r, http.StatusBadRequest, ferrors)
+			return
+		}
+		if err := validate.Check(filter); errors.As(err, &ferrors) {
+			respond(w, r, http.StatusBadRequest, ferrors)
+			return
+		} else if err != nil {
 			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
 			return
 		}
-		respond(w, r, http.StatusOK, &response{qs})
+		filter.AccountID = account
+		qs, next, err := h.Quote.Query(r.Context(), filter)
+		if errors.Is(err, quote.ErrInvalidCursor) {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		respond(w, r, http.StatusOK, &response{qs, next})
 	}
 
 }
 
+// parseFilter parses the query parameters of a request listing quotes.
+// Country codes are separated by commas, dates are RFC 3339 timestamps or
+// dates, and a sort order prefixed by "-" is descending. Returns
+// validate.FieldErrors for parameters that cannot be parsed.
+func parseFilter(params url.Values) (quote.Filter, error) {
+	var filter quote.Filter
+	var ferrors validate.FieldErrors
+
+	codes := func(name string) []string {
+		if params.Get(name) == "" {
+			return nil
+		}
+		return strings.Split(strings.ToUpper(params.Get(name)), ",")
+	}
+	integer := func(name string) *int64 {
+		if params.Get(name) == "" {
+			return nil
+		}
+		v, err := strconv.ParseInt(params.Get(name), 10, 64)
+		if err != nil {
+			ferrors = append(ferrors, validate.FieldError{Field: name, Error: name + " must be an integer"})
+			return nil
+		}
+		return &v
+	}
+	date := func(name string) *time.Time {
+		if params.Get(name) == "" {
+			return nil
+		}
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if t, err := time.Parse(layout, params.Get(name)); err == nil {
+				return &t
+			}
+		}
+		ferrors = append(ferrors, validate.FieldError{Field: name, Error: name + " must be a date"})
+		return nil
+	}
+
+	filter.FromCountryCodes = codes("from_country")
+	filter.ToCountryCodes = codes("to_country")
+	if v := integer("min_weight"); v != nil {
+		w := int(*v)
+		filter.MinWeight = &w
+	}
+	if v := integer("max_weight"); v != nil {
+		w := int(*v)
+		filter.MaxWeight = &w
+	}
+	filter.MinCost = integer("min_cost")
+	filter.MaxCost = integer("max_cost")
+	filter.Email = params.Get("email")
+	filter.CreatedFrom = date("created_from")
+	filter.CreatedUntil = date("created_until")
+	filter.Sort = strings.TrimPrefix(params.Get("sort"), "-")
+	filter.Descending = strings.HasPrefix(params.Get("sort"), "-")
+	if v := integer("limit"); v != nil {
+		filter.Limit = int(*v)
+	}
+	filter.Cursor = params.Get("cursor")
+
+	if len(ferrors) > 0 {
+		return quote.Filter{}, ferrors
+	}
+	return filter, nil
+}
+
+func (h *Handler) handleSearchQuotes() http.HandlerFunc {
+	type response struct {
+		Matches []quote.Match `json:"matches"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := accountID(r.Context())
+		if account == "" {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		}
+		text := r.URL.Query().Get("q")
+		var limit int
+		if v := r.URL.Query().Get("limit"); v != "" {
+			var err error
+			if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > quote.MaxSearchLimit {
+				respond(w, r, http.StatusBadRequest, validate.FieldErrors{{Field: "limit", Error: fmt.Sprintf("limit must be an integer between 1 and %d", quote.MaxSearchLimit)}})
+				return
+			}
+		}
+		ms, err := h.Quote.Search(r.Context(), account, text, limit)
+		if err == quote.ErrEmptySearch {
+			respond(w, r, http.StatusBadRequest, validate.FieldErrors{{Field: "q", Error: "q must contain a search term"}})
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		respond(w, r, http.StatusOK, &response{ms})
+	}
+}
+
 func (h *Handler) handleAddQuote() http.HandlerFunc {
 	type response struct {
 		Quote quote.Info `json:"quote"`
@@ -70,6 +228,7 @@ func (h *Handler) handleAddQuote() http.HandlerFunc {
 			respond(w, r, http.StatusBadRequest, fmt.Errorf("could not decode JSON"))
 			return
 		}
+		nq = nq.Normalize()
 		var ferrors validate.FieldErrors
 		if err := validate.Check(nq); errors.As(err, &ferrors) {
 			respond(w, r, http.StatusBadRequest, ferrors)
@@ -78,8 +237,12 @@ func (h *Handler) handleAddQuote() http.HandlerFunc {
 			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
 			return
 		}
+		nq.AccountID = accountID(r.Context())
 		q, err := h.Quote.Create(r.Context(), nq)
-		if err == region.ErrUnsupportedCountryCode {
+		if errors.Is(err, quote.ErrAnonymousCustomer) {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		} else if isBadNewQuote(err) {
 			respond(w, r, http.StatusBadRequest, err)
 			return
 		} else if err != nil {
@@ -89,3 +252,96 @@ func (h *Handler) handleAddQuote() http.HandlerFunc {
 		respond(w, r, http.StatusCreated, &response{q})
 	}
 }
+
+func (h *Handler) handleQuoteOffers() http.HandlerFunc {
+	type response struct {
+		Offers []quote.Offer `json:"offers"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		var nq quote.NewQuote
+		if err := decode(w, r, &nq); err != nil {
+			respond(w, r, http.StatusBadRequest, fmt.Errorf("could not decode JSON"))
+			return
+		}
+		nq = nq.Normalize()
+		var ferrors validate.FieldErrors
+		if err := validate.Check(nq); errors.As(err, &ferrors) {
+			respond(w, r, http.StatusBadRequest, ferrors)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		nq.AccountID = accountID(r.Context())
+		offers, err := h.Quote.Offers(r.Context(), nq)
+		if errors.Is(err, quote.ErrAnonymousCustomer) {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		} else if isBadNewQuote(err) {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		respond(w, r, http.StatusOK, &response{offers})
+	}
+}
+
+// handleQuoteTransition moves the quote with the id of the request to another
+// status using transition, e.g. Quote.Accept. Only the account that created
+// the quote may move it, so anonymous quotes cannot be moved.
+func (h *Handler) handleQuoteTransition(transition func(q Quote, ctx context.Context, id string) (quote.Info, error)) http.HandlerFunc {
+	type response struct {
+		Quote quote.Info `json:"quote"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := accountID(r.Context())
+		if account == "" {
+			respond(w, r, http.StatusUnauthorized, errAnonymous)
+			return
+		}
+		id := way.Param(r.Context(), "id")
+		if err := validate.CheckID(id); err != nil {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		}
+		q, err := h.Quote.QueryByID(r.Context(), id)
+		if err == quote.ErrNotFound {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		if q.AccountID != account {
+			respond(w, r, http.StatusForbidden, errForbidden)
+			return
+		}
+		q, err = transition(h.Quote, r.Context(), id)
+		if err == quote.ErrNotFound {
+			respond(w, r, http.StatusBadRequest, err)
+			return
+		} else if errors.Is(err, quote.ErrExpired) || errors.Is(err, quote.ErrInvalidTransition) {
+			respond(w, r, http.StatusConflict, err)
+			return
+		} else if err != nil {
+			respond(w, r, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+			return
+		}
+		respond(w, r, http.StatusOK, &response{q})
+	}
+}
+
+// isBadNewQuote reports whether err is caused by a new quote that cannot be
+// priced, has a promo code that cannot be redeemed, or references customers
+// that do not exist.
+func isBadNewQuote(err error) bool {
+	return errors.Is(err, customer.ErrNotFound) ||
+		errors.Is(err, region.ErrUnsupportedCountryCode) ||
+		errors.Is(err, exchange.ErrUnsupportedCurrency) ||
+		errors.Is(err, pricing.ErrInvalidWeight) ||
+		errors.Is(err, pricing.ErrUnsupportedServiceLevel) ||
+		errors.Is(err, promotion.ErrInvalidCode) ||
+		errors.Is(err, promotion.ErrLimitReached)
+}
@@ -4,7 +4,19 @@ import "net/http"
 
 func (s *Handler) routes() {
 	s.router.HandleFunc(http.MethodGet, "/api.v1/healthcheck", s.handleHealthCheck())
-	s.router.HandleFunc(http.MethodGet, "/api.v1/quotes/:id", s.handleGetQuote())
-	s.router.HandleFunc(http.MethodGet, "/api.v1/quotes", s.handleListQuotes())
-	s.router.HandleFunc(http.MethodPost, "/api.v1/quotes", s.handleAddQuote())
+	s.router.HandleFunc(http.MethodGet, "/api.v1/quotes/search", s.authenticate(s.handleSearchQuotes()))
+	s.router.HandleFunc(http.MethodGet, "/api.v1/quotes/:id", s.authenticate(s.handleGetQuote()))
+	s.router.HandleFunc(http.MethodGet, "/api.v1/quotes", s.authenticate(s.handleListQuotes()))
+	s.router.HandleFunc(http.MethodPost, "/api.v1/quotes", s.authenticate(s.handleAddQuote()))
+	s.router.HandleFunc(http.MethodPost, "/api.v1/quotes/offers", s.authenticate(s.handleQuoteOffers()))
+	s.router.HandleFunc(http.MethodPost, "/api.v1/quotes/:id/accept", s.authenticate(s.handleQuoteTransition(Quote.Accept)))
+	s.router.HandleFunc(http.MethodPost, "/api.v1/quotes/:id/reject", s.authenticate(s.handleQuoteTransition(Quote.Reject)))
+	s.router.HandleFunc(http.MethodGet, "/api.v1/bookings/:id", s.authenticate(s.handleGetBooking()))
+	s.router.HandleFunc(http.MethodGet, "/api.v1/bookings", s.authenticate(s.handleListBookings()))
+	s.router.HandleFunc(http.MethodPost, "/api.v1/bookings", s.authenticate(s.handleAddBooking()))
+	s.router.HandleFunc(http.MethodGet, "/api.v1/customers/:id", s.authenticate(s.handleGetCustomer()))
+	s.router.HandleFunc(http.MethodGet, "/api.v1/customers", s.authenticate(s.handleListCustomers()))
+	s.router.HandleFunc(http.MethodPost, "/api.v1/customers", s.authenticate(s.handleAddCustomer()))
+	s.router.HandleFunc(http.MethodPut, "/api.v1/customers/:id", s.authenticate(s.handleUpdateCustomer()))
+	s.router.HandleFunc(http.MethodDelete, "/api.v1/customers/:id", s.authenticate(s.handleDeleteCustomer()))
 }
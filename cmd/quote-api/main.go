@@ -8,12 +8,24 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/ardanlabs/conf"
 	"github.com/johanronkko/quote-service/cmd/quote-api/handler"
+	"github.com/johanronkko/quote-service/internal/business/carrier"
+	"github.com/johanronkko/quote-service/internal/business/data/account"
+	"github.com/johanronkko/quote-service/internal/business/data/booking"
+	"github.com/johanronkko/quote-service/internal/business/data/contract"
+	"github.com/johanronkko/quote-service/internal/business/data/country"
+	"github.com/johanronkko/quote-service/internal/business/data/customer"
 	"github.com/johanronkko/quote-service/internal/business/data/quote"
+	"github.com/johanronkko/quote-service/internal/business/delivery"
+	"github.com/johanronkko/quote-service/internal/business/exchange"
+	"github.com/johanronkko/quote-service/internal/business/geocode"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/region"
 	"github.com/johanronkko/quote-service/internal/foundation/database"
 )
 
@@ -50,6 +62,27 @@ func run(log *log.Logger) error {
 			Name       string `conf:"default:postgres"`
 			DisableTLS bool   `conf:"default:true"`
 		}
+		Pricing struct {
+			TariffFile     string `conf:"default:config/tariff.json"`
+			RatesFile      string `conf:"default:config/rates.json"`
+			SurchargesFile string `conf:"default:config/surcharges.json"`
+		}
+		Regions struct {
+			RefreshInterval time.Duration `conf:"default:5m"`
+		}
+		Delivery struct {
+			TransitFile string `conf:"default:config/transit.json"`
+		}
+		Geocoding struct {
+			PostalCodesFile string `conf:"default:config/postal-codes.csv"`
+		}
+		Quote struct {
+			Validity time.Duration `conf:"default:168h"`
+		}
+		Carriers struct {
+			Endpoints []string      `conf:"help:carrier APIs to rate shipments with as name=url separated by ;"`
+			Timeout   time.Duration `conf:"default:2s"`
+		}
 	}
 
 	const prefix = "QUOTE"
@@ -93,13 +126,138 @@ func run(log *log.Logger) error {
 		db.Close()
 	}()
 
+	// =========================================================================
+	// Start Region Cache
+
+	log.Println("main: Loading region catalogue")
+
+	regions := region.NewCache(country.New(db))
+	if err := regions.Refresh(context.Background()); err != nil {
+		return fmt.Errorf("loading region catalogue: %w", err)
+	}
+
+	// Keep the catalogue up to date so countries can be added without a restart.
+	stopRefresh := make(chan struct{})
+	defer close(stopRefresh)
+	go func() {
+		ticker := time.NewTicker(cfg.Regions.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := regions.Refresh(context.Background()); err != nil {
+					log.Printf("main: Refreshing region catalogue: %s", err)
+				}
+			case <-stopRefresh:
+				return
+			}
+		}
+	}()
+
+	// =========================================================================
+	// Load Pricing
+
+	log.Printf("main: Loading tariff: %s", cfg.Pricing.TariffFile)
+
+	tariff, err := pricing.LoadTariff(cfg.Pricing.TariffFile)
+	if err != nil {
+		return fmt.Errorf("loading tariff %q: %w", cfg.Pricing.TariffFile, err)
+	}
+
+	log.Printf("main: Loading surcharge rules: %s", cfg.Pricing.SurchargesFile)
+
+	rules, err := pricing.LoadSurchargeRules(cfg.Pricing.SurchargesFile)
+	if err != nil {
+		return fmt.Errorf("loading surcharge rules %q: %w", cfg.Pricing.SurchargesFile, err)
+	}
+
+	log.Printf("main: Loading exchange rates: %s", cfg.Pricing.RatesFile)
+
+	rates, err := exchange.LoadFile(cfg.Pricing.RatesFile)
+	if err != nil {
+		return fmt.Errorf("loading exchange rates %q: %w", cfg.Pricing.RatesFile, err)
+	}
+
+	// =========================================================================
+	// Start Carriers
+
+	// Shipments are rated and booked by the carriers when configured, with the
+	// tariff as the house carrier. Otherwise they are rated by the tariff only.
+	var calc pricing.ShipmentCostCalculator = tariff
+	var carriers []carrier.Carrier
+	if len(cfg.Carriers.Endpoints) > 0 {
+		client := &http.Client{Timeout: cfg.Carriers.Timeout}
+		carriers = make([]carrier.Carrier, len(cfg.Carriers.Endpoints))
+		for i, c := range cfg.Carriers.Endpoints {
+			parts := strings.SplitN(c, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("parsing carrier %q: expected name=url", c)
+			}
+			log.Printf("main: Rating with carrier %s: %s", parts[0], parts[1])
+			carriers[i] = carrier.NewHTTP(parts[0], parts[1], client)
+		}
+		calc = carrier.NewMarket(tariff, rates, cfg.Carriers.Timeout, carriers...)
+	}
+
+	// Surcharges are added to the cost of the shipment, whichever carrier
+	// rated it.
+	surcharges := pricing.NewSurcharges(calc, rules)
+
+	// Reload the surcharge rules on SIGHUP so they can be changed without a
+	// restart. Invalid rules are logged and the current rules are kept.
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+	stopReload := make(chan struct{})
+	defer close(stopReload)
+	go func() {
+		for {
+			select {
+			case <-reload:
+				log.Printf("main: Reloading surcharge rules: %s", cfg.Pricing.SurchargesFile)
+				rules, err := pricing.LoadSurchargeRules(cfg.Pricing.SurchargesFile)
+				if err != nil {
+					log.Printf("main: Reloading surcharge rules: %s", err)
+					continue
+				}
+				surcharges.Set(rules)
+			case <-stopReload:
+				return
+			}
+		}
+	}()
+
+	// =========================================================================
+	// Load Delivery Estimation
+
+	log.Printf("main: Loading transit rules: %s", cfg.Delivery.TransitFile)
+
+	transit, err := delivery.LoadTransit(cfg.Delivery.TransitFile)
+	if err != nil {
+		return fmt.Errorf("loading transit rules %q: %w", cfg.Delivery.TransitFile, err)
+	}
+
+	// =========================================================================
+	// Load Geocoding
+
+	log.Printf("main: Loading postal code centroids: %s", cfg.Geocoding.PostalCodesFile)
+
+	geocoder, err := geocode.LoadFile(cfg.Geocoding.PostalCodesFile)
+	if err != nil {
+		return fmt.Errorf("loading postal code centroids %q: %w", cfg.Geocoding.PostalCodesFile, err)
+	}
+
 	// =========================================================================
 	// Start API Service
 
 	log.Println("main: Initializing API support")
 
 	handler := handler.New()
-	handler.Quote = quote.New(db)
+	quotes := quote.New(log, db, regions, surcharges, rates, delivery.NewEstimator(delivery.SystemClock{}, transit), contract.New(db), geocoder, delivery.SystemClock{}, cfg.Quote.Validity)
+	handler.Quote = quotes
+	handler.Account = account.New(db)
+	handler.Booking = booking.New(db, quotes, delivery.SystemClock{}, carriers...)
+	handler.Customer = customer.New(db, delivery.SystemClock{})
 
 	// Make a channel to listen for an interrupt or terminate signal from the OS.
 	// Use a buffered channel because the signal package requires it.
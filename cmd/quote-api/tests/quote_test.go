@@ -2,15 +2,30 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/johanronkko/quote-service/cmd/quote-api/handler"
+	"github.com/johanronkko/quote-service/internal/business/address"
+	"github.com/johanronkko/quote-service/internal/business/data/account"
+	"github.com/johanronkko/quote-service/internal/business/data/booking"
+	"github.com/johanronkko/quote-service/internal/business/data/contract"
+	"github.com/johanronkko/quote-service/internal/business/data/country"
+	"github.com/johanronkko/quote-service/internal/business/data/customer"
 	"github.com/johanronkko/quote-service/internal/business/data/quote"
+	"github.com/johanronkko/quote-service/internal/business/delivery"
+	"github.com/johanronkko/quote-service/internal/business/exchange"
+	"github.com/johanronkko/quote-service/internal/business/geocode"
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/region"
 	"github.com/johanronkko/quote-service/internal/business/tests"
 	"github.com/johanronkko/quote-service/internal/business/validate"
 	"github.com/matryer/is"
@@ -35,13 +50,34 @@ type QuoteResponse struct {
 	} `json:"data"`
 }
 
+type OffersResponse struct {
+	NoDataResponse
+	Data struct {
+		Offers []quote.Offer `json:"offers"`
+	} `json:"data"`
+}
+
 type QuotesResponse struct {
 	NoDataResponse
 	Data struct {
-		Quotes []quote.Info `json:"quotes"`
+		Quotes     []quote.Info `json:"quotes"`
+		NextCursor string       `json:"next_cursor"`
 	} `json:"data"`
 }
 
+// do makes a request with the API key apiKey, or an anonymous request if
+// apiKey is empty.
+func do(is *is.I, method, url, apiKey string, body []byte) *http.Response {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	is.NoErr(err)
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	is.NoErr(err)
+	return resp
+}
+
 func decodePayload(is *is.I, r io.Reader, v interface{}) {
 	data, err := ioutil.ReadAll(r)
 	is.NoErr(err)
@@ -56,56 +92,230 @@ func TestQuotes(t *testing.T) {
 	db := tests.NewIntegration(t)
 	numSeededQuotes := 3
 
+	regions := region.NewCache(country.New(db))
+	is.NoErr(regions.Refresh(context.Background()))
+
 	handler := handler.New()
-	handler.Quote = quote.New(db)
+	quotes := quote.New(log.New(ioutil.Discard, "", 0), db, regions, pricing.FlatRate(), exchange.File{Base: "SEK"}, delivery.NewEstimator(delivery.SystemClock{}, delivery.Transit{}), contract.New(db), geocode.File{}, delivery.SystemClock{}, 24*time.Hour)
+	handler.Quote = quotes
+	handler.Account = account.New(db)
+	handler.Booking = booking.New(db, quotes, delivery.SystemClock{})
+	handler.Customer = customer.New(db, delivery.SystemClock{})
 
 	ts := httptest.NewServer(handler)
 	defer ts.Close()
 
-	// Is able to retrieve a list of quotes.
-	resp, err := http.Get(ts.URL + "/api.v1/quotes/")
-	is.NoErr(err)
+	// Is not able to list quotes without an API key.
+	resp := do(is, http.MethodGet, ts.URL+"/api.v1/quotes/", "", nil)
+	is.Equal(resp.StatusCode, http.StatusUnauthorized)
+
+	// Is able to retrieve a list of the quotes of the account.
+	resp = do(is, http.MethodGet, ts.URL+"/api.v1/quotes/", "acme-test-key", nil)
 	var quotesResponse QuotesResponse
 	json.NewDecoder(resp.Body).Decode(&quotesResponse)
 	is.Equal(quotesResponse.Code, http.StatusOK)
 	is.True(quotesResponse.Success)
 	is.Equal(len(quotesResponse.Data.Quotes), numSeededQuotes)
 
+	// Is able to page through quotes.
+	resp = do(is, http.MethodGet, ts.URL+"/api.v1/quotes/?sort=-total_cost&limit=2", "acme-test-key", nil)
+	var firstPage QuotesResponse
+	json.NewDecoder(resp.Body).Decode(&firstPage)
+	is.Equal(len(firstPage.Data.Quotes), 2)
+	is.True(firstPage.Data.NextCursor != "")
+	resp = do(is, http.MethodGet, ts.URL+"/api.v1/quotes/?sort=-total_cost&limit=2&cursor="+firstPage.Data.NextCursor, "acme-test-key", nil)
+	var lastPage QuotesResponse
+	json.NewDecoder(resp.Body).Decode(&lastPage)
+	is.Equal(len(lastPage.Data.Quotes), 1)
+	is.Equal(lastPage.Data.NextCursor, "")
+	is.Equal(lastPage.Data.Quotes[0].TotalCost, money.New(50000, "SEK")) // Cheapest seeded quote.
+
+	// Is not able to search quotes without an API key.
+	resp = do(is, http.MethodGet, ts.URL+"/api.v1/quotes/search?q=rose", "", nil)
+	is.Equal(resp.StatusCode, http.StatusUnauthorized)
+
+	// Is able to search the quotes of the account by customer.
+	resp = do(is, http.MethodGet, ts.URL+"/api.v1/quotes/search?q=rose", "acme-test-key", nil)
+	var searchResponse struct {
+		NoDataResponse
+		Data struct {
+			Matches []quote.Match `json:"matches"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&searchResponse)
+	is.NoErr(err)
+	is.Equal(searchResponse.Code, http.StatusOK)
+	is.Equal(len(searchResponse.Data.Matches), 1)
+	is.Equal(searchResponse.Data.Matches[0].Quote.ID, "32b0639f-2cc6-44b8-b97b-15d69dbb511e") // Seeded quote.
+
 	// Is able to add a new quote.
 	nq := quote.NewQuote{
-		To: quote.Customer{
-			Name:        "Sven Svensson",
-			Email:       "sven.svensson@test.com",
-			Address:     "Testgatan 42B, Göteborg 12345",
-			CountryCode: "SV",
+		To: &quote.Customer{
+			Name:  "Sven Svensson",
+			Email: "sven.svensson@test.com",
+			Address: address.Address{
+				Lines:       []string{"Testgatan 42B"},
+				PostalCode:  "12345",
+				City:        "Göteborg",
+				CountryCode: "SE",
+			},
 		},
-		From: quote.Customer{
-			Name:        "John Doe",
-			Email:       "john.doe@test.com",
-			Address:     "Teststreet 4242, Blaine 55434",
-			CountryCode: "US",
+		From: &quote.Customer{
+			Name:  "John Doe",
+			Email: "john.doe@test.com",
+			Address: address.Address{
+				Lines:       []string{"Teststreet 4242"},
+				PostalCode:  "55434",
+				City:        "Blaine",
+				Region:      "MN",
+				CountryCode: "US",
+			},
 		},
 		Weight: 500,
 	}
 	nqReqBody, err := json.Marshal(&nq)
 	is.NoErr(err)
-	resp, err = http.Post(ts.URL+"/api.v1/quotes/", "application/json", bytes.NewBuffer(nqReqBody))
-	is.NoErr(err)
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/quotes/", "", nqReqBody)
 	var newQuoteResponse QuoteResponse
 	err = json.NewDecoder(resp.Body).Decode(&newQuoteResponse)
 	is.NoErr(err)
 	is.Equal(newQuoteResponse.Code, http.StatusCreated)
 	is.True(newQuoteResponse.Success)
-	is.Equal(newQuoteResponse.Data.Quote.From, nq.From)
-	is.Equal(newQuoteResponse.Data.Quote.To, nq.To)
+	is.Equal(newQuoteResponse.Data.Quote.From, *nq.From)
+	is.Equal(newQuoteResponse.Data.Quote.To, *nq.To)
 	is.Equal(newQuoteResponse.Data.Quote.Weight, nq.Weight)
-	is.Equal(newQuoteResponse.Data.Quote.ShipmentCost, 2.5*2000) // From outside EU * huge package.
+	is.Equal(newQuoteResponse.Data.Quote.ShipmentCost, money.New(2.5*2000_00, "SEK")) // From outside EU * huge package.
+	is.Equal(newQuoteResponse.Data.Quote.TotalCost, money.New(2.5*2000_00, "SEK"))    // Exports are zero-rated.
+	is.Equal(newQuoteResponse.Data.Quote.Lane, "outside_eu:nordic")
 
-	// Is able to retrieve newly added quote.
-	resp, err = http.Get(ts.URL + "/api.v1/quotes/" + newQuoteResponse.Data.Quote.ID)
+	// Anonymous quotes cannot be retrieved or acted on.
+	resp = do(is, http.MethodGet, ts.URL+"/api.v1/quotes/"+newQuoteResponse.Data.Quote.ID, "", nil)
+	is.Equal(resp.StatusCode, http.StatusUnauthorized)
+	resp = do(is, http.MethodGet, ts.URL+"/api.v1/quotes/"+newQuoteResponse.Data.Quote.ID, "acme-test-key", nil)
+	is.Equal(resp.StatusCode, http.StatusForbidden)
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/quotes/"+newQuoteResponse.Data.Quote.ID+"/accept", "", nil)
+	is.Equal(resp.StatusCode, http.StatusUnauthorized)
+
+	// Authenticated accounts are priced under their contract.
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/quotes/", "acme-test-key", nqReqBody) // Seeded account.
+	var contractQuoteResponse QuoteResponse
+	err = json.NewDecoder(resp.Body).Decode(&contractQuoteResponse)
 	is.NoErr(err)
+	is.Equal(contractQuoteResponse.Code, http.StatusCreated)
+	is.Equal(contractQuoteResponse.Data.Quote.AccountID, "9d3a1b6e-2f4c-4b8e-a0d1-6c5e7f8a9b10")
+	is.Equal(contractQuoteResponse.Data.Quote.Contract, &quote.Contract{ID: "acme", Version: 1})
+	is.Equal(contractQuoteResponse.Data.Quote.ShipmentCost, money.New(0.9*2.5*2000_00, "SEK")) // 10% contract discount.
+
+	// Is able to retrieve newly added quote of the account.
+	resp = do(is, http.MethodGet, ts.URL+"/api.v1/quotes/"+contractQuoteResponse.Data.Quote.ID, "acme-test-key", nil)
 	var quoteByIDResponse QuoteResponse
 	err = json.NewDecoder(resp.Body).Decode(&quoteByIDResponse)
 	is.NoErr(err)
-	is.Equal(quoteByIDResponse.Data.Quote, newQuoteResponse.Data.Quote)
+	is.Equal(quoteByIDResponse.Data.Quote, contractQuoteResponse.Data.Quote)
+	is.Equal(quoteByIDResponse.Data.Quote.Status, quote.StatusIssued)
+
+	// Quotes are reported expired after their validity.
+	resp = do(is, http.MethodGet, ts.URL+"/api.v1/quotes/1cf37266-3473-4006-984f-9325122678b7", "acme-test-key", nil) // Seeded quote.
+	var expiredResponse QuoteResponse
+	err = json.NewDecoder(resp.Body).Decode(&expiredResponse)
+	is.NoErr(err)
+	is.Equal(expiredResponse.Data.Quote.Status, quote.StatusExpired)
+
+	// Is able to accept an issued quote, once.
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/quotes/"+contractQuoteResponse.Data.Quote.ID+"/accept", "acme-test-key", nil)
+	var acceptResponse QuoteResponse
+	err = json.NewDecoder(resp.Body).Decode(&acceptResponse)
+	is.NoErr(err)
+	is.Equal(acceptResponse.Code, http.StatusOK)
+	is.Equal(acceptResponse.Data.Quote.Status, quote.StatusAccepted)
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/quotes/"+contractQuoteResponse.Data.Quote.ID+"/reject", "acme-test-key", nil)
+	is.Equal(resp.StatusCode, http.StatusConflict)
+
+	// Is able to book an accepted quote, once.
+	nbReqBody, err := json.Marshal(booking.NewBooking{QuoteID: contractQuoteResponse.Data.Quote.ID})
+	is.NoErr(err)
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/bookings", "acme-test-key", nbReqBody)
+	var bookingResponse struct {
+		NoDataResponse
+		Data struct {
+			Booking booking.Info `json:"booking"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&bookingResponse)
+	is.NoErr(err)
+	is.Equal(bookingResponse.Code, http.StatusCreated)
+	is.Equal(bookingResponse.Data.Booking.QuoteID, contractQuoteResponse.Data.Quote.ID)
+	is.Equal(bookingResponse.Data.Booking.From, *nq.From)
+	is.Equal(bookingResponse.Data.Booking.Status, booking.StatusConfirmed)
+	is.True(bookingResponse.Data.Booking.Reference != "")
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/bookings", "acme-test-key", nbReqBody)
+	is.Equal(resp.StatusCode, http.StatusConflict)
+	resp = do(is, http.MethodGet, ts.URL+"/api.v1/bookings/"+bookingResponse.Data.Booking.ID, "", nil)
+	is.Equal(resp.StatusCode, http.StatusUnauthorized)
+
+	// Expired quotes cannot be accepted.
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/quotes/1cf37266-3473-4006-984f-9325122678b7/accept", "acme-test-key", nil)
+	is.Equal(resp.StatusCode, http.StatusConflict)
+
+	// Is able to get offers for every service level.
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/quotes/offers", "", nqReqBody)
+	var offersResponse OffersResponse
+	err = json.NewDecoder(resp.Body).Decode(&offersResponse)
+	is.NoErr(err)
+	is.Equal(offersResponse.Code, http.StatusOK)
+	is.Equal(len(offersResponse.Data.Offers), 3)
+	is.Equal(offersResponse.Data.Offers[1].ShipmentCost, newQuoteResponse.Data.Quote.ShipmentCost) // Standard.
+
+	// Unknown API keys are rejected.
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/quotes/", "banana", nqReqBody)
+	is.Equal(resp.StatusCode, http.StatusUnauthorized)
+
+	// Is able to save a customer to the address book of an account, and quote
+	// by its ID.
+	ncReqBody, err := json.Marshal(customer.NewCustomer{
+		Name:  "Kari Nordmann",
+		Email: "kari.nordmann@test.com",
+		Address: address.Address{
+			Lines:       []string{"Testveien 1"},
+			PostalCode:  "0150",
+			City:        "Oslo",
+			CountryCode: "NO",
+		},
+	})
+	is.NoErr(err)
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/customers", "", ncReqBody)
+	is.Equal(resp.StatusCode, http.StatusUnauthorized) // Anonymous.
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/customers", "acme-test-key", ncReqBody)
+	var customerResponse struct {
+		NoDataResponse
+		Data struct {
+			Customer customer.Info `json:"customer"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&customerResponse)
+	is.NoErr(err)
+	is.Equal(customerResponse.Code, http.StatusCreated)
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/customers", "acme-test-key", ncReqBody)
+	is.Equal(resp.StatusCode, http.StatusConflict)
+	byIDReqBody, err := json.Marshal(quote.NewQuote{
+		ToCustomerID:   customerResponse.Data.Customer.ID,
+		FromCustomerID: contractQuoteResponse.Data.Quote.FromCustomerID, // Saved with the quote.
+		Weight:         10,
+	})
+	is.NoErr(err)
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/quotes/", "", byIDReqBody)
+	is.Equal(resp.StatusCode, http.StatusUnauthorized) // Only accounts have an address book.
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/quotes/", "acme-test-key", byIDReqBody)
+	var byIDResponse QuoteResponse
+	err = json.NewDecoder(resp.Body).Decode(&byIDResponse)
+	is.NoErr(err)
+	is.Equal(byIDResponse.Code, http.StatusCreated)
+	is.Equal(byIDResponse.Data.Quote.To.Name, "Kari Nordmann")
+	is.Equal(byIDResponse.Data.Quote.From, *nq.From)
+
+	// Deleted customers can no longer be quoted.
+	resp = do(is, http.MethodDelete, ts.URL+"/api.v1/customers/"+customerResponse.Data.Customer.ID, "acme-test-key", nil)
+	is.Equal(resp.StatusCode, http.StatusOK)
+	resp = do(is, http.MethodPost, ts.URL+"/api.v1/quotes/", "acme-test-key", byIDReqBody)
+	is.Equal(resp.StatusCode, http.StatusBadRequest)
 }
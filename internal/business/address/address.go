@@ -0,0 +1,42 @@
+// Package address defines the postal addresses of senders and receivers.
+package address
+
+import "strings"
+
+// Address is a postal address. Lines are the street address, e.g. the street
+// and house number followed by any apartment or c/o line. Which of the postal
+// code, city and region an address must have, and the format of the postal
+// code, depend on the country of the address and are checked by the validate
+// package.
+type Address struct {
+	Lines       []string `json:"lines" validate:"required,min=1,max=3,dive,required,max=100"`
+	PostalCode  string   `json:"postal_code,omitempty"`
+	City        string   `json:"city,omitempty" validate:"max=50"`
+	Region      string   `json:"region,omitempty" validate:"max=50"`
+	CountryCode string   `json:"country_code" validate:"required,iso3166_1_alpha2"`
+}
+
+// Normalize returns a with runs of whitespace in its fields collapsed to a
+// single space and leading and trailing whitespace removed, without blank
+// lines, and with the postal code and country code in upper case.
+func (a Address) Normalize() Address {
+	lines := make([]string, 0, len(a.Lines))
+	for _, l := range a.Lines {
+		if l = squash(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return Address{
+		Lines:       lines,
+		PostalCode:  strings.ToUpper(squash(a.PostalCode)),
+		City:        squash(a.City),
+		Region:      squash(a.Region),
+		CountryCode: strings.ToUpper(squash(a.CountryCode)),
+	}
+}
+
+// squash returns s with runs of whitespace collapsed to a single space and
+// leading and trailing whitespace removed.
+func squash(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,45 @@
+package address
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Address Address
+
+		Want Address
+	}{
+		{
+			"normalized",
+			Address{Lines: []string{"Testgatan 42B"}, PostalCode: "411 24", City: "Göteborg", CountryCode: "SE"},
+			Address{Lines: []string{"Testgatan 42B"}, PostalCode: "411 24", City: "Göteborg", CountryCode: "SE"},
+		},
+		{
+			"whitespace",
+			Address{Lines: []string{"  Testgatan \t 42B "}, PostalCode: " 411  24", City: "Göteborg\n", CountryCode: "SE"},
+			Address{Lines: []string{"Testgatan 42B"}, PostalCode: "411 24", City: "Göteborg", CountryCode: "SE"},
+		},
+		{
+			"blank lines",
+			Address{Lines: []string{"Testgatan 42B", " ", "c/o Karlsson"}, PostalCode: "41124", City: "Göteborg", CountryCode: "SE"},
+			Address{Lines: []string{"Testgatan 42B", "c/o Karlsson"}, PostalCode: "41124", City: "Göteborg", CountryCode: "SE"},
+		},
+		{
+			"upper case codes",
+			Address{Lines: []string{"10 Downing Street"}, PostalCode: "sw1a 2aa", City: "London", CountryCode: "gb"},
+			Address{Lines: []string{"10 Downing Street"}, PostalCode: "SW1A 2AA", City: "London", CountryCode: "GB"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			is.Equal(tc.Address.Normalize(), tc.Want)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Package carrier contains adapters for the carriers shipments are rated and
+// booked with, and a market asking several carriers for offers at once.
+package carrier
+
+import (
+	"context"
+	"errors"
+
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/validate"
+)
+
+var (
+	// ErrUnavailable occurs when a carrier cannot be reached or fails to
+	// answer.
+	ErrUnavailable = errors.New("carrier unavailable")
+
+	// ErrUnknownBooking occurs when cancelling a booking the carrier does not
+	// know of.
+	ErrUnknownBooking = errors.New("unknown booking")
+)
+
+// Carrier rates and books shipments with a carrier.
+type Carrier interface {
+	// Name returns the name of the carrier.
+	Name() string
+	// Rate returns the cost of shipping s with the carrier. The cost is rated
+	// at the service level of s.
+	Rate(ctx context.Context, s pricing.Shipment) (pricing.Cost, error)
+	// Book books the shipment of s with the carrier, and returns the
+	// reference of the booking at the carrier.
+	Book(ctx context.Context, s pricing.Shipment) (string, error)
+	// Cancel cancels the booking with reference. Returns ErrUnknownBooking if
+	// the carrier does not know of the booking.
+	Cancel(ctx context.Context, reference string) error
+}
+
+// HouseName is the name of the house carrier.
+const HouseName = "house"
+
+// House is the in-house carrier, rating shipments by a ShipmentCostCalculator.
+// It is the fallback of a Market when no other carrier offers to ship.
+type House struct {
+	calc pricing.ShipmentCostCalculator
+}
+
+// NewHouse constructs the house carrier rating shipments by calc.
+func NewHouse(calc pricing.ShipmentCostCalculator) House {
+	return House{calc}
+}
+
+// Name implements Carrier.
+func (House) Name() string {
+	return HouseName
+}
+
+// Rate implements Carrier.
+func (h House) Rate(ctx context.Context, s pricing.Shipment) (pricing.Cost, error) {
+	cost, err := pricing.ShipmentCostContext(ctx, h.calc, s)
+	if err != nil {
+		return pricing.Cost{}, err
+	}
+	cost.Carrier = HouseName
+	return cost, nil
+}
+
+// Book implements Carrier. Shipments are booked in-house, so the reference
+// is generated locally.
+func (House) Book(ctx context.Context, s pricing.Shipment) (string, error) {
+	return validate.GenerateID(), nil
+}
+
+// Cancel implements Carrier. In-house bookings are cancelled by the caller.
+func (House) Cancel(ctx context.Context, reference string) error {
+	return nil
+}
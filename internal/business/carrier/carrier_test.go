@@ -0,0 +1,190 @@
+package carrier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/carrier/carriertest"
+	"github.com/johanronkko/quote-service/internal/business/exchange"
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/region"
+	"github.com/matryer/is"
+)
+
+// countries is the catalogue used to look up countries in tests.
+var countries = region.NewCache(nil)
+
+func shipment(tb testing.TB, from, to string, parcels ...pricing.Parcel) pricing.Shipment {
+	f, err := countries.Country(from)
+	if err != nil {
+		tb.Fatalf("country %q: %s", from, err)
+	}
+	t, err := countries.Country(to)
+	if err != nil {
+		tb.Fatalf("country %q: %s", to, err)
+	}
+	return pricing.Shipment{Parcels: parcels, From: f, To: t}
+}
+
+// levels are the service levels served by fake carriers in tests.
+var levels = map[string]int{pricing.ServiceStandard: 2, pricing.ServiceExpress: 1}
+
+func TestHTTP(t *testing.T) {
+	is := is.New(t)
+
+	server := carriertest.NewServer(carriertest.Config{PricePerKg: 10_00, Currency: "SEK", TransitDays: levels})
+	defer server.Close()
+	c := NewHTTP("fake", server.URL, server.Client())
+	ctx := context.Background()
+
+	// Packages are rated by their chargeable weight.
+	s := shipment(t, "SE", "NO",
+		pricing.Parcel{Weight: 5, Quantity: 2},
+		pricing.Parcel{Weight: 1, Dimensions: pricing.Dimensions{Length: 50, Width: 20, Height: 20}, Quantity: 1},
+	)
+	cost, err := c.Rate(ctx, s)
+	is.NoErr(err)
+	is.Equal(cost.Carrier, "fake")
+	is.Equal(cost.Amount, money.New(14*10_00, "SEK"))
+	is.Equal(cost.ChargeableWeight, 14)
+	is.Equal(cost.ChargeableWeights, []int{5, 4})
+	is.Equal(cost.ServiceLevel.Name, pricing.ServiceStandard)
+	is.Equal(cost.ServiceLevel.TransitDays, 2)
+	is.Equal(cost.Lane, s.Lane())
+	total, err := cost.Breakdown.Total()
+	is.NoErr(err)
+	is.Equal(total, cost.Amount)
+
+	// Service levels not served by the carrier are not rated.
+	s.ServiceLevel = pricing.ServiceEconomy
+	_, err = c.Rate(ctx, s)
+	is.True(errors.Is(err, ErrUnavailable))
+	s.ServiceLevel = ""
+
+	// Bookings can be cancelled once.
+	reference, err := c.Book(ctx, s)
+	is.NoErr(err)
+	is.Equal(server.Bookings(), []string{reference})
+	is.NoErr(c.Cancel(ctx, reference))
+	is.Equal(server.Bookings(), []string{})
+	is.Equal(c.Cancel(ctx, reference), ErrUnknownBooking)
+
+	// Failing carriers are unavailable.
+	failing := carriertest.NewServer(carriertest.Config{Status: http.StatusInternalServerError})
+	defer failing.Close()
+	_, err = NewHTTP("failing", failing.URL, failing.Client()).Rate(ctx, s)
+	is.True(errors.Is(err, ErrUnavailable))
+}
+
+func TestMarket(t *testing.T) {
+	cheap := carriertest.NewServer(carriertest.Config{PricePerKg: 10_00, Currency: "SEK", TransitDays: levels})
+	defer cheap.Close()
+	pricey := carriertest.NewServer(carriertest.Config{PricePerKg: 30_00, Currency: "SEK", TransitDays: levels})
+	defer pricey.Close()
+	euro := carriertest.NewServer(carriertest.Config{PricePerKg: 2_00, Currency: "EUR", TransitDays: levels})
+	defer euro.Close()
+	slow := carriertest.NewServer(carriertest.Config{PricePerKg: 1_00, Currency: "SEK", TransitDays: levels, Delay: time.Second})
+	defer slow.Close()
+	failing := carriertest.NewServer(carriertest.Config{Status: http.StatusServiceUnavailable})
+	defer failing.Close()
+
+	carrier := func(name string, s *carriertest.Server) Carrier {
+		return NewHTTP(name, s.URL, s.Client())
+	}
+	rates := exchange.File{Base: "SEK", Rates: map[string]float64{"EUR": 0.1}}
+
+	cases := []struct {
+		Name string
+
+		Carriers     []Carrier
+		ServiceLevel string
+
+		Want []money.Money // Offers, cheapest first.
+		Best string
+	}{
+		{"no carriers", nil, "", []money.Money{money.New(100_00, "SEK")}, HouseName},
+		{"cheapest first", []Carrier{carrier("pricey", pricey), carrier("cheap", cheap)}, "", []money.Money{money.New(50_00, "SEK"), money.New(150_00, "SEK")}, "cheap"},
+		{"converted", []Carrier{carrier("pricey", pricey), carrier("euro", euro)}, "", []money.Money{money.New(100_00, "SEK"), money.New(150_00, "SEK")}, "euro"},
+		{"timeout", []Carrier{carrier("slow", slow), carrier("pricey", pricey)}, "", []money.Money{money.New(150_00, "SEK")}, "pricey"},
+		{"failing", []Carrier{carrier("failing", failing), carrier("pricey", pricey)}, "", []money.Money{money.New(150_00, "SEK")}, "pricey"},
+		{"house fallback", []Carrier{carrier("slow", slow), carrier("failing", failing)}, "", []money.Money{money.New(100_00, "SEK")}, HouseName},
+		{"level not served", []Carrier{carrier("cheap", cheap)}, pricing.ServiceEconomy, []money.Money{money.New(80_00, "SEK")}, HouseName},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			m := NewMarket(pricing.FlatRate(), rates, 100*time.Millisecond, tc.Carriers...)
+			s := shipment(t, "SE", "SE", pricing.Parcel{Weight: 5, Quantity: 1})
+			s.ServiceLevel = tc.ServiceLevel
+
+			offers, err := m.Offers(context.Background(), s)
+			is.NoErr(err)
+			got := []money.Money{}
+			for _, offer := range offers {
+				got = append(got, offer.Amount)
+			}
+			is.Equal(got, tc.Want)
+
+			best, err := m.ShipmentCost(s)
+			is.NoErr(err)
+			is.Equal(best.Carrier, tc.Best)
+		})
+	}
+
+	t.Run("contract and surcharges", func(t *testing.T) {
+		is := is.New(t)
+
+		m := NewMarket(pricing.FlatRate(), rates, 100*time.Millisecond, carrier("pricey", pricey), carrier("cheap", cheap))
+		s := shipment(t, "SE", "SE", pricing.Parcel{Weight: 5, Quantity: 1})
+		s.Contract = &pricing.Contract{ID: "acme", Version: 1, Discount: 10}
+
+		// Carrier offers are discounted by the contract.
+		offers, err := m.Offers(context.Background(), s)
+		is.NoErr(err)
+		is.Equal(len(offers), 2)
+		is.Equal(offers[0].Amount, money.New(45_00, "SEK"))
+		is.Equal(offers[1].Amount, money.New(135_00, "SEK"))
+
+		// Surcharges are added to the best offer.
+		calc := pricing.NewSurcharges(m, pricing.SurchargeRules{Rules: []pricing.SurchargeRule{{Name: "fuel", Percent: 10}}})
+		cost, err := pricing.ShipmentCostContext(context.Background(), calc, s)
+		is.NoErr(err)
+		is.Equal(cost.Carrier, "cheap")
+		is.Equal(cost.Amount, money.New(49_50, "SEK"))
+		kinds := []string{}
+		for _, item := range cost.Breakdown {
+			kinds = append(kinds, item.Kind)
+		}
+		is.Equal(kinds, []string{pricing.ItemBase, pricing.ItemDiscount, pricing.ItemSurcharge})
+		total, err := cost.Breakdown.Total()
+		is.NoErr(err)
+		is.Equal(total, cost.Amount)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		is := is.New(t)
+
+		// Carriers are not waited for once the context of the request is done,
+		// and the house carrier rates the shipment.
+		m := NewMarket(pricing.FlatRate(), rates, 100*time.Millisecond, carrier("cheap", cheap))
+		calc := pricing.NewSurcharges(m, pricing.SurchargeRules{})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		cost, err := pricing.ShipmentCostContext(ctx, calc, shipment(t, "SE", "SE", pricing.Parcel{Weight: 5, Quantity: 1}))
+		is.NoErr(err)
+		is.Equal(cost.Carrier, HouseName)
+	})
+
+	t.Run("house errors", func(t *testing.T) {
+		is := is.New(t)
+
+		m := NewMarket(pricing.FlatRate(), rates, 100*time.Millisecond, carrier("cheap", cheap))
+		_, err := m.Offers(context.Background(), shipment(t, "SE", "SE", pricing.Parcel{Weight: 2000, Quantity: 1}))
+		is.Equal(err, pricing.ErrInvalidWeight)
+	})
+}
@@ -0,0 +1,171 @@
+// Package carriertest provides a fake carrier with a JSON API over HTTP, for
+// testing the carrier adapters.
+package carriertest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+)
+
+// Config configures a fake carrier.
+type Config struct {
+	// PricePerKg is the price in minor units of Currency per chargeable kg.
+	PricePerKg int64
+	Currency   string
+	// TransitDays are the transit days of the served service levels, by
+	// service level name. Other service levels are not served.
+	TransitDays map[string]int
+	// Delay delays every response, e.g. to make the carrier time out.
+	Delay time.Duration
+	// Status, if set, is the status every request fails with.
+	Status int
+}
+
+// Server is a fake carrier. Packages are charged by the greater of their
+// actual and volumetric weight, using a divisor of 5000.
+type Server struct {
+	*httptest.Server
+	cfg Config
+
+	mu       sync.Mutex
+	bookings map[string]bool
+}
+
+// NewServer starts a fake carrier configured by cfg. The caller should call
+// Close when finished, to shut it down.
+func NewServer(cfg Config) *Server {
+	s := Server{cfg: cfg, bookings: make(map[string]bool)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return &s
+}
+
+// Bookings returns the references of the bookings that are not cancelled.
+func (s *Server) Bookings() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	references := []string{}
+	for reference := range s.bookings {
+		references = append(references, reference)
+	}
+	return references
+}
+
+type shipmentRequest struct {
+	ServiceLevel string `json:"service_level"`
+	Parcels      []struct {
+		Weight   int `json:"weight"`
+		Length   int `json:"length"`
+		Width    int `json:"width"`
+		Height   int `json:"height"`
+		Quantity int `json:"quantity"`
+	} `json:"parcels"`
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-time.After(s.cfg.Delay):
+	case <-r.Context().Done():
+		return
+	}
+	if s.cfg.Status != 0 {
+		w.WriteHeader(s.cfg.Status)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/rates":
+		s.rate(w, r)
+	case r.Method == http.MethodPost && r.URL.Path == "/bookings":
+		s.book(w, r)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/bookings/"):
+		s.cancel(w, strings.TrimPrefix(r.URL.Path, "/bookings/"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *Server) rate(w http.ResponseWriter, r *http.Request) {
+	var req shipmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	transitDays, ok := s.cfg.TransitDays[req.ServiceLevel]
+	if !ok {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	resp := struct {
+		Amount            int64  `json:"amount"`
+		Currency          string `json:"currency"`
+		TransitDays       int    `json:"transit_days"`
+		ChargeableWeights []int  `json:"chargeable_weights"`
+	}{
+		Currency:          s.cfg.Currency,
+		TransitDays:       transitDays,
+		ChargeableWeights: make([]int, len(req.Parcels)),
+	}
+	for i, p := range req.Parcels {
+		weight := p.Weight
+		d := pricing.Dimensions{Length: p.Length, Width: p.Width, Height: p.Height}
+		if v := pricing.VolumetricWeight(d, 5000); v > weight {
+			weight = v
+		}
+		resp.ChargeableWeights[i] = weight
+		resp.Amount += int64(weight*p.Quantity) * s.cfg.PricePerKg
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) book(w http.ResponseWriter, r *http.Request) {
+	var req shipmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.cfg.TransitDays[req.ServiceLevel]; !ok {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	reference := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	s.bookings[reference] = true
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, struct {
+		Reference string `json:"reference"`
+	}{reference})
+}
+
+func (s *Server) cancel(w http.ResponseWriter, reference string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.bookings[reference] {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	delete(s.bookings, reference)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
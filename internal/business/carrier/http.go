@@ -0,0 +1,187 @@
+package carrier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+)
+
+// HTTP is a carrier with a JSON API over HTTP. Shipments are rated by
+// POST /rates, booked by POST /bookings and cancelled by
+// DELETE /bookings/{reference}. Amounts are in minor units.
+type HTTP struct {
+	name    string
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTP constructs the carrier with name and the API at baseURL, called
+// with client.
+func NewHTTP(name, baseURL string, client *http.Client) HTTP {
+	return HTTP{name, baseURL, client}
+}
+
+// shipmentRequest is the body of rate and booking requests.
+type shipmentRequest struct {
+	ServiceLevel   string          `json:"service_level"`
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	ToPostalCode   string          `json:"to_postal_code,omitempty"`
+	DangerousGoods bool            `json:"dangerous_goods,omitempty"`
+	Parcels        []parcelRequest `json:"parcels"`
+}
+
+type parcelRequest struct {
+	Weight   int `json:"weight"`
+	Length   int `json:"length,omitempty"`
+	Width    int `json:"width,omitempty"`
+	Height   int `json:"height,omitempty"`
+	Quantity int `json:"quantity"`
+}
+
+// rateResponse is the body of rate responses. ChargeableWeights are in the
+// order of the parcels of the request.
+type rateResponse struct {
+	Amount            int64  `json:"amount"`
+	Currency          string `json:"currency"`
+	TransitDays       int    `json:"transit_days"`
+	ChargeableWeights []int  `json:"chargeable_weights"`
+}
+
+// bookingResponse is the body of booking responses.
+type bookingResponse struct {
+	Reference string `json:"reference"`
+}
+
+// Name implements Carrier.
+func (h HTTP) Name() string {
+	return h.name
+}
+
+// Rate implements Carrier.
+func (h HTTP) Rate(ctx context.Context, s pricing.Shipment) (pricing.Cost, error) {
+	var resp rateResponse
+	if err := h.do(ctx, http.MethodPost, "/rates", toShipmentRequest(s), http.StatusOK, &resp); err != nil {
+		return pricing.Cost{}, err
+	}
+	if len(resp.ChargeableWeights) != len(s.Parcels) {
+		return pricing.Cost{}, fmt.Errorf("%s: %d chargeable weights for %d parcels: %w", h.name, len(resp.ChargeableWeights), len(s.Parcels), ErrUnavailable)
+	}
+
+	amount := money.New(resp.Amount, resp.Currency)
+	cost := pricing.Cost{
+		Amount:            amount,
+		ChargeableWeights: resp.ChargeableWeights,
+		Lane:              s.Lane(),
+		ServiceLevel:      pricing.ServiceLevel{Name: serviceLevel(s), Multiplier: 1, TransitDays: resp.TransitDays},
+		Breakdown:         pricing.Breakdown{{Kind: pricing.ItemBase, Description: "rate of " + h.name, Amount: amount}},
+		Carrier:           h.name,
+	}
+	for i, p := range s.Parcels {
+		cost.ChargeableWeight += resp.ChargeableWeights[i] * p.Quantity
+	}
+	return cost, nil
+}
+
+// Book implements Carrier.
+func (h HTTP) Book(ctx context.Context, s pricing.Shipment) (string, error) {
+	var resp bookingResponse
+	if err := h.do(ctx, http.MethodPost, "/bookings", toShipmentRequest(s), http.StatusCreated, &resp); err != nil {
+		return "", err
+	}
+	return resp.Reference, nil
+}
+
+// Cancel implements Carrier.
+func (h HTTP) Cancel(ctx context.Context, reference string) error {
+	err := h.do(ctx, http.MethodDelete, "/bookings/"+url.PathEscape(reference), nil, http.StatusNoContent, nil)
+	if err == errNotFound {
+		return ErrUnknownBooking
+	}
+	return err
+}
+
+// errNotFound is returned by do when the API responds with not found.
+var errNotFound = fmt.Errorf("not found: %w", ErrUnavailable)
+
+// do sends a request with the JSON encoding of body, if any, to path, and
+// decodes the response into v, if set. Errors wrap ErrUnavailable unless the
+// response has status want.
+func (h HTTP) do(ctx context.Context, method, path string, body interface{}, want int, v interface{}) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		r = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, h.baseURL+path, r)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", h.name, err, ErrUnavailable)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if resp.StatusCode != want {
+		io.Copy(ioutil.Discard, resp.Body)
+		return fmt.Errorf("%s: %s %s: status %d: %w", h.name, method, path, resp.StatusCode, ErrUnavailable)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s: decoding response: %s: %w", h.name, err, ErrUnavailable)
+	}
+	return nil
+}
+
+// toShipmentRequest translates s into the body of a rate or booking request.
+func toShipmentRequest(s pricing.Shipment) shipmentRequest {
+	req := shipmentRequest{
+		ServiceLevel:   serviceLevel(s),
+		From:           s.From.Alpha2,
+		To:             s.To.Alpha2,
+		ToPostalCode:   s.ToPostalCode,
+		DangerousGoods: s.DangerousGoods,
+		Parcels:        make([]parcelRequest, len(s.Parcels)),
+	}
+	for i, p := range s.Parcels {
+		req.Parcels[i] = parcelRequest{
+			Weight:   p.Weight,
+			Length:   p.Dimensions.Length,
+			Width:    p.Dimensions.Width,
+			Height:   p.Dimensions.Height,
+			Quantity: p.Quantity,
+		}
+	}
+	return req
+}
+
+// serviceLevel returns the name of the service level of s. An empty name
+// means pricing.ServiceStandard.
+func serviceLevel(s pricing.Shipment) string {
+	if s.ServiceLevel == "" {
+		return pricing.ServiceStandard
+	}
+	return s.ServiceLevel
+}
@@ -0,0 +1,139 @@
+package carrier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/exchange"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+)
+
+// Market asks carriers for offers on shipments. The carriers are asked
+// concurrently and are given a timeout to answer; carriers that fail or do
+// not answer in time are left out. Offers are converted to the currency of
+// the house carrier, which is the fallback when no carrier makes an offer.
+// Offers on shipments under a contract are discounted by the contract; the
+// house carrier applies the contract itself.
+type Market struct {
+	house    House
+	rates    exchange.Provider
+	timeout  time.Duration
+	carriers []Carrier
+}
+
+// NewMarket constructs a Market asking carriers, with the house carrier
+// rating by calc. Offers are converted using rates, and each carrier is given
+// timeout to answer.
+func NewMarket(calc pricing.ShipmentCostCalculator, rates exchange.Provider, timeout time.Duration, carriers ...Carrier) Market {
+	return Market{NewHouse(calc), rates, timeout, carriers}
+}
+
+// Offers returns the offers of the carriers on s, cheapest first. If no
+// carrier makes an offer, the offer of the house carrier is returned. The
+// shipment is always rated by the house carrier first, and its errors are
+// returned, e.g. pricing.ErrInvalidWeight.
+func (m Market) Offers(ctx context.Context, s pricing.Shipment) ([]pricing.Cost, error) {
+	house, err := m.house.Rate(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	offers := make([]*pricing.Cost, len(m.carriers))
+	var wg sync.WaitGroup
+	for i, c := range m.carriers {
+		wg.Add(1)
+		go func(i int, c Carrier) {
+			defer wg.Done()
+			cost, err := c.Rate(ctx, s)
+			if err != nil {
+				return
+			}
+			if cost, err = m.convert(ctx, cost, house.Amount.Currency); err != nil {
+				return
+			}
+			if s.Contract != nil {
+				if cost, err = s.Contract.Apply(cost); err != nil {
+					return
+				}
+			}
+			offers[i] = &cost
+		}(i, c)
+	}
+	wg.Wait()
+
+	costs := []pricing.Cost{}
+	for _, offer := range offers {
+		if offer != nil {
+			costs = append(costs, *offer)
+		}
+	}
+	if len(costs) == 0 {
+		return []pricing.Cost{house}, nil
+	}
+	sort.SliceStable(costs, func(i, j int) bool {
+		return costs[i].Amount.Amount < costs[j].Amount.Amount
+	})
+	return costs, nil
+}
+
+// Best returns the cheapest offer on s. See Offers.
+func (m Market) Best(ctx context.Context, s pricing.Shipment) (pricing.Cost, error) {
+	offers, err := m.Offers(ctx, s)
+	if err != nil {
+		return pricing.Cost{}, err
+	}
+	return offers[0], nil
+}
+
+// ShipmentCost implements pricing.ShipmentCostCalculator with the best offer
+// on s.
+func (m Market) ShipmentCost(s pricing.Shipment) (pricing.Cost, error) {
+	return m.Best(context.Background(), s)
+}
+
+// ShipmentCostContext implements pricing.ContextCalculator with the best
+// offer on s. The carriers are no longer waited for when ctx is done.
+func (m Market) ShipmentCostContext(ctx context.Context, s pricing.Shipment) (pricing.Cost, error) {
+	return m.Best(ctx, s)
+}
+
+// ServiceLevels implements pricing.ShipmentCostCalculator with the service
+// levels of the house carrier.
+func (m Market) ServiceLevels() []pricing.ServiceLevel {
+	return m.house.calc.ServiceLevels()
+}
+
+// convert converts cost to currency. The items of the breakdown are converted
+// one by one, and the amount is their total.
+func (m Market) convert(ctx context.Context, cost pricing.Cost, currency string) (pricing.Cost, error) {
+	if cost.Amount.Currency == currency {
+		return cost, nil
+	}
+	rate, err := m.rates.Rate(ctx, cost.Amount.Currency, currency)
+	if err != nil {
+		return pricing.Cost{}, fmt.Errorf("exchange rate: %w", err)
+	}
+
+	if len(cost.Breakdown) == 0 {
+		cost.Amount, err = rate.Convert(cost.Amount)
+		return cost, err
+	}
+	breakdown := make(pricing.Breakdown, len(cost.Breakdown))
+	for i, item := range cost.Breakdown {
+		if item.Amount, err = rate.Convert(item.Amount); err != nil {
+			return pricing.Cost{}, fmt.Errorf("converting %s: %w", item.Description, err)
+		}
+		breakdown[i] = item
+	}
+	if cost.Amount, err = breakdown.Total(); err != nil {
+		return pricing.Cost{}, err
+	}
+	cost.Breakdown = breakdown
+	return cost, nil
+}
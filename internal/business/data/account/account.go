@@ -0,0 +1,144 @@
+// Package account contains customer account related create and authentication
+// functionality. Accounts authenticate with an API key, of which only a hash
+// is stored.
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/johanronkko/quote-service/internal/business/validate"
+)
+
+var (
+	// ErrNotFound is used when a specific Account is requested but does not
+	// exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnauthenticated occurs when an API key does not belong to any
+	// account.
+	ErrUnauthenticated = errors.New("invalid API key")
+)
+
+// Info represents an individual customer account.
+type Info struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// NewAccount contains information needed to create a new Account.
+type NewAccount struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// Account manages the set of API's for account access.
+type Account struct {
+	db *sqlx.DB
+}
+
+// New constructs an Account for api access.
+func New(db *sqlx.DB) Account {
+	return Account{db}
+}
+
+// Create adds an account to the database. Returns the API key of the account,
+// which cannot be retrieved later.
+func (a Account) Create(ctx context.Context, na NewAccount) (Info, string, error) {
+	if err := validate.Check(na); err != nil {
+		return Info{}, "", fmt.Errorf("validating data: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return Info{}, "", fmt.Errorf("generating API key: %w", err)
+	}
+	apiKey := hex.EncodeToString(key)
+
+	info := Info{
+		ID:   validate.GenerateID(),
+		Name: na.Name,
+	}
+
+	const query = `
+	INSERT INTO accounts
+		(account_id, name, api_key_hash)
+	VALUES
+		($1, $2, $3)`
+
+	if _, err := a.db.ExecContext(ctx, query, info.ID, info.Name, hash(apiKey)); err != nil {
+		return Info{}, "", fmt.Errorf("inserting account: %w", err)
+	}
+
+	return info, apiKey, nil
+}
+
+// QueryByID gets the specified account from the database.
+func (a Account) QueryByID(ctx context.Context, accountID string) (Info, error) {
+
+	const query = `
+	SELECT
+		*
+	FROM
+		accounts
+	WHERE
+		account_id = $1`
+
+	var qa queryAccount
+	if err := a.db.GetContext(ctx, &qa, query, accountID); err != nil {
+		if err == sql.ErrNoRows {
+			return Info{}, ErrNotFound
+		}
+		return Info{}, fmt.Errorf("selecting account %q: %w", accountID, err)
+	}
+
+	return qa.toInfo(), nil
+}
+
+// Authenticate returns the account with apiKey. Returns ErrUnauthenticated if
+// no account has the key.
+func (a Account) Authenticate(ctx context.Context, apiKey string) (Info, error) {
+
+	const query = `
+	SELECT
+		*
+	FROM
+		accounts
+	WHERE
+		api_key_hash = $1`
+
+	var qa queryAccount
+	if err := a.db.GetContext(ctx, &qa, query, hash(apiKey)); err != nil {
+		if err == sql.ErrNoRows {
+			return Info{}, ErrUnauthenticated
+		}
+		return Info{}, fmt.Errorf("selecting account: %w", err)
+	}
+
+	return qa.toInfo(), nil
+}
+
+// hash returns the hex encoded SHA-256 hash of apiKey. API keys are random, so
+// a plain hash is enough to protect them at rest.
+func hash(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+type queryAccount struct {
+	ID         string `db:"account_id"`
+	Name       string `db:"name"`
+	APIKeyHash string `db:"api_key_hash"`
+}
+
+func (qa queryAccount) toInfo() Info {
+	return Info{
+		ID:   qa.ID,
+		Name: qa.Name,
+	}
+}
@@ -0,0 +1,44 @@
+package account
+
+import (
+	"context"
+	"testing"
+
+	"github.com/johanronkko/quote-service/internal/business/tests"
+	"github.com/matryer/is"
+)
+
+func TestAccount(t *testing.T) {
+	is := is.New(t)
+
+	db := tests.NewUnit(t)
+	a := New(db)
+
+	ctx := context.Background()
+
+	// Create account.
+	info, apiKey, err := a.Create(ctx, NewAccount{Name: "Acme AB"})
+	is.NoErr(err)
+	is.Equal(info.Name, "Acme AB")
+	is.Equal(len(apiKey), 64)
+
+	// Query by ID returns correct account.
+	saved, err := a.QueryByID(ctx, info.ID)
+	is.NoErr(err)
+	is.Equal(saved, info)
+
+	// The API key authenticates the account.
+	authenticated, err := a.Authenticate(ctx, apiKey)
+	is.NoErr(err)
+	is.Equal(authenticated, info)
+
+	// Unknown API keys and accounts are rejected.
+	_, err = a.Authenticate(ctx, "banana")
+	is.Equal(err, ErrUnauthenticated)
+	_, err = a.QueryByID(ctx, "banana")
+	is.Equal(err, ErrNotFound)
+
+	// Accounts must have a name.
+	_, _, err = a.Create(ctx, NewAccount{})
+	is.True(err != nil)
+}
@@ -0,0 +1,488 @@
+// Package booking contains functionality for booking the shipments of
+// accepted quotes. A booking refers to its quote for the shipment and the
+// customers, and a quote is booked at most once.
+package booking
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/johanronkko/quote-service/internal/business/address"
+	"github.com/johanronkko/quote-service/internal/business/carrier"
+	"github.com/johanronkko/quote-service/internal/business/data/quote"
+	"github.com/johanronkko/quote-service/internal/business/delivery"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/validate"
+)
+
+var (
+	// ErrNotFound is used when a specific Booking is requested but does not
+	// exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrAnonymous is used when a new booking is made without an account.
+	ErrAnonymous = errors.New("bookings are made by an account")
+
+	// ErrForbidden is used when the quote of a new booking belongs to another
+	// account than the booking.
+	ErrForbidden = errors.New("quote belongs to another account")
+
+	// ErrPending is used when the quote of a new booking is being booked by
+	// another booking.
+	ErrPending = errors.New("quote is being booked")
+
+	// ErrUnknownCarrier is used when the quote of a new booking was rated by
+	// a carrier that is not configured.
+	ErrUnknownCarrier = errors.New("unknown carrier")
+)
+
+// Pickups are made within business hours in UTC, and a pickup window is at
+// least pickupLead long.
+const (
+	pickupOpens  = 9 * time.Hour
+	pickupCloses = 17 * time.Hour
+	pickupLead   = 2 * time.Hour
+)
+
+// undoTimeout is how long undoing a failed booking may take. Undoing does not
+// use the context of the booking, which may be what made it fail.
+const undoTimeout = 10 * time.Second
+
+// Statuses of a booking. A booking with a carrier is pending while it is
+// booked with the carrier, and confirmed once the carrier has booked it.
+const (
+	StatusPending   = "pending"
+	StatusConfirmed = "confirmed"
+)
+
+// Info represents the booking of the shipment of a quote. The sender and the
+// receiver are those of the quote. Carrier is the carrier the quote was rated
+// by, if rated by a carrier, and CarrierReference the reference of the
+// booking at the carrier.
+type Info struct {
+	ID               string         `json:"id"`
+	Reference        string         `json:"reference"`
+	Status           string         `json:"status"`
+	QuoteID          string         `json:"quote_id"`
+	AccountID        string         `json:"account_id,omitempty"`
+	Carrier          string         `json:"carrier,omitempty"`
+	CarrierReference string         `json:"carrier_reference,omitempty"`
+	To               quote.Customer `json:"to"`
+	From             quote.Customer `json:"from"`
+	Pickup           Window         `json:"pickup"`
+	CreatedAt        time.Time      `json:"created_at"`
+}
+
+// Window is the time window a shipment is picked up within.
+type Window struct {
+	From  time.Time `json:"from"`
+	Until time.Time `json:"until"`
+}
+
+// NewBooking contains information needed to book the shipment of a quote.
+// AccountID is the authenticated account booking the quote, and is not part
+// of the request body. It must be the account of the quote.
+type NewBooking struct {
+	AccountID string `json:"-"`
+	QuoteID   string `json:"quote_id" validate:"required,uuid"`
+}
+
+// Quotes looks up quotes.
+type Quotes interface {
+	// QueryByID gets the quote with quoteID. Returns quote.ErrNotFound if the
+	// quote does not exist.
+	QueryByID(ctx context.Context, quoteID string) (quote.Info, error)
+	// Shipment returns the shipment of quote q.
+	Shipment(q quote.Info) (pricing.Shipment, error)
+}
+
+// Booking manages the set of API's for booking access.
+type Booking struct {
+	db       *sqlx.DB
+	quotes   Quotes
+	clock    delivery.Clock
+	carriers map[string]carrier.Carrier
+}
+
+// New constructs a Booking for api access. Quotes are looked up in quotes, and
+// pickup windows are planned from the current time told by clock. Quotes rated
+// by one of carriers are booked with the carrier.
+func New(db *sqlx.DB, quotes Quotes, clock delivery.Clock, carriers ...carrier.Carrier) Booking {
+	byName := make(map[string]carrier.Carrier, len(carriers))
+	for _, c := range carriers {
+		byName[c.Name()] = c
+	}
+	return Booking{db, quotes, clock, byName}
+}
+
+// Create books the shipment of the accepted quote of nb. The quote is moved
+// to booked in the same transaction as the booking is confirmed in, so a quote
+// is never booked twice. Returns ErrAnonymous if nb has no account,
+// ErrForbidden if the quote belongs to another account,
+// quote.ErrInvalidTransition if the quote is not accepted, e.g. if it is
+// already booked, and quote.ErrExpired if it has expired, in which case the
+// expiry is recorded.
+//
+// Quotes rated by a carrier are booked with the carrier while the booking is
+// pending, without holding a lock on the quote, and a pending booking of the
+// quote makes other bookings of it fail with ErrPending. The pending booking
+// is removed, and the booking at the carrier cancelled, if confirming it
+// fails. Returns ErrUnknownCarrier if the carrier is not configured and
+// carrier.ErrUnavailable if the carrier fails to book the shipment.
+func (b Booking) Create(ctx context.Context, nb NewBooking) (Info, error) {
+	if err := validate.Check(nb); err != nil {
+		return Info{}, fmt.Errorf("validating data: %w", err)
+	}
+	if nb.AccountID == "" {
+		return Info{}, ErrAnonymous
+	}
+
+	q, err := b.quotes.QueryByID(ctx, nb.QuoteID)
+	if err != nil {
+		return Info{}, fmt.Errorf("looking up quote: %w", err)
+	}
+	if q.AccountID != nb.AccountID {
+		return Info{}, ErrForbidden
+	}
+
+	reference, err := newReference()
+	if err != nil {
+		return Info{}, err
+	}
+	now := b.clock.Now().UTC().Truncate(time.Microsecond)
+	info := Info{
+		ID:        validate.GenerateID(),
+		Reference: reference,
+		Status:    StatusConfirmed,
+		QuoteID:   q.ID,
+		AccountID: q.AccountID,
+		To:        q.To,
+		From:      q.From,
+		Pickup:    pickupWindow(q, now),
+		CreatedAt: now,
+	}
+
+	if q.Carrier == "" || q.Carrier == carrier.HouseName {
+		return info, b.confirm(ctx, info, now)
+	}
+
+	c, ok := b.carriers[q.Carrier]
+	if !ok {
+		return Info{}, fmt.Errorf("%w %q", ErrUnknownCarrier, q.Carrier)
+	}
+	info.Carrier = q.Carrier
+	s, err := b.quotes.Shipment(q)
+	if err != nil {
+		return Info{}, fmt.Errorf("looking up shipment: %w", err)
+	}
+
+	info.Status = StatusPending
+	if err := b.reserve(ctx, info, now); err != nil {
+		return Info{}, err
+	}
+
+	if info.CarrierReference, err = c.Book(ctx, s); err != nil {
+		err = fmt.Errorf("booking with carrier: %w", err)
+		if derr := b.remove(info.ID); derr != nil {
+			return Info{}, fmt.Errorf("%w, removing pending booking: %s", err, derr)
+		}
+		return Info{}, err
+	}
+
+	info.Status = StatusConfirmed
+	if err := b.confirm(ctx, info, now); err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), undoTimeout)
+		defer cancel()
+		if cerr := c.Cancel(ctx, info.CarrierReference); cerr != nil {
+			return Info{}, fmt.Errorf("%w, cancelling booking %s with carrier: %s", err, info.CarrierReference, cerr)
+		}
+		if derr := b.remove(info.ID); derr != nil {
+			return Info{}, fmt.Errorf("%w, removing pending booking: %s", err, derr)
+		}
+		return Info{}, err
+	}
+
+	return info, nil
+}
+
+// reserve adds the pending booking info of a quote, if the quote can be
+// booked at now and has no other pending booking. The quote is not moved.
+func (b Booking) reserve(ctx context.Context, info Info, now time.Time) error {
+	tx, err := b.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := quote.Lock(ctx, tx, info.QuoteID, quote.StatusBooked, now); err == quote.ErrExpired {
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("committing expiry: %w", err)
+		}
+		return fmt.Errorf("booking quote: %w", err)
+	} else if err != nil {
+		return fmt.Errorf("booking quote: %w", err)
+	}
+
+	const query = `
+	SELECT
+		EXISTS (SELECT 1 FROM bookings WHERE quote_id = $1)`
+
+	var pending bool
+	if err := tx.GetContext(ctx, &pending, query, info.QuoteID); err != nil {
+		return fmt.Errorf("selecting pending booking: %w", err)
+	}
+	if pending {
+		return ErrPending
+	}
+
+	if err := insert(ctx, tx, info); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing pending booking: %w", err)
+	}
+	return nil
+}
+
+// confirm moves the quote of the booking info to booked at now, and adds info
+// or, if booked with a carrier, confirms its pending booking.
+func (b Booking) confirm(ctx context.Context, info Info, now time.Time) error {
+	tx, err := b.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := quote.Book(ctx, tx, info.QuoteID, now); err == quote.ErrExpired {
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("committing expiry: %w", err)
+		}
+		return fmt.Errorf("booking quote: %w", err)
+	} else if err != nil {
+		return fmt.Errorf("booking quote: %w", err)
+	}
+
+	if info.Carrier == "" {
+		if err := insert(ctx, tx, info); err != nil {
+			return err
+		}
+	} else {
+		const query = `
+		UPDATE
+			bookings
+		SET
+			status = $2,
+			carrier_reference = $3
+		WHERE
+			booking_id = $1`
+
+		if _, err := tx.ExecContext(ctx, query, info.ID, info.Status, info.CarrierReference); err != nil {
+			return fmt.Errorf("confirming booking: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing booking: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the pending booking with bookingID, under its own timeout.
+func (b Booking) remove(bookingID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), undoTimeout)
+	defer cancel()
+
+	const query = `
+	DELETE FROM
+		bookings
+	WHERE
+		booking_id = $1 AND status = $2`
+
+	if _, err := b.db.ExecContext(ctx, query, bookingID, StatusPending); err != nil {
+		return fmt.Errorf("deleting booking: %w", err)
+	}
+	return nil
+}
+
+// insert adds the booking info within tx.
+func insert(ctx context.Context, tx *sqlx.Tx, info Info) error {
+	const query = `
+	INSERT INTO bookings
+		(booking_id, reference, status, quote_id, carrier_reference, pickup_from, pickup_until, created_at)
+	VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	if _, err := tx.ExecContext(ctx, query, info.ID, info.Reference, info.Status, info.QuoteID, info.CarrierReference, info.Pickup.From, info.Pickup.Until, info.CreatedAt); err != nil {
+		return fmt.Errorf("inserting booking: %w", err)
+	}
+	return nil
+}
+
+// selectBookings selects bookings together with the customers of their
+// quotes.
+const selectBookings = `
+	SELECT
+		b.booking_id, b.reference, b.status, b.quote_id, b.carrier_reference, b.pickup_from, b.pickup_until, b.created_at, q.account_id, q.carrier,
+		q.to_name, q.to_email, q.to_address, q.to_postal_code, q.to_city, q.to_region, q.to_country_code, q.to_vat_number,
+		q.from_name, q.from_email, q.from_address, q.from_postal_code, q.from_city, q.from_region, q.from_country_code, q.from_vat_number
+	FROM
+		bookings b
+		JOIN quotes q ON q.quote_id = b.quote_id`
+
+// Query retrieves a list of the existing bookings of the account with
+// accountID from the database, most recent first.
+func (b Booking) Query(ctx context.Context, accountID string) ([]Info, error) {
+	query := selectBookings + `
+	WHERE
+		q.account_id = $1
+	ORDER BY
+		b.created_at DESC`
+
+	queryBookings := []queryBooking{}
+	if err := b.db.SelectContext(ctx, &queryBookings, query, accountID); err != nil {
+		return nil, fmt.Errorf("selecting bookings: %w", err)
+	}
+
+	bookings := []Info{}
+	for _, qb := range queryBookings {
+		bookings = append(bookings, qb.toInfo())
+	}
+
+	return bookings, nil
+}
+
+// QueryByID gets the specified booking from the database.
+func (b Booking) QueryByID(ctx context.Context, bookingID string) (Info, error) {
+	query := selectBookings + `
+	WHERE
+		b.booking_id = $1`
+
+	var qb queryBooking
+	if err := b.db.GetContext(ctx, &qb, query, bookingID); err != nil {
+		if err == sql.ErrNoRows {
+			return Info{}, ErrNotFound
+		}
+		return Info{}, fmt.Errorf("selecting booking %q: %w", bookingID, err)
+	}
+
+	return qb.toInfo(), nil
+}
+
+// pickupWindow plans the pickup of the shipment of q booked at now. The
+// shipment is picked up on the estimated pickup date of the quote, or on the
+// first business day in the origin country with at least pickupLead of
+// business hours left if that date has passed.
+func pickupWindow(q quote.Info, now time.Time) Window {
+	calendar := delivery.CalendarOf(q.From.Address.CountryCode)
+
+	day := now.Truncate(24 * time.Hour)
+	if q.PickupDate != nil && q.PickupDate.After(day) {
+		day = *q.PickupDate
+	}
+	day = calendar.NextBusinessDay(day)
+
+	from := day.Add(pickupOpens)
+	if from.Before(now) {
+		from = now.Truncate(time.Hour).Add(time.Hour)
+	}
+	if day.Add(pickupCloses).Sub(from) < pickupLead {
+		day = calendar.NextBusinessDay(day.AddDate(0, 0, 1))
+		from = day.Add(pickupOpens)
+	}
+
+	return Window{From: from, Until: day.Add(pickupCloses)}
+}
+
+// referenceAlphabet are the characters of booking references, leaving out
+// those easily mistaken for one another.
+const referenceAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// newReference generates a random booking reference, e.g. "BK-7F3K9Q2M".
+func newReference() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating reference: %w", err)
+	}
+	for i := range b {
+		b[i] = referenceAlphabet[int(b[i])%len(referenceAlphabet)]
+	}
+	return "BK-" + string(b), nil
+}
+
+type queryBooking struct {
+	ID               string    `db:"booking_id"`
+	Reference        string    `db:"reference"`
+	Status           string    `db:"status"`
+	QuoteID          string    `db:"quote_id"`
+	CarrierReference string    `db:"carrier_reference"`
+	Carrier          string    `db:"carrier"`
+	PickupFrom       time.Time `db:"pickup_from"`
+	PickupUntil      time.Time `db:"pickup_until"`
+	CreatedAt        time.Time `db:"created_at"`
+	AccountID        *string   `db:"account_id"`
+	ToName           string    `db:"to_name"`
+	ToEmail          string    `db:"to_email"`
+	ToAddress        string    `db:"to_address"`
+	ToPostalCode     string    `db:"to_postal_code"`
+	ToCity           string    `db:"to_city"`
+	ToRegion         string    `db:"to_region"`
+	ToCountryCode    string    `db:"to_country_code"`
+	ToVATNumber      string    `db:"to_vat_number"`
+	FromName         string    `db:"from_name"`
+	FromEmail        string    `db:"from_email"`
+	FromAddress      string    `db:"from_address"`
+	FromPostalCode   string    `db:"from_postal_code"`
+	FromCity         string    `db:"from_city"`
+	FromRegion       string    `db:"from_region"`
+	FromCountryCode  string    `db:"from_country_code"`
+	FromVATNumber    string    `db:"from_vat_number"`
+}
+
+func (qb queryBooking) toInfo() Info {
+	info := Info{
+		ID:               qb.ID,
+		Reference:        qb.Reference,
+		Status:           qb.Status,
+		QuoteID:          qb.QuoteID,
+		CarrierReference: qb.CarrierReference,
+		To: quote.Customer{
+			Name:  qb.ToName,
+			Email: qb.ToEmail,
+			Address: address.Address{
+				Lines:       strings.Split(qb.ToAddress, "\n"),
+				PostalCode:  qb.ToPostalCode,
+				City:        qb.ToCity,
+				Region:      qb.ToRegion,
+				CountryCode: qb.ToCountryCode,
+			},
+			VATNumber: qb.ToVATNumber,
+		},
+		From: quote.Customer{
+			Name:  qb.FromName,
+			Email: qb.FromEmail,
+			Address: address.Address{
+				Lines:       strings.Split(qb.FromAddress, "\n"),
+				PostalCode:  qb.FromPostalCode,
+				City:        qb.FromCity,
+				Region:      qb.FromRegion,
+				CountryCode: qb.FromCountryCode,
+			},
+			VATNumber: qb.FromVATNumber,
+		},
+		Pickup:    Window{From: qb.PickupFrom.UTC(), Until: qb.PickupUntil.UTC()},
+		CreatedAt: qb.CreatedAt.UTC(),
+	}
+	if qb.AccountID != nil {
+		info.AccountID = *qb.AccountID
+	}
+	if qb.Carrier != carrier.HouseName {
+		info.Carrier = qb.Carrier
+	}
+	return info
+}
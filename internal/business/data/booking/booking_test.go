@@ -0,0 +1,224 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/address"
+	"github.com/johanronkko/quote-service/internal/business/carrier"
+	"github.com/johanronkko/quote-service/internal/business/carrier/carriertest"
+	"github.com/johanronkko/quote-service/internal/business/data/account"
+	"github.com/johanronkko/quote-service/internal/business/data/contract"
+	"github.com/johanronkko/quote-service/internal/business/data/country"
+	"github.com/johanronkko/quote-service/internal/business/data/quote"
+	"github.com/johanronkko/quote-service/internal/business/delivery"
+	"github.com/johanronkko/quote-service/internal/business/exchange"
+	"github.com/johanronkko/quote-service/internal/business/geocode"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/region"
+	"github.com/johanronkko/quote-service/internal/business/tests"
+	"github.com/johanronkko/quote-service/internal/business/validate"
+	"github.com/matryer/is"
+)
+
+// clock is a delivery.Clock stopped at a fixed time.
+type clock time.Time
+
+func (c clock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestPickupWindow(t *testing.T) {
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		Name string
+
+		Country    string
+		PickupDate time.Time
+		Now        time.Time
+
+		Want Window
+	}{
+		{"on pickup date", "SE", at(10, 21, 0, 0), at(10, 19, 10, 0), Window{at(10, 21, 9, 0), at(10, 21, 17, 0)}},
+		{"pickup date passed", "SE", at(10, 16, 0, 0), at(10, 19, 10, 30), Window{at(10, 19, 11, 0), at(10, 19, 17, 0)}},
+		{"too late today", "SE", at(10, 19, 0, 0), at(10, 19, 15, 30), Window{at(10, 20, 9, 0), at(10, 20, 17, 0)}},
+		{"weekend", "SE", at(10, 23, 0, 0), at(10, 23, 16, 0), Window{at(10, 26, 9, 0), at(10, 26, 17, 0)}},
+		{"holidays", "SE", at(12, 23, 0, 0), at(12, 23, 16, 0), Window{at(12, 28, 9, 0), at(12, 28, 17, 0)}},
+		{"holidays elsewhere", "US", at(12, 23, 0, 0), at(12, 23, 16, 0), Window{at(12, 24, 9, 0), at(12, 24, 17, 0)}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			q := quote.Info{From: quote.Customer{Address: address.Address{CountryCode: tc.Country}}, PickupDate: &tc.PickupDate}
+			is.Equal(pickupWindow(q, tc.Now), tc.Want)
+		})
+	}
+}
+
+func TestBooking(t *testing.T) {
+	is := is.New(t)
+
+	db := tests.NewUnit(t)
+
+	ctx := context.Background()
+
+	regions := region.NewCache(country.New(db))
+	is.NoErr(regions.Refresh(ctx))
+
+	// Monday morning.
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	quotes := quote.New(log.New(ioutil.Discard, "", 0), db, regions, pricing.FlatRate(), exchange.File{Base: "SEK"}, delivery.NewEstimator(clock(now), delivery.Transit{CutoffHour: 15}), contract.New(db), geocode.File{}, clock(now), 24*time.Hour)
+	b := New(db, quotes, clock(now))
+
+	acme, _, err := account.New(db).Create(ctx, account.NewAccount{Name: "Acme AB"})
+	is.NoErr(err)
+
+	// Query empty database.
+	bookings, err := b.Query(ctx, acme.ID)
+	is.NoErr(err)
+	is.Equal(len(bookings), 0)
+
+	nq := quote.NewQuote{
+		AccountID: acme.ID,
+		To: &quote.Customer{
+			Name:  "Sven Svensson",
+			Email: "sven.svensson@test.com",
+			Address: address.Address{
+				Lines:       []string{"Testgatan 42B"},
+				PostalCode:  "12345",
+				City:        "Göteborg",
+				CountryCode: "SE",
+			},
+		},
+		From: &quote.Customer{
+			Name:  "Kari Nordmann",
+			Email: "kari.nordmann@test.com",
+			Address: address.Address{
+				Lines:       []string{"Testveien 1"},
+				PostalCode:  "0150",
+				City:        "Oslo",
+				CountryCode: "NO",
+			},
+		},
+		Weight: 5,
+	}
+	q, err := quotes.Create(ctx, nq)
+	is.NoErr(err)
+
+	// Quotes must be accepted before they are booked.
+	_, err = b.Create(ctx, NewBooking{AccountID: acme.ID, QuoteID: q.ID})
+	is.True(errors.Is(err, quote.ErrInvalidTransition))
+
+	_, err = quotes.Accept(ctx, q.ID)
+	is.NoErr(err)
+
+	// Quotes are booked by their own account.
+	_, err = b.Create(ctx, NewBooking{QuoteID: q.ID})
+	is.Equal(err, ErrAnonymous)
+	other, _, err := account.New(db).Create(ctx, account.NewAccount{Name: "Other AB"})
+	is.NoErr(err)
+	_, err = b.Create(ctx, NewBooking{AccountID: other.ID, QuoteID: q.ID})
+	is.Equal(err, ErrForbidden)
+
+	booking, err := b.Create(ctx, NewBooking{AccountID: acme.ID, QuoteID: q.ID})
+	is.NoErr(err)
+	is.Equal(booking.AccountID, acme.ID)
+	is.Equal(booking.Status, StatusConfirmed)
+	is.True(regexp.MustCompile(`^BK-[2-9A-HJ-NP-Z]{8}$`).MatchString(booking.Reference))
+	is.Equal(booking.QuoteID, q.ID)
+	is.Equal(booking.From, *nq.From)
+	is.Equal(booking.To, *nq.To)
+	is.Equal(booking.Pickup, Window{From: now, Until: now.Add(8 * time.Hour)})
+
+	booked, err := quotes.QueryByID(ctx, q.ID)
+	is.NoErr(err)
+	is.Equal(booked.Status, quote.StatusBooked)
+
+	saved, err := b.QueryByID(ctx, booking.ID)
+	is.NoErr(err)
+	is.Equal(saved, booking)
+
+	// Quotes are booked once.
+	_, err = b.Create(ctx, NewBooking{AccountID: acme.ID, QuoteID: q.ID})
+	is.True(errors.Is(err, quote.ErrInvalidTransition))
+
+	_, err = b.Create(ctx, NewBooking{AccountID: acme.ID, QuoteID: "00000000-0000-0000-0000-000000000000"})
+	is.True(errors.Is(err, quote.ErrNotFound))
+	_, err = b.QueryByID(ctx, "00000000-0000-0000-0000-000000000000")
+	is.Equal(err, ErrNotFound)
+
+	// Bookings are listed by account.
+	bookings, err = b.Query(ctx, acme.ID)
+	is.NoErr(err)
+	is.Equal(bookings, []Info{booking})
+	bookings, err = b.Query(ctx, "")
+	is.NoErr(err)
+	is.Equal(len(bookings), 0)
+
+	// Quotes rated by a carrier are booked with the carrier.
+	server := carriertest.NewServer(carriertest.Config{PricePerKg: 1_00, Currency: "SEK", TransitDays: map[string]int{pricing.ServiceStandard: 2}})
+	defer server.Close()
+	fake := carrier.NewHTTP("fake", server.URL, server.Client())
+	market := carrier.NewMarket(pricing.FlatRate(), exchange.File{Base: "SEK"}, time.Second, fake)
+	rated := quote.New(log.New(ioutil.Discard, "", 0), db, regions, market, exchange.File{Base: "SEK"}, delivery.NewEstimator(clock(now), delivery.Transit{CutoffHour: 15}), contract.New(db), geocode.File{}, clock(now), 24*time.Hour)
+	q, err = rated.Create(ctx, nq)
+	is.NoErr(err)
+	is.Equal(q.Carrier, "fake")
+	_, err = rated.Accept(ctx, q.ID)
+	is.NoErr(err)
+
+	// The carrier must be configured.
+	_, err = New(db, rated, clock(now)).Create(ctx, NewBooking{AccountID: acme.ID, QuoteID: q.ID})
+	is.True(errors.Is(err, ErrUnknownCarrier))
+
+	// Bookings that the carrier fails to book are removed, and the quote can
+	// be booked again.
+	down := carriertest.NewServer(carriertest.Config{Status: http.StatusServiceUnavailable})
+	defer down.Close()
+	_, err = New(db, rated, clock(now), carrier.NewHTTP("fake", down.URL, down.Client())).Create(ctx, NewBooking{AccountID: acme.ID, QuoteID: q.ID})
+	is.True(errors.Is(err, carrier.ErrUnavailable))
+	bookings, err = b.Query(ctx, acme.ID)
+	is.NoErr(err)
+	is.Equal(len(bookings), 1)
+
+	// Quotes with a pending booking are not booked again.
+	withCarrier := New(db, rated, clock(now), fake)
+	pending := Info{ID: validate.GenerateID(), Reference: "BK-7F3K9Q2M", Status: StatusPending, QuoteID: q.ID, Carrier: "fake", CreatedAt: now}
+	is.NoErr(withCarrier.reserve(ctx, pending, now))
+	_, err = withCarrier.Create(ctx, NewBooking{AccountID: acme.ID, QuoteID: q.ID})
+	is.Equal(err, ErrPending)
+	is.Equal(len(server.Bookings()), 0)
+	is.NoErr(withCarrier.remove(pending.ID))
+
+	shipped, err := withCarrier.Create(ctx, NewBooking{AccountID: acme.ID, QuoteID: q.ID})
+	is.NoErr(err)
+	is.Equal(shipped.Carrier, "fake")
+	is.Equal(shipped.Status, StatusConfirmed)
+	is.Equal(server.Bookings(), []string{shipped.CarrierReference})
+	saved, err = b.QueryByID(ctx, shipped.ID)
+	is.NoErr(err)
+	is.Equal(saved, shipped)
+	booked, err = rated.QueryByID(ctx, q.ID)
+	is.NoErr(err)
+	is.Equal(booked.Status, quote.StatusBooked)
+
+	// Booking a quote past its validity records its expiry.
+	q, err = quotes.Create(ctx, nq)
+	is.NoErr(err)
+	later := New(db, quotes, clock(now.Add(25*time.Hour)))
+	_, err = later.Create(ctx, NewBooking{AccountID: acme.ID, QuoteID: q.ID})
+	is.True(errors.Is(err, quote.ErrExpired))
+	events, err := quotes.QueryEvents(ctx, q.ID)
+	is.NoErr(err)
+	is.Equal(events[len(events)-1].To, quote.StatusExpired)
+}
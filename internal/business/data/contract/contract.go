@@ -0,0 +1,213 @@
+// Package contract contains functionality for managing the negotiated rates of
+// customer accounts. Contracts are never changed in place; renegotiated rates
+// are added as a new version, so quotes priced under an earlier version
+// remain explainable.
+package contract
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+)
+
+var (
+	// ErrNotFound is used when a specific Contract is requested but does not
+	// exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrAccountMismatch occurs when adding a version of a contract that
+	// belongs to another account.
+	ErrAccountMismatch = errors.New("contract belongs to another account")
+)
+
+// Info represents a version of a contract.
+type Info struct {
+	ID        string                 `json:"id"`
+	Version   int                    `json:"version"`
+	AccountID string                 `json:"account_id"`
+	Discount  float64                `json:"discount"`
+	Lanes     []pricing.ContractLane `json:"lanes"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// Contract returns the pricing contract of the version.
+func (i Info) Contract() pricing.Contract {
+	return pricing.Contract{
+		ID:       i.ID,
+		Version:  i.Version,
+		Discount: i.Discount,
+		Lanes:    i.Lanes,
+	}
+}
+
+// NewContract contains information needed to add a version of a contract.
+// Discount is the percentage off the list price, and Lanes override the
+// discount or tariff on specific lanes, see pricing.Contract.
+type NewContract struct {
+	ID        string                 `json:"id"`
+	AccountID string                 `json:"account_id"`
+	Discount  float64                `json:"discount"`
+	Lanes     []pricing.ContractLane `json:"lanes"`
+}
+
+// Contract manages the set of API's for contract access.
+type Contract struct {
+	db *sqlx.DB
+}
+
+// New constructs a Contract for api access.
+func New(db *sqlx.DB) Contract {
+	return Contract{db}
+}
+
+// Create adds the next version of contract nc.ID to the database, starting at
+// version 1. The new version becomes the current contract of the account.
+func (c Contract) Create(ctx context.Context, nc NewContract) (Info, error) {
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Info{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the versions of the contract so concurrent additions do not get
+	// the same version.
+	const versionsQuery = `
+	SELECT
+		account_id, version
+	FROM
+		contracts
+	WHERE
+		contract_id = $1
+	ORDER BY
+		version DESC
+	LIMIT 1
+	FOR UPDATE`
+
+	var latest struct {
+		AccountID string `db:"account_id"`
+		Version   int    `db:"version"`
+	}
+	if err := tx.GetContext(ctx, &latest, versionsQuery, nc.ID); err != nil && err != sql.ErrNoRows {
+		return Info{}, fmt.Errorf("selecting contract %q: %w", nc.ID, err)
+	}
+	if latest.Version > 0 && latest.AccountID != nc.AccountID {
+		return Info{}, ErrAccountMismatch
+	}
+
+	lanes := nc.Lanes
+	if lanes == nil {
+		lanes = []pricing.ContractLane{}
+	}
+	info := Info{
+		ID:        nc.ID,
+		Version:   latest.Version + 1,
+		AccountID: nc.AccountID,
+		Discount:  nc.Discount,
+		Lanes:     lanes,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if err := info.Contract().Validate(); err != nil {
+		return Info{}, fmt.Errorf("validating contract: %w", err)
+	}
+
+	qc, err := toQueryContract(info)
+	if err != nil {
+		return Info{}, err
+	}
+
+	const query = `
+	INSERT INTO contracts
+		(contract_id, version, account_id, discount, lanes, created_at)
+	VALUES
+		(:contract_id, :version, :account_id, :discount, :lanes, :created_at)`
+
+	if _, err := tx.NamedExecContext(ctx, query, qc); err != nil {
+		return Info{}, fmt.Errorf("inserting contract: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Info{}, fmt.Errorf("committing contract: %w", err)
+	}
+
+	return info, nil
+}
+
+// QueryByAccount gets the current contract of the account with accountID, the
+// most recently added contract version of the account. Returns ErrNotFound if
+// the account has no contract.
+func (c Contract) QueryByAccount(ctx context.Context, accountID string) (Info, error) {
+
+	const query = `
+	SELECT
+		*
+	FROM
+		contracts
+	WHERE
+		account_id = $1
+	ORDER BY
+		created_at DESC, version DESC
+	LIMIT 1`
+
+	var qc queryContract
+	if err := c.db.GetContext(ctx, &qc, query, accountID); err != nil {
+		if err == sql.ErrNoRows {
+			return Info{}, ErrNotFound
+		}
+		return Info{}, fmt.Errorf("selecting contract of account %q: %w", accountID, err)
+	}
+
+	return qc.toInfo()
+}
+
+// ContractOf implements quote.Contracts.
+func (c Contract) ContractOf(ctx context.Context, accountID string) (pricing.Contract, error) {
+	info, err := c.QueryByAccount(ctx, accountID)
+	if err != nil {
+		return pricing.Contract{}, err
+	}
+	return info.Contract(), nil
+}
+
+type queryContract struct {
+	ID        string    `db:"contract_id"`
+	Version   int       `db:"version"`
+	AccountID string    `db:"account_id"`
+	Discount  float64   `db:"discount"`
+	Lanes     []byte    `db:"lanes"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func toQueryContract(info Info) (queryContract, error) {
+	lanes, err := json.Marshal(info.Lanes)
+	if err != nil {
+		return queryContract{}, fmt.Errorf("encoding lanes: %w", err)
+	}
+	return queryContract{
+		ID:        info.ID,
+		Version:   info.Version,
+		AccountID: info.AccountID,
+		Discount:  info.Discount,
+		Lanes:     lanes,
+		CreatedAt: info.CreatedAt,
+	}, nil
+}
+
+func (qc queryContract) toInfo() (Info, error) {
+	var lanes []pricing.ContractLane
+	if err := json.Unmarshal(qc.Lanes, &lanes); err != nil {
+		return Info{}, fmt.Errorf("decoding lanes of contract %q: %w", qc.ID, err)
+	}
+	return Info{
+		ID:        qc.ID,
+		Version:   qc.Version,
+		AccountID: qc.AccountID,
+		Discount:  qc.Discount,
+		Lanes:     lanes,
+		CreatedAt: qc.CreatedAt.UTC(),
+	}, nil
+}
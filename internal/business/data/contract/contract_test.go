@@ -0,0 +1,68 @@
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"github.com/johanronkko/quote-service/internal/business/data/account"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/tests"
+	"github.com/matryer/is"
+)
+
+func TestContract(t *testing.T) {
+	is := is.New(t)
+
+	db := tests.NewUnit(t)
+	c := New(db)
+
+	ctx := context.Background()
+
+	acme, _, err := account.New(db).Create(ctx, account.NewAccount{Name: "Acme AB"})
+	is.NoErr(err)
+	other, _, err := account.New(db).Create(ctx, account.NewAccount{Name: "Other AB"})
+	is.NoErr(err)
+
+	// Accounts without a contract have no contract.
+	_, err = c.QueryByAccount(ctx, acme.ID)
+	is.Equal(err, ErrNotFound)
+
+	// Create contract.
+	v1, err := c.Create(ctx, NewContract{ID: "acme", AccountID: acme.ID, Discount: 10})
+	is.NoErr(err)
+	is.Equal(v1.Version, 1)
+
+	current, err := c.QueryByAccount(ctx, acme.ID)
+	is.NoErr(err)
+	is.Equal(current, v1)
+
+	// Renegotiated rates are added as a new version.
+	override := pricing.FlatRate()
+	v2, err := c.Create(ctx, NewContract{
+		ID:        "acme",
+		AccountID: acme.ID,
+		Discount:  15,
+		Lanes: []pricing.ContractLane{
+			{From: "nordic", To: "nordic", Domestic: true, Discount: 25},
+			{From: "nordic", To: "outside_eu", Tariff: &override},
+		},
+	})
+	is.NoErr(err)
+	is.Equal(v2.Version, 2)
+
+	current, err = c.QueryByAccount(ctx, acme.ID)
+	is.NoErr(err)
+	is.Equal(current, v2)
+
+	contract, err := c.ContractOf(ctx, acme.ID)
+	is.NoErr(err)
+	is.Equal(contract, v2.Contract())
+
+	// Contracts belong to a single account.
+	_, err = c.Create(ctx, NewContract{ID: "acme", AccountID: other.ID, Discount: 10})
+	is.Equal(err, ErrAccountMismatch)
+
+	// Invalid contracts are rejected.
+	_, err = c.Create(ctx, NewContract{ID: "other", AccountID: other.ID, Discount: 100})
+	is.True(err != nil)
+}
@@ -0,0 +1,110 @@
+// Package country contains country catalogue related read functionality. The
+// countries table holds additions to and overrides of the ISO 3166-1 dataset
+// embedded in the region package.
+package country
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/johanronkko/quote-service/internal/business/region"
+)
+
+// Info represents an individual country in the catalogue. Unset fields are
+// taken from the embedded dataset.
+type Info struct {
+	Alpha2       string   `json:"alpha2"`
+	Alpha3       *string  `json:"alpha3,omitempty"`
+	Name         *string  `json:"name,omitempty"`
+	Nordic       *bool    `json:"nordic,omitempty"`
+	EU           *bool    `json:"eu,omitempty"`
+	EEA          *bool    `json:"eea,omitempty"`
+	CustomsUnion *bool    `json:"customs_union,omitempty"`
+	VATRate      *float64 `json:"vat_rate,omitempty"`
+	Active       bool     `json:"active"`
+}
+
+// Country manages the set of API's for country access.
+type Country struct {
+	db *sqlx.DB
+}
+
+// New constructs a Country for api access.
+func New(db *sqlx.DB) Country {
+	return Country{db}
+}
+
+// Query retrieves every country in the catalogue from the database.
+func (c Country) Query(ctx context.Context) ([]Info, error) {
+
+	const query = `
+	SELECT
+		*
+	FROM
+		countries
+	ORDER BY
+		alpha2`
+
+	queryCountries := []queryCountry{}
+	if err := c.db.SelectContext(ctx, &queryCountries, query); err != nil {
+		return nil, fmt.Errorf("selecting countries: %w", err)
+	}
+
+	countries := []Info{}
+	for _, qc := range queryCountries {
+		countries = append(countries, qc.toInfo())
+	}
+
+	return countries, nil
+}
+
+// LoadCountries implements region.Loader.
+func (c Country) LoadCountries(ctx context.Context) ([]region.Override, error) {
+	infos, err := c.Query(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	countries := []region.Override{}
+	for _, info := range infos {
+		countries = append(countries, region.Override{
+			Alpha2:       info.Alpha2,
+			Alpha3:       info.Alpha3,
+			Name:         info.Name,
+			Nordic:       info.Nordic,
+			EU:           info.EU,
+			EEA:          info.EEA,
+			CustomsUnion: info.CustomsUnion,
+			VATRate:      info.VATRate,
+			Active:       info.Active,
+		})
+	}
+	return countries, nil
+}
+
+type queryCountry struct {
+	Alpha2       string   `db:"alpha2"`
+	Alpha3       *string  `db:"alpha3"`
+	Name         *string  `db:"name"`
+	Active       bool     `db:"active"`
+	Nordic       *bool    `db:"nordic"`
+	EU           *bool    `db:"eu"`
+	EEA          *bool    `db:"eea"`
+	CustomsUnion *bool    `db:"customs_union"`
+	VATRate      *float64 `db:"vat_rate"`
+}
+
+func (qc queryCountry) toInfo() Info {
+	return Info{
+		Alpha2:       qc.Alpha2,
+		Alpha3:       qc.Alpha3,
+		Name:         qc.Name,
+		Nordic:       qc.Nordic,
+		EU:           qc.EU,
+		EEA:          qc.EEA,
+		CustomsUnion: qc.CustomsUnion,
+		VATRate:      qc.VATRate,
+		Active:       qc.Active,
+	}
+}
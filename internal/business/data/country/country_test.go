@@ -0,0 +1,68 @@
+package country
+
+import (
+	"context"
+	"testing"
+
+	"github.com/johanronkko/quote-service/internal/business/region"
+	"github.com/johanronkko/quote-service/internal/business/tests"
+	"github.com/matryer/is"
+)
+
+func TestCountry(t *testing.T) {
+	is := is.New(t)
+
+	db := tests.NewUnit(t)
+	c := New(db)
+
+	ctx := context.Background()
+
+	// The countries of earlier migrations are kept as overrides of the
+	// embedded dataset, with the columns they did not have left unset.
+	countries, err := c.Query(ctx)
+	is.NoErr(err)
+	is.Equal(len(countries), 35)
+	var sweden Info
+	for _, info := range countries {
+		if info.Alpha2 == "se" {
+			sweden = info
+		}
+	}
+	is.True(sweden.Active)
+	is.True(*sweden.Nordic)
+	is.Equal(sweden.EU, nil)
+	is.Equal(sweden.VATRate, nil)
+
+	// Add a country and deactivate another.
+	const insert = `
+	INSERT INTO countries
+		(alpha2, alpha3, name, active, nordic, eu, eea, customs_union)
+	VALUES
+		('xk', 'xkx', 'Kosovo', TRUE, FALSE, FALSE, FALSE, FALSE)`
+	_, err = db.ExecContext(ctx, insert)
+	is.NoErr(err)
+	_, err = db.ExecContext(ctx, `UPDATE countries SET active = FALSE WHERE alpha2 = 'fr'`)
+	is.NoErr(err)
+
+	loaded, err := c.LoadCountries(ctx)
+	is.NoErr(err)
+	is.Equal(len(loaded), 36)
+
+	regions := region.NewCache(c)
+	is.NoErr(regions.Refresh(ctx))
+
+	r, err := regions.From("xk")
+	is.NoErr(err)
+	is.Equal(r, region.Region(region.OutsideEU))
+
+	_, err = regions.From("fr")
+	is.Equal(err, region.ErrUnsupportedCountryCode)
+
+	// Sweden keeps the VAT rate and EU membership of the dataset.
+	se, err := regions.Country("se")
+	is.NoErr(err)
+	is.Equal(se.Alpha2, "SE")
+	is.True(se.EU)
+	is.Equal(se.VATRate, 0.25)
+	is.Equal(se.Region(), region.Region(region.Nordic))
+}
@@ -0,0 +1,353 @@
+// Package customer contains the address books of senders and receivers of
+// quotes. Every account has its own address book, and the customers of
+// anonymous quotes are kept in an address book of their own. A customer is
+// identified by its email and address, so the same customer is stored once
+// per address book however many quotes it is on.
+package customer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/johanronkko/quote-service/internal/business/address"
+	"github.com/johanronkko/quote-service/internal/business/delivery"
+	"github.com/johanronkko/quote-service/internal/business/validate"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrNotFound is used when a specific Customer is requested but does not
+	// exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrDuplicate occurs when saving a customer with the email and address
+	// of another customer in the same address book.
+	ErrDuplicate = errors.New("customer with email and address already exists")
+)
+
+// Info represents an individual customer. AccountID is the account of the
+// address book of the customer, empty for customers of anonymous quotes.
+type Info struct {
+	ID        string          `json:"id"`
+	AccountID string          `json:"account_id,omitempty"`
+	Name      string          `json:"name"`
+	Email     string          `json:"email"`
+	Address   address.Address `json:"address"`
+	VATNumber string          `json:"vat_number,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// NewCustomer contains information needed to create or update a Customer.
+// Addresses are normalized before they are validated and saved, and emails
+// and addresses are compared case-insensitively. AccountID is the account of
+// the address book of the customer, and is not part of the request body.
+type NewCustomer struct {
+	AccountID string          `json:"-"`
+	Name      string          `json:"name" validate:"required,personname"`
+	Email     string          `json:"email" validate:"required,email"`
+	Address   address.Address `json:"address"`
+	VATNumber string          `json:"vat_number,omitempty" validate:"omitempty,vatnumber"`
+}
+
+// Customer manages the set of API's for customer access.
+type Customer struct {
+	db    *sqlx.DB
+	clock delivery.Clock
+}
+
+// New constructs a Customer for api access. Customers are timestamped with
+// the time told by clock.
+func New(db *sqlx.DB, clock delivery.Clock) Customer {
+	return Customer{db, clock}
+}
+
+// Create adds a customer to the address book of the account of nc. Returns
+// ErrDuplicate if a customer with the same email and address exists in the
+// address book.
+func (c Customer) Create(ctx context.Context, nc NewCustomer) (Info, error) {
+	nc.Address = nc.Address.Normalize()
+	if err := validate.Check(nc); err != nil {
+		return Info{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	now := c.clock.Now().UTC().Truncate(time.Microsecond)
+	info := toInfo(validate.GenerateID(), nc, now)
+
+	const query = `
+	INSERT INTO customers
+		(customer_id, account_id, name, email, address, postal_code, city, region, country_code, vat_number, created_at, updated_at)
+	VALUES
+		(:customer_id, :account_id, :name, :email, :address, :postal_code, :city, :region, :country_code, :vat_number, :created_at, :updated_at)`
+
+	if _, err := c.db.NamedExecContext(ctx, query, toQueryCustomer(info)); err != nil {
+		if isUniqueViolation(err) {
+			return Info{}, ErrDuplicate
+		}
+		return Info{}, fmt.Errorf("inserting customer: %w", err)
+	}
+
+	return info, nil
+}
+
+// Update replaces the details of the customer with customerID in the address
+// book of the account of nc. Quotes keep the details the customer had when
+// they were created. Returns ErrDuplicate if another customer in the address
+// book has the same email and address.
+func (c Customer) Update(ctx context.Context, customerID string, nc NewCustomer) (Info, error) {
+	nc.Address = nc.Address.Normalize()
+	if err := validate.Check(nc); err != nil {
+		return Info{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	current, err := c.QueryByID(ctx, nc.AccountID, customerID)
+	if err != nil {
+		return Info{}, err
+	}
+	info := toInfo(customerID, nc, c.clock.Now().UTC().Truncate(time.Microsecond))
+	info.CreatedAt = current.CreatedAt
+
+	const query = `
+	UPDATE
+		customers
+	SET
+		name = :name,
+		email = :email,
+		address = :address,
+		postal_code = :postal_code,
+		city = :city,
+		region = :region,
+		country_code = :country_code,
+		vat_number = :vat_number,
+		updated_at = :updated_at
+	WHERE
+		customer_id = :customer_id AND account_id IS NOT DISTINCT FROM :account_id`
+
+	res, err := c.db.NamedExecContext(ctx, query, toQueryCustomer(info))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return Info{}, ErrDuplicate
+		}
+		return Info{}, fmt.Errorf("updating customer %q: %w", customerID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return Info{}, fmt.Errorf("updating customer %q: %w", customerID, err)
+	}
+	if n == 0 {
+		return Info{}, ErrNotFound // Deleted meanwhile.
+	}
+
+	return info, nil
+}
+
+// Delete removes the customer with customerID from the address book of the
+// account with accountID. Quotes of the customer keep its details but no
+// longer reference it.
+func (c Customer) Delete(ctx context.Context, accountID, customerID string) error {
+
+	const query = `
+	DELETE FROM
+		customers
+	WHERE
+		customer_id = $1 AND account_id IS NOT DISTINCT FROM $2`
+
+	res, err := c.db.ExecContext(ctx, query, customerID, nullable(accountID))
+	if err != nil {
+		return fmt.Errorf("deleting customer %q: %w", customerID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("deleting customer %q: %w", customerID, err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Query retrieves the page of the customers in the address book of the
+// account of filter selected by filter, ordered by name, and the cursor of
+// the next page, empty on the last page. Returns ErrInvalidCursor if the
+// cursor of filter is not valid.
+func (c Customer) Query(ctx context.Context, filter Filter) ([]Info, string, error) {
+	if err := validate.Check(filter); err != nil {
+		return nil, "", fmt.Errorf("validating data: %w", err)
+	}
+	where, args, err := filter.where()
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := `
+	SELECT
+		*
+	FROM
+		customers
+	` + where
+
+	queryCustomers := []queryCustomer{}
+	if err := c.db.SelectContext(ctx, &queryCustomers, query, args...); err != nil {
+		return nil, "", fmt.Errorf("selecting customers: %w", err)
+	}
+
+	var next string
+	if len(queryCustomers) > filter.limit() {
+		queryCustomers = queryCustomers[:filter.limit()]
+		last := queryCustomers[len(queryCustomers)-1]
+		next = cursor{Name: last.Name, ID: last.ID}.encode()
+	}
+
+	customers := []Info{}
+	for _, qc := range queryCustomers {
+		customers = append(customers, qc.toInfo())
+	}
+
+	return customers, next, nil
+}
+
+// QueryByID gets the customer with customerID in the address book of the
+// account with accountID from the database.
+func (c Customer) QueryByID(ctx context.Context, accountID, customerID string) (Info, error) {
+
+	const query = `
+	SELECT
+		*
+	FROM
+		customers
+	WHERE
+		customer_id = $1 AND account_id IS NOT DISTINCT FROM $2`
+
+	var qc queryCustomer
+	if err := c.db.GetContext(ctx, &qc, query, customerID, nullable(accountID)); err != nil {
+		if err == sql.ErrNoRows {
+			return Info{}, ErrNotFound
+		}
+		return Info{}, fmt.Errorf("selecting customer %q: %w", customerID, err)
+	}
+
+	return qc.toInfo(), nil
+}
+
+// Ensure returns the ID of the customer with the email and address of nc in
+// the address book of the account of nc within tx, adding the customer at now
+// if it does not exist. The details of an existing customer are left as they
+// are. nc is expected to be valid.
+func Ensure(ctx context.Context, tx *sqlx.Tx, nc NewCustomer, now time.Time) (string, error) {
+	nc.Address = nc.Address.Normalize()
+	info := toInfo(validate.GenerateID(), nc, now.UTC().Truncate(time.Microsecond))
+
+	const insert = `
+	INSERT INTO customers
+		(customer_id, account_id, name, email, address, postal_code, city, region, country_code, vat_number, created_at, updated_at)
+	VALUES
+		(:customer_id, :account_id, :name, :email, :address, :postal_code, :city, :region, :country_code, :vat_number, :created_at, :updated_at)
+	ON CONFLICT DO NOTHING`
+
+	if _, err := tx.NamedExecContext(ctx, insert, toQueryCustomer(info)); err != nil {
+		return "", fmt.Errorf("inserting customer: %w", err)
+	}
+
+	const query = `
+	SELECT
+		customer_id
+	FROM
+		customers
+	WHERE
+		account_id IS NOT DISTINCT FROM $1 AND lower(email) = lower($2) AND lower(address) = lower($3) AND lower(postal_code) = lower($4) AND lower(city) = lower($5) AND country_code = $6`
+
+	qc := toQueryCustomer(info)
+	var id string
+	if err := tx.GetContext(ctx, &id, query, qc.AccountID, qc.Email, qc.Address, qc.PostalCode, qc.City, qc.CountryCode); err != nil {
+		return "", fmt.Errorf("selecting customer: %w", err)
+	}
+
+	return id, nil
+}
+
+// isUniqueViolation reports whether err is caused by a unique constraint.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// nullable returns the account_id column value of accountID, NULL if empty.
+func nullable(accountID string) *string {
+	if accountID == "" {
+		return nil
+	}
+	return &accountID
+}
+
+func toInfo(id string, nc NewCustomer, now time.Time) Info {
+	return Info{
+		ID:        id,
+		AccountID: nc.AccountID,
+		Name:      nc.Name,
+		Email:     nc.Email,
+		Address:   nc.Address,
+		VATNumber: nc.VATNumber,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+type queryCustomer struct {
+	ID          string    `db:"customer_id"`
+	AccountID   *string   `db:"account_id"`
+	Name        string    `db:"name"`
+	Email       string    `db:"email"`
+	Address     string    `db:"address"`
+	PostalCode  string    `db:"postal_code"`
+	City        string    `db:"city"`
+	Region      string    `db:"region"`
+	CountryCode string    `db:"country_code"`
+	VATNumber   string    `db:"vat_number"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+func toQueryCustomer(info Info) queryCustomer {
+	return queryCustomer{
+		ID:          info.ID,
+		AccountID:   nullable(info.AccountID),
+		Name:        info.Name,
+		Email:       info.Email,
+		Address:     strings.Join(info.Address.Lines, "\n"),
+		PostalCode:  info.Address.PostalCode,
+		City:        info.Address.City,
+		Region:      info.Address.Region,
+		CountryCode: info.Address.CountryCode,
+		VATNumber:   info.VATNumber,
+		CreatedAt:   info.CreatedAt,
+		UpdatedAt:   info.UpdatedAt,
+	}
+}
+
+func (qc queryCustomer) toInfo() Info {
+	info := Info{
+		ID:    qc.ID,
+		Name:  qc.Name,
+		Email: qc.Email,
+		Address: address.Address{
+			Lines:       strings.Split(qc.Address, "\n"),
+			PostalCode:  qc.PostalCode,
+			City:        qc.City,
+			Region:      qc.Region,
+			CountryCode: qc.CountryCode,
+		},
+		VATNumber: qc.VATNumber,
+		CreatedAt: qc.CreatedAt.UTC(),
+		UpdatedAt: qc.UpdatedAt.UTC(),
+	}
+	if qc.AccountID != nil {
+		info.AccountID = *qc.AccountID
+	}
+	return info
+}
@@ -0,0 +1,141 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/address"
+	"github.com/johanronkko/quote-service/internal/business/data/account"
+	"github.com/johanronkko/quote-service/internal/business/tests"
+	"github.com/johanronkko/quote-service/internal/business/validate"
+	"github.com/matryer/is"
+)
+
+// clock is a delivery.Clock stopped at a fixed time.
+type clock time.Time
+
+func (c clock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestCustomer(t *testing.T) {
+	is := is.New(t)
+
+	db := tests.NewIntegration(t)
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	c := New(db, clock(now))
+
+	ctx := context.Background()
+
+	// Seeded customers are in the address book of the seeded account.
+	customers, _, err := c.Query(ctx, Filter{AccountID: "9d3a1b6e-2f4c-4b8e-a0d1-6c5e7f8a9b10"})
+	is.NoErr(err)
+	is.Equal(len(customers), 4)
+	customers, _, err = c.Query(ctx, Filter{})
+	is.NoErr(err)
+	is.Equal(len(customers), 0)
+
+	// The address book of a new account is empty.
+	acme, _, err := account.New(db).Create(ctx, account.NewAccount{Name: "Acme AB"})
+	is.NoErr(err)
+	customers, _, err = c.Query(ctx, Filter{AccountID: acme.ID})
+	is.NoErr(err)
+	is.Equal(len(customers), 0)
+
+	// Create customer.
+	nc := NewCustomer{
+		AccountID: acme.ID,
+		Name:      "Sven Svensson",
+		Email:     "sven.svensson@test.com",
+		Address: address.Address{
+			Lines:       []string{"Testgatan 42B"},
+			PostalCode:  "12345",
+			City:        "Göteborg",
+			CountryCode: "SE",
+		},
+	}
+	info, err := c.Create(ctx, nc)
+	is.NoErr(err)
+	is.Equal(info.Name, nc.Name)
+	is.Equal(info.AccountID, acme.ID)
+	is.Equal(info.CreatedAt, now)
+
+	// Query by ID returns correct customer, only from its address book.
+	saved, err := c.QueryByID(ctx, acme.ID, info.ID)
+	is.NoErr(err)
+	is.Equal(saved, info)
+	_, err = c.QueryByID(ctx, "", info.ID)
+	is.Equal(err, ErrNotFound)
+
+	// Customers are unique by email and address in an address book,
+	// regardless of case.
+	dup := nc
+	dup.Email = "Sven.Svensson@Test.com"
+	_, err = c.Create(ctx, dup)
+	is.Equal(err, ErrDuplicate)
+	untidy := nc
+	untidy.Address.Lines = []string{" Testgatan  42B ", ""}
+	untidy.Address.CountryCode = "se"
+	_, err = c.Create(ctx, untidy) // Normalized before it is compared.
+	is.Equal(err, ErrDuplicate)
+	anonymous := dup
+	anonymous.AccountID = ""
+	other, err := c.Create(ctx, anonymous)
+	is.NoErr(err)
+	is.NoErr(c.Delete(ctx, "", other.ID))
+
+	// Update customer.
+	later := New(db, clock(now.Add(time.Hour)))
+	nc.Address.Lines = []string{"Testgatan 42B", "c/o Karlsson"}
+	updated, err := later.Update(ctx, info.ID, nc)
+	is.NoErr(err)
+	is.Equal(updated.Address, nc.Address)
+	is.Equal(updated.CreatedAt, now)
+	is.Equal(updated.UpdatedAt, now.Add(time.Hour))
+	saved, err = c.QueryByID(ctx, acme.ID, info.ID)
+	is.NoErr(err)
+	is.Equal(saved, updated)
+
+	// Ensure returns the existing customer, or adds a new one.
+	tx, err := db.BeginTxx(ctx, nil)
+	is.NoErr(err)
+	dup.Address = nc.Address
+	id, err := Ensure(ctx, tx, dup, now)
+	is.NoErr(err)
+	is.Equal(id, info.ID)
+	elsewhere := nc
+	elsewhere.Address.City = "Mölndal" // Same street in another city.
+	id, err = Ensure(ctx, tx, elsewhere, now)
+	is.NoErr(err)
+	is.True(id != info.ID)
+	is.NoErr(tx.Commit())
+
+	// Customers are paged by name.
+	customers, next, err := c.Query(ctx, Filter{AccountID: acme.ID, Limit: 1})
+	is.NoErr(err)
+	is.Equal(len(customers), 1)
+	is.True(next != "")
+	rest, next, err := c.Query(ctx, Filter{AccountID: acme.ID, Limit: 1, Cursor: next})
+	is.NoErr(err)
+	is.Equal(len(rest), 1)
+	is.Equal(next, "")
+	is.True(rest[0].ID != customers[0].ID)
+	_, _, err = c.Query(ctx, Filter{AccountID: acme.ID, Cursor: "banana"})
+	is.Equal(err, ErrInvalidCursor)
+
+	// Delete customer.
+	is.Equal(c.Delete(ctx, "", info.ID), ErrNotFound)
+	is.NoErr(c.Delete(ctx, acme.ID, info.ID))
+	_, err = c.QueryByID(ctx, acme.ID, info.ID)
+	is.Equal(err, ErrNotFound)
+	is.Equal(c.Delete(ctx, acme.ID, info.ID), ErrNotFound)
+	_, err = c.Update(ctx, validate.GenerateID(), nc)
+	is.Equal(err, ErrNotFound)
+
+	// Invalid customers are rejected.
+	_, err = c.Create(ctx, NewCustomer{Name: "Sven Svensson"})
+	var fieldErrs validate.FieldErrors
+	is.True(errors.As(err, &fieldErrs))
+}
@@ -0,0 +1,80 @@
+package customer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidCursor occurs when a page of customers is requested with a cursor
+// that was not returned by Query.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page sizes of customers.
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// Filter selects a page of the address book of the account with AccountID,
+// the address book of anonymous quotes if empty. Customers are ordered by
+// name, with ties broken by ID, and Limit customers are returned per page,
+// DefaultLimit by default. Cursor is the cursor of the page to get, empty for
+// the first.
+type Filter struct {
+	AccountID string `json:"-"`
+	Limit     int    `json:"limit" validate:"omitempty,gte=1,lte=500"`
+	Cursor    string `json:"cursor"`
+}
+
+// cursor is the position after the last customer of a page.
+type cursor struct {
+	Name string `json:"n"`
+	ID   string `json:"id"`
+}
+
+// encode returns the opaque form of c.
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses the opaque form of a cursor.
+func decodeCursor(s string) (cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// limit returns the page size of f.
+func (f Filter) limit() int {
+	if f.Limit == 0 {
+		return DefaultLimit
+	}
+	return f.Limit
+}
+
+// where returns the conditions and the ordering selecting the page of f, and
+// their arguments. Returns ErrInvalidCursor if the cursor of f is not valid.
+func (f Filter) where() (string, []interface{}, error) {
+	args := []interface{}{nullable(f.AccountID)}
+	query := "WHERE\n\t\taccount_id IS NOT DISTINCT FROM $1"
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, c.Name, c.ID)
+		query += "\n\t\tAND (name, customer_id) > ($2, $3)"
+	}
+	args = append(args, f.limit()+1)
+	query += fmt.Sprintf("\n\tORDER BY\n\t\tname, customer_id\n\tLIMIT $%d", len(args))
+	return query, args, nil
+}
@@ -0,0 +1,49 @@
+package customer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestFilterWhere(t *testing.T) {
+	after := cursor{Name: "Sven Svensson", ID: "a"}.encode()
+
+	cases := []struct {
+		Name string
+
+		Filter  Filter
+		Want    []string
+		NumArgs int
+		Err     error
+	}{
+		{"empty", Filter{}, []string{"WHERE\n\t\taccount_id IS NOT DISTINCT FROM $1", "ORDER BY\n\t\tname, customer_id\n\tLIMIT $2"}, 2, nil},
+		{"account", Filter{AccountID: "acme", Limit: 10}, []string{"account_id IS NOT DISTINCT FROM $1", "LIMIT $2"}, 2, nil},
+		{"after cursor", Filter{Cursor: after}, []string{"AND (name, customer_id) > ($2, $3)", "LIMIT $4"}, 4, nil},
+		{"malformed cursor", Filter{Cursor: "banana"}, nil, 0, ErrInvalidCursor},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			query, args, err := tc.Filter.where()
+			is.Equal(err, tc.Err)
+			is.Equal(len(args), tc.NumArgs)
+			for _, part := range tc.Want {
+				i := strings.Index(query, part)
+				is.True(i >= 0) // Query has part.
+				query = query[i+len(part):]
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	is := is.New(t)
+
+	c := cursor{Name: "Sven Svensson", ID: "a"}
+	decoded, err := decodeCursor(c.encode())
+	is.NoErr(err)
+	is.Equal(decoded, c)
+}
@@ -0,0 +1,314 @@
+// Package promotion contains promo code related create, read and redemption
+// functionality. Redemptions are counted in the transaction creating the quote
+// the code is redeemed on, so the redemption limits of a promotion hold under
+// concurrent requests.
+package promotion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/validate"
+)
+
+var (
+	// ErrNotFound is used when a specific Promotion is requested but does not
+	// exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrInvalidCode occurs when redeeming a promo code that does not exist or
+	// is not currently valid.
+	ErrInvalidCode = errors.New("invalid promo code")
+
+	// ErrLimitReached occurs when redeeming a promo code that has reached its
+	// maximum number of redemptions, in total or for the customer.
+	ErrLimitReached = errors.New("promo code redemption limit reached")
+)
+
+// Discount types of a promotion.
+const (
+	DiscountPercent = "percent" // Value is the percentage off the shipment cost.
+	DiscountAmount  = "amount"  // Value is an amount in the tariff currency.
+)
+
+// Info represents an individual promotion. A zero MaxRedemptions or
+// PerCustomerLimit means unlimited.
+type Info struct {
+	Code             string    `json:"code"`
+	DiscountType     string    `json:"discount_type"`
+	Value            float64   `json:"value"`
+	ValidFrom        time.Time `json:"valid_from"`
+	ValidUntil       time.Time `json:"valid_until"`
+	MaxRedemptions   int       `json:"max_redemptions"`
+	PerCustomerLimit int       `json:"per_customer_limit"`
+	Redemptions      int       `json:"redemptions"`
+}
+
+// Promotion returns the pricing promotion of the info.
+func (i Info) Promotion() pricing.Promotion {
+	p := pricing.Promotion{Code: i.Code}
+	if i.DiscountType == DiscountPercent {
+		p.Percent = i.Value
+	} else {
+		p.Amount = i.Value
+	}
+	return p
+}
+
+// NewPromotion contains information needed to create a new Promotion. Codes
+// are case-insensitive and stored in uppercase.
+type NewPromotion struct {
+	Code             string    `json:"code" validate:"required,alphanum,max=32"`
+	DiscountType     string    `json:"discount_type" validate:"required,oneof=percent amount"`
+	Value            float64   `json:"value" validate:"required,gt=0"`
+	ValidFrom        time.Time `json:"valid_from" validate:"required"`
+	ValidUntil       time.Time `json:"valid_until" validate:"required,gtfield=ValidFrom"`
+	MaxRedemptions   int       `json:"max_redemptions" validate:"gte=0"`
+	PerCustomerLimit int       `json:"per_customer_limit" validate:"gte=0"`
+}
+
+// Promotion manages the set of API's for promotion access.
+type Promotion struct {
+	db *sqlx.DB
+}
+
+// New constructs a Promotion for api access.
+func New(db *sqlx.DB) Promotion {
+	return Promotion{db}
+}
+
+// Create adds a promotion to the database.
+func (p Promotion) Create(ctx context.Context, np NewPromotion) (Info, error) {
+	if err := validate.Check(np); err != nil {
+		return Info{}, fmt.Errorf("validating data: %w", err)
+	}
+	if np.DiscountType == DiscountPercent && np.Value >= 100 {
+		return Info{}, fmt.Errorf("validating data: percent %v is not below 100", np.Value)
+	}
+
+	info := Info{
+		Code:             strings.ToUpper(np.Code),
+		DiscountType:     np.DiscountType,
+		Value:            np.Value,
+		ValidFrom:        np.ValidFrom.UTC().Truncate(time.Microsecond),
+		ValidUntil:       np.ValidUntil.UTC().Truncate(time.Microsecond),
+		MaxRedemptions:   np.MaxRedemptions,
+		PerCustomerLimit: np.PerCustomerLimit,
+	}
+
+	const query = `
+	INSERT INTO promotions
+		(code, discount_type, value, valid_from, valid_until, max_redemptions, per_customer_limit, redemptions)
+	VALUES
+		(:code, :discount_type, :value, :valid_from, :valid_until, :max_redemptions, :per_customer_limit, :redemptions)`
+
+	if _, err := p.db.NamedExecContext(ctx, query, toQueryPromotion(info)); err != nil {
+		return Info{}, fmt.Errorf("inserting promotion: %w", err)
+	}
+
+	return info, nil
+}
+
+// Query retrieves every promotion from the database, most recent first.
+func (p Promotion) Query(ctx context.Context) ([]Info, error) {
+
+	const query = `
+	SELECT
+		*
+	FROM
+		promotions
+	ORDER BY
+		valid_from DESC, code`
+
+	queryPromotions := []queryPromotion{}
+	if err := p.db.SelectContext(ctx, &queryPromotions, query); err != nil {
+		return nil, fmt.Errorf("selecting promotions: %w", err)
+	}
+
+	promotions := []Info{}
+	for _, qp := range queryPromotions {
+		promotions = append(promotions, qp.toInfo())
+	}
+
+	return promotions, nil
+}
+
+// QueryByCode gets the specified promotion from the database.
+func (p Promotion) QueryByCode(ctx context.Context, code string) (Info, error) {
+	info, err := queryByCode(ctx, p.db, code, false)
+	if err == sql.ErrNoRows {
+		return Info{}, ErrNotFound
+	} else if err != nil {
+		return Info{}, fmt.Errorf("selecting promotion %q: %w", code, err)
+	}
+	return info, nil
+}
+
+// End ends the promotion with code at now, if it has not already ended.
+func (p Promotion) End(ctx context.Context, code string, now time.Time) error {
+
+	const query = `
+	UPDATE
+		promotions
+	SET
+		valid_until = LEAST(valid_until, $2)
+	WHERE
+		code = $1`
+
+	res, err := p.db.ExecContext(ctx, query, strings.ToUpper(code), now.UTC().Truncate(time.Microsecond))
+	if err != nil {
+		return fmt.Errorf("updating promotion %q: %w", code, err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("updating promotion %q: %w", code, err)
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Check returns the promotion with code if it can be redeemed by customer at
+// now, without redeeming it. Returns ErrInvalidCode or ErrLimitReached if it
+// cannot.
+func Check(ctx context.Context, db sqlx.QueryerContext, code, customer string, now time.Time) (Info, error) {
+	return check(ctx, db, code, customer, now, false)
+}
+
+// Redeem redeems the promotion with code for customer on the quote with
+// quoteID within tx. The promotion is locked until tx ends, so concurrent
+// redemptions are counted one at a time. Returns ErrInvalidCode or
+// ErrLimitReached if the promotion cannot be redeemed. The quote may be added
+// later within tx.
+func Redeem(ctx context.Context, tx *sqlx.Tx, code, customer, quoteID string, now time.Time) (Info, error) {
+	info, err := check(ctx, tx, code, customer, now, true)
+	if err != nil {
+		return Info{}, err
+	}
+
+	const redemption = `
+	INSERT INTO promotion_redemptions
+		(code, quote_id, customer, redeemed_at)
+	VALUES
+		($1, $2, $3, $4)`
+
+	if _, err := tx.ExecContext(ctx, redemption, info.Code, quoteID, customer, now.UTC()); err != nil {
+		return Info{}, fmt.Errorf("inserting redemption: %w", err)
+	}
+
+	const count = `
+	UPDATE
+		promotions
+	SET
+		redemptions = redemptions + 1
+	WHERE
+		code = $1`
+
+	if _, err := tx.ExecContext(ctx, count, info.Code); err != nil {
+		return Info{}, fmt.Errorf("counting redemption: %w", err)
+	}
+
+	info.Redemptions++
+	return info, nil
+}
+
+// check returns the promotion with code if it can be redeemed by customer at
+// now. If lock is set, the promotion is locked for update.
+func check(ctx context.Context, db sqlx.QueryerContext, code, customer string, now time.Time, lock bool) (Info, error) {
+	info, err := queryByCode(ctx, db, code, lock)
+	if err == sql.ErrNoRows {
+		return Info{}, ErrInvalidCode
+	} else if err != nil {
+		return Info{}, fmt.Errorf("selecting promotion %q: %w", code, err)
+	}
+	if now.Before(info.ValidFrom) || !now.Before(info.ValidUntil) {
+		return Info{}, ErrInvalidCode
+	}
+	if info.MaxRedemptions > 0 && info.Redemptions >= info.MaxRedemptions {
+		return Info{}, ErrLimitReached
+	}
+
+	if info.PerCustomerLimit > 0 {
+		const query = `
+		SELECT
+			COUNT(*)
+		FROM
+			promotion_redemptions
+		WHERE
+			code = $1 AND customer = $2`
+
+		var redeemed int
+		if err := sqlx.GetContext(ctx, db, &redeemed, query, info.Code, customer); err != nil {
+			return Info{}, fmt.Errorf("counting redemptions of %q: %w", info.Code, err)
+		}
+		if redeemed >= info.PerCustomerLimit {
+			return Info{}, ErrLimitReached
+		}
+	}
+
+	return info, nil
+}
+
+// queryByCode gets the promotion with code. If lock is set, the promotion is
+// locked for update.
+func queryByCode(ctx context.Context, db sqlx.QueryerContext, code string, lock bool) (Info, error) {
+	query := `
+	SELECT
+		*
+	FROM
+		promotions
+	WHERE
+		code = $1`
+	if lock {
+		query += `
+	FOR UPDATE`
+	}
+
+	var qp queryPromotion
+	if err := sqlx.GetContext(ctx, db, &qp, query, strings.ToUpper(code)); err != nil {
+		return Info{}, err
+	}
+	return qp.toInfo(), nil
+}
+
+type queryPromotion struct {
+	Code             string    `db:"code"`
+	DiscountType     string    `db:"discount_type"`
+	Value            float64   `db:"value"`
+	ValidFrom        time.Time `db:"valid_from"`
+	ValidUntil       time.Time `db:"valid_until"`
+	MaxRedemptions   int       `db:"max_redemptions"`
+	PerCustomerLimit int       `db:"per_customer_limit"`
+	Redemptions      int       `db:"redemptions"`
+}
+
+func toQueryPromotion(info Info) queryPromotion {
+	return queryPromotion{
+		Code:             info.Code,
+		DiscountType:     info.DiscountType,
+		Value:            info.Value,
+		ValidFrom:        info.ValidFrom,
+		ValidUntil:       info.ValidUntil,
+		MaxRedemptions:   info.MaxRedemptions,
+		PerCustomerLimit: info.PerCustomerLimit,
+		Redemptions:      info.Redemptions,
+	}
+}
+
+func (qp queryPromotion) toInfo() Info {
+	return Info{
+		Code:             qp.Code,
+		DiscountType:     qp.DiscountType,
+		Value:            qp.Value,
+		ValidFrom:        qp.ValidFrom.UTC(),
+		ValidUntil:       qp.ValidUntil.UTC(),
+		MaxRedemptions:   qp.MaxRedemptions,
+		PerCustomerLimit: qp.PerCustomerLimit,
+		Redemptions:      qp.Redemptions,
+	}
+}
@@ -0,0 +1,85 @@
+package promotion
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/tests"
+	"github.com/matryer/is"
+)
+
+func TestPromotion(t *testing.T) {
+	is := is.New(t)
+
+	db := tests.NewUnit(t)
+	p := New(db)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	// Create promotion.
+	np := NewPromotion{
+		Code:             "spring25",
+		DiscountType:     DiscountPercent,
+		Value:            25,
+		ValidFrom:        now.Add(-time.Hour),
+		ValidUntil:       now.Add(time.Hour),
+		MaxRedemptions:   3,
+		PerCustomerLimit: 1,
+	}
+	info, err := p.Create(ctx, np)
+	is.NoErr(err)
+	is.Equal(info.Code, "SPRING25")
+	is.Equal(info.Promotion(), pricing.Promotion{Code: "SPRING25", Percent: 25})
+
+	saved, err := p.QueryByCode(ctx, "Spring25")
+	is.NoErr(err)
+	is.Equal(saved, info)
+
+	_, err = p.QueryByCode(ctx, "WINTER10")
+	is.Equal(err, ErrNotFound)
+
+	// Invalid promotions are rejected.
+	invalid := np
+	invalid.Code, invalid.Value = "HALFOFF", 100
+	_, err = p.Create(ctx, invalid)
+	is.True(err != nil)
+	invalid.Value, invalid.ValidUntil = 50, invalid.ValidFrom
+	_, err = p.Create(ctx, invalid)
+	is.True(err != nil)
+
+	// Redemptions are counted per customer.
+	_, err = Check(ctx, db, "SPRING25", "a@example.com", now)
+	is.NoErr(err)
+
+	tx := db.MustBeginTx(ctx, nil)
+	redeemed, err := Redeem(ctx, tx, "SPRING25", "a@example.com", "quote-1", now)
+	is.NoErr(err)
+	is.Equal(redeemed.Redemptions, 1)
+	_, err = Redeem(ctx, tx, "SPRING25", "a@example.com", "quote-2", now)
+	is.Equal(err, ErrLimitReached)
+	is.NoErr(tx.Rollback()) // The quotes were never added.
+
+	saved, err = p.QueryByCode(ctx, "SPRING25")
+	is.NoErr(err)
+	is.Equal(saved.Redemptions, 0)
+
+	// Codes cannot be redeemed outside the validity window.
+	_, err = Check(ctx, db, "SPRING25", "a@example.com", now.Add(2*time.Hour))
+	is.Equal(err, ErrInvalidCode)
+	_, err = Check(ctx, db, "WINTER10", "a@example.com", now)
+	is.Equal(err, ErrInvalidCode)
+
+	// Ended promotions cannot be redeemed.
+	is.NoErr(p.End(ctx, "spring25", now))
+	_, err = Check(ctx, db, "SPRING25", "b@example.com", now)
+	is.Equal(err, ErrInvalidCode)
+	is.Equal(p.End(ctx, "WINTER10", now), ErrNotFound)
+
+	promotions, err := p.Query(ctx)
+	is.NoErr(err)
+	is.Equal(len(promotions), 1)
+	is.Equal(promotions[0].ValidUntil, now.Truncate(time.Microsecond))
+}
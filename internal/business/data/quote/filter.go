@@ -0,0 +1,187 @@
+package quote
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrInvalidCursor occurs when a page of quotes is requested with a cursor
+// that was not returned for the same sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Sort orders of quotes.
+const (
+	SortCreatedAt = "created_at"
+	SortTotalCost = "total_cost"
+	SortWeight    = "weight"
+)
+
+// Page sizes of quotes.
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// Filter selects a page of the quotes of the account with AccountID, which is
+// not part of the query parameters, so anonymous quotes are never selected.
+// Quotes match every other criterion that is set. FromCountryCodes and
+// ToCountryCodes match any of the countries of the sender and the receiver,
+// and Email the email of either. Weight is in kg and cost is the total cost in
+// minor units. Quotes are created at or after CreatedFrom and before
+// CreatedUntil. Quotes are ordered by Sort, created_at by default, with ties
+// broken by ID, and Limit quotes are returned per page, DefaultLimit by
+// default. Cursor is the cursor of the page to get, empty for the first.
+type Filter struct {
+	AccountID        string     `json:"-"`
+	FromCountryCodes []string   `json:"from_country" validate:"omitempty,max=50,dive,iso3166_1_alpha2"`
+	ToCountryCodes   []string   `json:"to_country" validate:"omitempty,max=50,dive,iso3166_1_alpha2"`
+	MinWeight        *int       `json:"min_weight" validate:"omitempty,gte=0"`
+	MaxWeight        *int       `json:"max_weight" validate:"omitempty,gte=0"`
+	MinCost          *int64     `json:"min_cost" validate:"omitempty,gte=0"`
+	MaxCost          *int64     `json:"max_cost" validate:"omitempty,gte=0"`
+	Email            string     `json:"email" validate:"omitempty,email"`
+	CreatedFrom      *time.Time `json:"created_from"`
+	CreatedUntil     *time.Time `json:"created_until"`
+	Sort             string     `json:"sort" validate:"omitempty,oneof=created_at total_cost weight"`
+	Descending       bool       `json:"-"`
+	Limit            int        `json:"limit" validate:"omitempty,gte=1,lte=500"`
+	Cursor           string     `json:"cursor"`
+}
+
+// sortColumns are the columns of the sort orders.
+var sortColumns = map[string]string{
+	SortCreatedAt: "created_at",
+	SortTotalCost: "total_cost",
+	SortWeight:    "package_weight",
+}
+
+// cursor is the position after the last quote of a page in the sort order it
+// was returned in. At is set when ordered by creation time, and N otherwise.
+type cursor struct {
+	Sort       string    `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	At         time.Time `json:"t,omitempty"`
+	N          int64     `json:"n,omitempty"`
+	ID         string    `json:"id"`
+}
+
+// encode returns the opaque form of c.
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses the opaque form of a cursor.
+func decodeCursor(s string) (cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// sort returns the sort order of f.
+func (f Filter) sort() string {
+	if f.Sort == "" {
+		return SortCreatedAt
+	}
+	return f.Sort
+}
+
+// limit returns the page size of f.
+func (f Filter) limit() int {
+	if f.Limit == 0 {
+		return DefaultLimit
+	}
+	return f.Limit
+}
+
+// cursorOf returns the cursor positioned after qq in the sort order of f.
+func (f Filter) cursorOf(qq queryQuote) cursor {
+	c := cursor{Sort: f.sort(), Descending: f.Descending, ID: qq.ID}
+	switch c.Sort {
+	case SortCreatedAt:
+		c.At = qq.CreatedAt.UTC()
+	case SortTotalCost:
+		c.N = qq.TotalCost
+	case SortWeight:
+		c.N = int64(qq.Weight)
+	}
+	return c
+}
+
+// where returns the conditions and the ordering selecting the page of f, and
+// their arguments. Returns ErrInvalidCursor if the cursor of f is not in the
+// sort order of f.
+func (f Filter) where() (string, []interface{}, error) {
+	var conds []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conds = append(conds, "account_id = "+arg(f.AccountID))
+	if len(f.FromCountryCodes) > 0 {
+		conds = append(conds, "from_country_code = ANY("+arg(pq.Array(f.FromCountryCodes))+")")
+	}
+	if len(f.ToCountryCodes) > 0 {
+		conds = append(conds, "to_country_code = ANY("+arg(pq.Array(f.ToCountryCodes))+")")
+	}
+	if f.MinWeight != nil {
+		conds = append(conds, "package_weight >= "+arg(*f.MinWeight))
+	}
+	if f.MaxWeight != nil {
+		conds = append(conds, "package_weight <= "+arg(*f.MaxWeight))
+	}
+	if f.MinCost != nil {
+		conds = append(conds, "total_cost >= "+arg(*f.MinCost))
+	}
+	if f.MaxCost != nil {
+		conds = append(conds, "total_cost <= "+arg(*f.MaxCost))
+	}
+	if f.Email != "" {
+		email := arg(strings.ToLower(f.Email))
+		conds = append(conds, "(lower(from_email) = "+email+" OR lower(to_email) = "+email+")")
+	}
+	if f.CreatedFrom != nil {
+		conds = append(conds, "created_at >= "+arg(f.CreatedFrom.UTC()))
+	}
+	if f.CreatedUntil != nil {
+		conds = append(conds, "created_at < "+arg(f.CreatedUntil.UTC()))
+	}
+
+	column := sortColumns[f.sort()]
+	op, dir := ">", "ASC"
+	if f.Descending {
+		op, dir = "<", "DESC"
+	}
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		if c.Sort != f.sort() || c.Descending != f.Descending {
+			return "", nil, ErrInvalidCursor
+		}
+		var v interface{} = c.N
+		if c.Sort == SortCreatedAt {
+			v = c.At
+		}
+		conds = append(conds, fmt.Sprintf("(%s, quote_id) %s (%s, %s)", column, op, arg(v), arg(c.ID)))
+	}
+
+	query := "WHERE\n\t\t" + strings.Join(conds, "\n\t\tAND ")
+	query += fmt.Sprintf("\n\tORDER BY\n\t\t%s %s, quote_id %s\n\tLIMIT %s", column, dir, dir, arg(f.limit()+1))
+	return query, args, nil
+}
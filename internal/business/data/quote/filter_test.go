@@ -0,0 +1,81 @@
+package quote
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestFilterWhere(t *testing.T) {
+	weight, cost := 10, int64(100_00)
+	at := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	after := Filter{Sort: SortTotalCost}.cursorOf(queryQuote{ID: "a", TotalCost: cost}).encode()
+
+	cases := []struct {
+		Name string
+
+		Filter Filter
+
+		Want    []string // Parts of the query, in order.
+		NumArgs int
+		Err     error
+	}{
+		{"empty", Filter{}, []string{"WHERE\n\t\taccount_id = $1", "ORDER BY\n\t\tcreated_at ASC, quote_id ASC\n\tLIMIT $2"}, 2, nil},
+		{"every criterion", Filter{
+			AccountID:        "acme",
+			FromCountryCodes: []string{"SE"},
+			ToCountryCodes:   []string{"NO", "DK"},
+			MinWeight:        &weight,
+			MaxWeight:        &weight,
+			MinCost:          &cost,
+			MaxCost:          &cost,
+			Email:            "Sven@Test.com",
+			CreatedFrom:      &at,
+			CreatedUntil:     &at,
+			Sort:             SortWeight,
+			Descending:       true,
+		}, []string{
+			"WHERE",
+			"account_id = $1",
+			"AND from_country_code = ANY($2)",
+			"AND to_country_code = ANY($3)",
+			"AND package_weight >= $4",
+			"AND package_weight <= $5",
+			"AND total_cost >= $6",
+			"AND total_cost <= $7",
+			"AND (lower(from_email) = $8 OR lower(to_email) = $8)",
+			"AND created_at >= $9",
+			"AND created_at < $10",
+			"ORDER BY\n\t\tpackage_weight DESC, quote_id DESC\n\tLIMIT $11",
+		}, 11, nil},
+		{"after cursor", Filter{Sort: SortTotalCost, Cursor: after}, []string{"WHERE\n\t\taccount_id = $1", "AND (total_cost, quote_id) > ($2, $3)", "ORDER BY\n\t\ttotal_cost ASC"}, 4, nil},
+		{"cursor of other order", Filter{Sort: SortTotalCost, Descending: true, Cursor: after}, nil, 0, ErrInvalidCursor},
+		{"malformed cursor", Filter{Cursor: "banana"}, nil, 0, ErrInvalidCursor},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			query, args, err := tc.Filter.where()
+			is.Equal(err, tc.Err)
+			is.Equal(len(args), tc.NumArgs)
+			for _, part := range tc.Want {
+				i := strings.Index(query, part)
+				is.True(i >= 0) // Query has part.
+				query = query[i+len(part):]
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	is := is.New(t)
+
+	at := time.Date(2026, 10, 19, 9, 0, 0, 123000, time.UTC)
+	c := Filter{Descending: true}.cursorOf(queryQuote{ID: "a", CreatedAt: at})
+	decoded, err := decodeCursor(c.encode())
+	is.NoErr(err)
+	is.Equal(decoded, cursor{Sort: SortCreatedAt, Descending: true, At: at, ID: "a"})
+}
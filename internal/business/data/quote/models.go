@@ -1,25 +1,210 @@
 package quote
 
-// Info represents an individual quote.
+import (
+	"strings"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/address"
+	"github.com/johanronkko/quote-service/internal/business/exchange"
+	"github.com/johanronkko/quote-service/internal/business/geocode"
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+)
+
+// Info represents an individual quote. Weight and ChargeableWeight are the
+// totals in kg of all parcels. PickupDate and DeliveryDate are the estimated
+// dates, and are not set on quotes created before delivery was estimated.
+// AccountID is set on quotes created by an authenticated account, and Contract
+// on quotes priced under the contract of the account. PromoCode is the
+// redeemed promo code, if any. Carrier is the carrier the shipment was rated
+// by, if rated by a carrier. To and From are the receiver and the sender as
+// they were when the quote was created, and ToCustomerID and FromCustomerID
+// refer to them in the address book unless they have been deleted from it.
+// ToLocation and FromLocation are the coordinates of their addresses, and are
+// not set if an address could not be located. An issued quote can be accepted
+// or rejected until ValidUntil, after which it is expired. Breakdown lists the
+// items of ShipmentCost followed by the VAT, and adds up to TotalCost.
 type Info struct {
-	ID           string   `json:"id"`
-	To           Customer `json:"to"`
-	From         Customer `json:"from"`
-	Weight       int      `json:"weight"`
-	ShipmentCost float64  `json:"shipment_cost"`
+	ID               string            `json:"id"`
+	Status           string            `json:"status"`
+	CreatedAt        time.Time         `json:"created_at"`
+	ValidUntil       time.Time         `json:"valid_until"`
+	AccountID        string            `json:"account_id,omitempty"`
+	Contract         *Contract         `json:"contract,omitempty"`
+	PromoCode        string            `json:"promo_code,omitempty"`
+	To               Customer          `json:"to"`
+	ToCustomerID     string            `json:"to_customer_id,omitempty"`
+	ToLocation       *geocode.Point    `json:"to_location,omitempty"`
+	From             Customer          `json:"from"`
+	FromCustomerID   string            `json:"from_customer_id,omitempty"`
+	FromLocation     *geocode.Point    `json:"from_location,omitempty"`
+	Weight           int               `json:"weight"`
+	Parcels          []Parcel          `json:"parcels"`
+	DangerousGoods   bool              `json:"dangerous_goods"`
+	ChargeableWeight int               `json:"chargeable_weight"`
+	ServiceLevel     string            `json:"service_level"`
+	TransitDays      int               `json:"transit_days"`
+	PickupDate       *time.Time        `json:"pickup_date,omitempty"`
+	DeliveryDate     *time.Time        `json:"delivery_date,omitempty"`
+	ShipmentCost     money.Money       `json:"shipment_cost"`
+	Tax              pricing.Tax       `json:"tax"`
+	TotalCost        money.Money       `json:"total_cost"`
+	Lane             string            `json:"lane"`
+	Carrier          string            `json:"carrier,omitempty"`
+	Breakdown        pricing.Breakdown `json:"breakdown"`
+	Converted        *Conversion       `json:"converted,omitempty"`
+}
+
+// Statuses of a quote.
+const (
+	StatusDraft    = "draft"
+	StatusIssued   = "issued"
+	StatusAccepted = "accepted"
+	StatusRejected = "rejected"
+	StatusExpired  = "expired"
+	StatusBooked   = "booked"
+)
+
+// transitions are the statuses a quote can move to from each status. Quotes
+// are issued when created, and expire if not accepted or rejected in time.
+var transitions = map[string][]string{
+	StatusDraft:    {StatusIssued},
+	StatusIssued:   {StatusAccepted, StatusRejected, StatusExpired},
+	StatusAccepted: {StatusBooked},
+}
+
+// canTransition reports whether a quote can move from status from to status
+// to.
+func canTransition(from, to string) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is a status transition of a quote.
+type Event struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+// Offer is the price, estimated transit time in business days and estimated
+// pickup and delivery dates of a shipment at a service level. Carrier is the
+// carrier the shipment was rated by, if rated by a carrier. Breakdown lists the
+// items of ShipmentCost followed by the VAT, and adds up to TotalCost.
+type Offer struct {
+	ServiceLevel     string            `json:"service_level"`
+	TransitDays      int               `json:"transit_days"`
+	PickupDate       time.Time         `json:"pickup_date"`
+	DeliveryDate     time.Time         `json:"delivery_date"`
+	ChargeableWeight int               `json:"chargeable_weight"`
+	ShipmentCost     money.Money       `json:"shipment_cost"`
+	Tax              pricing.Tax       `json:"tax"`
+	TotalCost        money.Money       `json:"total_cost"`
+	Lane             string            `json:"lane"`
+	Carrier          string            `json:"carrier,omitempty"`
+	Breakdown        pricing.Breakdown `json:"breakdown"`
+	Converted        *Conversion       `json:"converted,omitempty"`
+}
+
+// Contract identifies the version of the contract a quote was priced under.
+type Contract struct {
+	ID      string `json:"id"`
+	Version int    `json:"version"`
 }
 
-// NewQuote contains information needed to create a new Quote.
+// Conversion is the total cost converted to the currency requested by the
+// customer, together with the exchange rate that was used.
+type Conversion struct {
+	Cost money.Money   `json:"cost"`
+	Rate exchange.Rate `json:"rate"`
+}
+
+// NewQuote contains information needed to create a new Quote. A shipment of a
+// single package is described by Weight and optionally Dimensions, a shipment
+// of several by Parcels, in which case Weight and Dimensions must be omitted.
+// DangerousGoods is set for shipments of dangerous goods, which may be
+// surcharged. ServiceLevel defaults to standard. If Currency is set, the total
+// cost is additionally quoted in that currency. PromoCode is redeemed on the
+// quote for a discount. AccountID is the authenticated account creating the
+// quote, if any, and is not part of the request body. The receiver and the
+// sender are given either in full by To and From, or by the IDs of saved
+// customers by ToCustomerID and FromCustomerID.
 type NewQuote struct {
-	To     Customer `json:"to" validate:"required,dive"`
-	From   Customer `json:"from" validate:"required,dive"`
-	Weight int      `json:"weight" validate:"required,gte=0,lte=1000"`
+	AccountID      string      `json:"-"`
+	To             *Customer   `json:"to,omitempty" validate:"required_without=ToCustomerID"`
+	ToCustomerID   string      `json:"to_customer_id,omitempty" validate:"excluded_with=To,omitempty,uuid"`
+	From           *Customer   `json:"from,omitempty" validate:"required_without=FromCustomerID"`
+	FromCustomerID string      `json:"from_customer_id,omitempty" validate:"excluded_with=From,omitempty,uuid"`
+	Weight         int         `json:"weight,omitempty" validate:"required_without=Parcels,excluded_with=Parcels,omitempty,gte=0,lte=1000"`
+	Dimensions     *Dimensions `json:"dimensions,omitempty"`
+	Parcels        []Parcel    `json:"parcels,omitempty" validate:"required_without=Weight,excluded_with=Dimensions,omitempty,min=1,max=50,dive"`
+	DangerousGoods bool        `json:"dangerous_goods,omitempty"`
+	ServiceLevel   string      `json:"service_level,omitempty" validate:"omitempty,oneof=economy standard express"`
+	Currency       string      `json:"currency,omitempty" validate:"omitempty,currency"`
+	PromoCode      string      `json:"promo_code,omitempty" validate:"omitempty,alphanum,max=32"`
+}
+
+// Normalize returns nq with the addresses of To and From normalized, without
+// changing the customers nq points to.
+func (nq NewQuote) Normalize() NewQuote {
+	if nq.To != nil {
+		to := *nq.To
+		to.Address = to.Address.Normalize()
+		nq.To = &to
+	}
+	if nq.From != nil {
+		from := *nq.From
+		from.Address = from.Address.Normalize()
+		nq.From = &from
+	}
+	return nq
+}
+
+// customer identifies the customer of nq for per-customer promotion limits:
+// the account if authenticated, otherwise the email of the sender.
+func (nq NewQuote) customer() string {
+	if nq.AccountID != "" {
+		return nq.AccountID
+	}
+	return strings.ToLower(nq.From.Email)
+}
+
+// parcels returns the parcels of the shipment.
+func (nq NewQuote) parcels() []Parcel {
+	if len(nq.Parcels) > 0 {
+		return nq.Parcels
+	}
+	return []Parcel{{Weight: nq.Weight, Dimensions: nq.Dimensions, Quantity: 1}}
+}
+
+// Parcel is one or more identical packages. If Dimensions are set, a package
+// is priced by the greater of its actual and volumetric weight. The weight a
+// single package was priced by is returned as ChargeableWeight.
+type Parcel struct {
+	Weight           int         `json:"weight" validate:"required,gte=0,lte=1000"`
+	Dimensions       *Dimensions `json:"dimensions,omitempty"`
+	Quantity         int         `json:"quantity" validate:"required,gte=1,lte=100"`
+	ChargeableWeight int         `json:"chargeable_weight,omitempty"`
+}
+
+// Dimensions are the outer dimensions of a package in cm.
+type Dimensions struct {
+	Length int `json:"length" validate:"required,gt=0,lte=300"`
+	Width  int `json:"width" validate:"required,gt=0,lte=300"`
+	Height int `json:"height" validate:"required,gt=0,lte=300"`
 }
 
 // Customer contains information about a customer associated with a quote.
+// VATNumber is set for businesses, and makes shipments between EU member
+// states reverse charged when set on the sender. The postal code of the
+// receiver decides whether remote area surcharges apply.
 type Customer struct {
-	Name        string `json:"name" validate:"required,personname"`
-	Email       string `json:"email" validate:"required,email"`
-	Address     string `json:"address" validate:"required,max=100"`
-	CountryCode string `json:"country_code" validate:"required,iso3166_1_alpha2"`
+	Name      string          `json:"name" validate:"required,personname"`
+	Email     string          `json:"email" validate:"required,email"`
+	Address   address.Address `json:"address"`
+	VATNumber string          `json:"vat_number,omitempty" validate:"omitempty,vatnumber"`
 }
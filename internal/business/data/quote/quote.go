@@ -4,86 +4,693 @@ package quote
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/johanronkko/quote-service/internal/business/address"
+	"github.com/johanronkko/quote-service/internal/business/data/contract"
+	"github.com/johanronkko/quote-service/internal/business/data/customer"
+	"github.com/johanronkko/quote-service/internal/business/data/promotion"
+	"github.com/johanronkko/quote-service/internal/business/delivery"
+	"github.com/johanronkko/quote-service/internal/business/exchange"
+	"github.com/johanronkko/quote-service/internal/business/geocode"
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
 	"github.com/johanronkko/quote-service/internal/business/region"
 	"github.com/johanronkko/quote-service/internal/business/validate"
+	"github.com/lib/pq"
 )
 
 var (
 	// ErrNotFound is used when a specific Quote is requested but does not exist.
 	ErrNotFound = errors.New("not found")
+
+	// ErrExpired occurs when acting on a quote after it is no longer valid.
+	ErrExpired = errors.New("quote has expired")
+
+	// ErrInvalidTransition occurs when moving a quote to a status it cannot
+	// move to from its current status, e.g. accepting a rejected quote.
+	ErrInvalidTransition = errors.New("invalid status transition")
+
+	// ErrAnonymousCustomer occurs when a new quote without an account
+	// references a customer by ID, as only accounts have an address book.
+	ErrAnonymousCustomer = errors.New("customers are referenced by ID from the address book of an account")
 )
 
+// Countries looks up countries in the country catalogue.
+type Countries interface {
+	// Country returns the country with country code ccode. Returns
+	// region.ErrUnsupportedCountryCode if the country is not supported.
+	Country(ccode string) (region.Country, error)
+}
+
+// Contracts looks up the negotiated rates of customer accounts.
+type Contracts interface {
+	// ContractOf returns the current contract of the account with accountID.
+	// Returns contract.ErrNotFound if the account has no contract.
+	ContractOf(ctx context.Context, accountID string) (pricing.Contract, error)
+}
+
 // Quote manages the set of API's for quote access.
 type Quote struct {
-	db *sqlx.DB
+	log       *log.Logger
+	db        *sqlx.DB
+	countries Countries
+	calc      pricing.ShipmentCostCalculator
+	rates     exchange.Provider
+	estimator delivery.Estimator
+	contracts Contracts
+	geocoder  geocode.Geocoder
+	clock     delivery.Clock
+	validity  time.Duration
 }
 
-// New constructs a Quote for api access.
-func New(db *sqlx.DB) Quote {
-	return Quote{db}
+// New constructs a Quote for api access. Shipment costs are calculated by calc
+// based on the countries of the sender and the receiver and the contract of
+// the account, and converted to the requested currency using rates. Pickup and
+// delivery dates are estimated by estimator, and the sender and the receiver
+// are located by geocoder. Quotes are valid for validity after they are
+// created, as told by clock. Failures that do not fail a quote, such as a
+// geocoder being unavailable, are logged to log.
+func New(log *log.Logger, db *sqlx.DB, countries Countries, calc pricing.ShipmentCostCalculator, rates exchange.Provider, estimator delivery.Estimator, contracts Contracts, geocoder geocode.Geocoder, clock delivery.Clock, validity time.Duration) Quote {
+	return Quote{log, db, countries, calc, rates, estimator, contracts, geocoder, clock, validity}
 }
 
-// Create adds a quote to the database.
+// Create adds a quote to the database. The quote is priced at the service
+// level of nq, under the current contract of the account of nq if any. If nq
+// has a promo code, the promotion is redeemed in the same transaction as the
+// quote is added in, so the quote is not added if the promotion cannot be
+// redeemed. Customers given in full are added to the address book unless a
+// customer with the same email and address exists. The addresses of the
+// customers are normalized and geocoded, and a quote is still added if an
+// address cannot be located, without its coordinates. Returns
+// ErrAnonymousCustomer if nq references a customer but has no account, and
+// customer.ErrNotFound if a referenced customer does not exist.
 func (q Quote) Create(ctx context.Context, nq NewQuote) (Info, error) {
 
-	cost, err := calcShipmentCost(nq.Weight, nq.From.CountryCode)
+	nq, err := q.resolve(ctx, nq)
+	if err != nil {
+		return Info{}, err
+	}
+	shipment, err := q.shipment(ctx, nq)
+	if err != nil {
+		return Info{}, err
+	}
+	shipment.ServiceLevel = nq.ServiceLevel
+	toLocation := q.locate(ctx, nq.To.Address)
+	fromLocation := q.locate(ctx, nq.From.Address)
+	now := q.clock.Now().UTC().Truncate(time.Microsecond)
+
+	tx, err := q.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Info{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	toID, err := ensureCustomer(ctx, tx, nq.AccountID, nq.ToCustomerID, *nq.To, now)
 	if err != nil {
-		return Info{}, fmt.Errorf("calculating shipment cost: %w", err)
+		return Info{}, err
+	}
+	fromID, err := ensureCustomer(ctx, tx, nq.AccountID, nq.FromCustomerID, *nq.From, now)
+	if err != nil {
+		return Info{}, err
+	}
+
+	id := validate.GenerateID()
+	var promo *pricing.Promotion
+	if nq.PromoCode != "" {
+		p, err := promotion.Redeem(ctx, tx, nq.PromoCode, nq.customer(), id, now)
+		if err != nil {
+			return Info{}, fmt.Errorf("redeeming promo code: %w", err)
+		}
+		pp := p.Promotion()
+		promo = &pp
+	}
+
+	offer, cost, err := q.offer(ctx, shipment, nq.Currency, promo)
+	if err != nil {
+		return Info{}, err
+	}
+
+	parcels := nq.parcels()
+	for i := range parcels {
+		parcels[i].ChargeableWeight = cost.ChargeableWeights[i]
 	}
 
 	info := Info{
-		ID:           validate.GenerateID(),
-		To:           nq.To,
-		From:         nq.From,
-		Weight:       nq.Weight,
-		ShipmentCost: cost,
+		ID:               id,
+		Status:           StatusIssued,
+		CreatedAt:        now,
+		ValidUntil:       now.Add(q.validity),
+		AccountID:        nq.AccountID,
+		To:               *nq.To,
+		ToCustomerID:     toID,
+		ToLocation:       toLocation,
+		From:             *nq.From,
+		FromCustomerID:   fromID,
+		FromLocation:     fromLocation,
+		Weight:           shipment.Weight(),
+		Parcels:          parcels,
+		DangerousGoods:   nq.DangerousGoods,
+		ChargeableWeight: offer.ChargeableWeight,
+		ServiceLevel:     offer.ServiceLevel,
+		TransitDays:      offer.TransitDays,
+		PickupDate:       &offer.PickupDate,
+		DeliveryDate:     &offer.DeliveryDate,
+		ShipmentCost:     offer.ShipmentCost,
+		Tax:              offer.Tax,
+		TotalCost:        offer.TotalCost,
+		Lane:             offer.Lane,
+		Carrier:          offer.Carrier,
+		Breakdown:        offer.Breakdown,
+		Converted:        offer.Converted,
+	}
+	if c := shipment.Contract; c != nil {
+		info.Contract = &Contract{ID: c.ID, Version: c.Version}
+	}
+	if promo != nil {
+		info.PromoCode = promo.Code
+	}
+
+	qq, err := toQueryQuote(info)
+	if err != nil {
+		return Info{}, err
 	}
 
 	const query = `
 	INSERT INTO quotes
-		(quote_id, package_weight, shipment_cost, to_name, to_email, to_address, to_country_code, from_name, from_email, from_address, from_country_code)
+		(quote_id, status, created_at, valid_until, account_id, contract_id, contract_version, promo_code, package_weight, dangerous_goods, chargeable_weight, service_level, transit_days, pickup_date, delivery_date, shipment_cost, shipment_currency, tax_rate, tax_amount, reverse_charge, tax_country, total_cost, lane, carrier, breakdown,
+		converted_cost, converted_currency, exchange_rate, exchange_rate_at,
+		to_customer_id, to_name, to_email, to_address, to_postal_code, to_city, to_region, to_country_code, to_vat_number, to_lat, to_lon, from_customer_id, from_name, from_email, from_address, from_postal_code, from_city, from_region, from_country_code, from_vat_number, from_lat, from_lon)
 	VALUES
-		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+		(:quote_id, :status, :created_at, :valid_until, :account_id, :contract_id, :contract_version, :promo_code, :package_weight, :dangerous_goods, :chargeable_weight, :service_level, :transit_days, :pickup_date, :delivery_date, :shipment_cost, :shipment_currency, :tax_rate, :tax_amount, :reverse_charge, :tax_country, :total_cost, :lane, :carrier, :breakdown,
+		:converted_cost, :converted_currency, :exchange_rate, :exchange_rate_at,
+		:to_customer_id, :to_name, :to_email, :to_address, :to_postal_code, :to_city, :to_region, :to_country_code, :to_vat_number, :to_lat, :to_lon, :from_customer_id, :from_name, :from_email, :from_address, :from_postal_code, :from_city, :from_region, :from_country_code, :from_vat_number, :from_lat, :from_lon)`
 
-	if _, err := q.db.ExecContext(ctx, query, info.ID, info.Weight, info.ShipmentCost, info.To.Name, info.To.Email, info.To.Address, info.To.CountryCode, info.From.Name, info.From.Email, info.From.Address, info.From.CountryCode); err != nil {
+	const parcelQuery = `
+	INSERT INTO quote_parcels
+		(quote_id, parcel_no, package_weight, length_cm, width_cm, height_cm, quantity, chargeable_weight)
+	VALUES
+		(:quote_id, :parcel_no, :package_weight, :length_cm, :width_cm, :height_cm, :quantity, :chargeable_weight)`
+
+	if _, err := tx.NamedExecContext(ctx, query, qq); err != nil {
 		return Info{}, fmt.Errorf("inserting quote: %w", err)
 	}
+	for i, p := range info.Parcels {
+		if _, err := tx.NamedExecContext(ctx, parcelQuery, toQueryParcel(info.ID, i, p)); err != nil {
+			return Info{}, fmt.Errorf("inserting parcel %d: %w", i, err)
+		}
+	}
+	if err := addEvent(ctx, tx, info.ID, 0, Event{From: StatusDraft, To: StatusIssued, At: now}); err != nil {
+		return Info{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Info{}, fmt.Errorf("committing quote: %w", err)
+	}
 
 	return info, nil
 }
 
-// Query retrieves a list of existing quotes from the database.
-func (q Quote) Query(ctx context.Context) ([]Info, error) {
+// Accept accepts the issued quote with quoteID. Returns ErrExpired if the
+// quote has expired, and ErrInvalidTransition if it is not issued.
+func (q Quote) Accept(ctx context.Context, quoteID string) (Info, error) {
+	return q.transition(ctx, quoteID, StatusAccepted)
+}
+
+// Reject rejects the issued quote with quoteID. Returns ErrExpired if the
+// quote has expired, and ErrInvalidTransition if it is not issued.
+func (q Quote) Reject(ctx context.Context, quoteID string) (Info, error) {
+	return q.transition(ctx, quoteID, StatusRejected)
+}
+
+// transition moves the quote with quoteID to status and records the
+// transition. Quotes are expired lazily: an issued quote past its validity is
+// moved to expired when first acted on, and ErrExpired is returned.
+func (q Quote) transition(ctx context.Context, quoteID, status string) (Info, error) {
+	tx, err := q.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Info{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = move(ctx, tx, quoteID, status, q.clock.Now())
+	if err != nil && err != ErrExpired {
+		return Info{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Info{}, fmt.Errorf("committing transition: %w", err)
+	}
+	if err != nil {
+		return Info{}, err // Expired, which is recorded.
+	}
+
+	return q.QueryByID(ctx, quoteID)
+}
+
+// Book moves the accepted quote with quoteID to booked within tx, at now.
+// Returns ErrInvalidTransition if the quote is not accepted, e.g. if it is
+// already booked.
+func Book(ctx context.Context, tx *sqlx.Tx, quoteID string, now time.Time) error {
+	return move(ctx, tx, quoteID, StatusBooked, now)
+}
+
+// Lock locks the quote with quoteID until tx ends, and checks that it can be
+// moved to status at now without moving it. Returns the errors of a move to
+// status, e.g. ErrExpired, in which case tx should be committed to record the
+// expiry.
+func Lock(ctx context.Context, tx *sqlx.Tx, quoteID, status string, now time.Time) error {
+	_, _, err := lock(ctx, tx, quoteID, status, now)
+	return err
+}
 
+// move moves the quote with quoteID to status within tx, at now. The quote is
+// locked until tx ends, so concurrent transitions are applied one at a time.
+// If the quote is issued but past its validity, it is moved to expired
+// instead and ErrExpired is returned; commit tx to record the expiry.
+func move(ctx context.Context, tx *sqlx.Tx, quoteID, status string, now time.Time) error {
+	e, events, err := lock(ctx, tx, quoteID, status, now)
+	if err != nil {
+		return err
+	}
+	return setStatus(ctx, tx, quoteID, events, e)
+}
+
+// lock locks the quote with quoteID within tx, and returns the event of
+// moving it to status at now and the number of events of the quote. Records
+// the expiry of the quote and returns ErrExpired if it is past its validity.
+func lock(ctx context.Context, tx *sqlx.Tx, quoteID, status string, now time.Time) (Event, int, error) {
 	const query = `
 	SELECT
-		*
+		status, valid_until
+	FROM
+		quotes
+	WHERE
+		quote_id = $1
+	FOR UPDATE`
+
+	var current struct {
+		Status     string    `db:"status"`
+		ValidUntil time.Time `db:"valid_until"`
+	}
+	if err := tx.GetContext(ctx, &current, query, quoteID); err != nil {
+		if err == sql.ErrNoRows {
+			return Event{}, 0, ErrNotFound
+		}
+		return Event{}, 0, fmt.Errorf("selecting quote %q: %w", quoteID, err)
+	}
+
+	const eventsQuery = `
+	SELECT
+		COUNT(*)
+	FROM
+		quote_events
+	WHERE
+		quote_id = $1`
+
+	var events int
+	if err := tx.GetContext(ctx, &events, eventsQuery, quoteID); err != nil {
+		return Event{}, 0, fmt.Errorf("counting events of quote %q: %w", quoteID, err)
+	}
+
+	now = now.UTC().Truncate(time.Microsecond)
+	if current.Status == StatusIssued && !now.Before(current.ValidUntil) {
+		if err := setStatus(ctx, tx, quoteID, events, Event{From: current.Status, To: StatusExpired, At: now}); err != nil {
+			return Event{}, 0, err
+		}
+		return Event{}, 0, ErrExpired
+	}
+	if current.Status == StatusExpired {
+		return Event{}, 0, ErrExpired
+	}
+	if !canTransition(current.Status, status) {
+		return Event{}, 0, fmt.Errorf("%w from %s to %s", ErrInvalidTransition, current.Status, status)
+	}
+
+	return Event{From: current.Status, To: status, At: now}, events, nil
+}
+
+// setStatus moves the quote with quoteID to the status e transitions to, and
+// records e as event number eventNo of the quote.
+func setStatus(ctx context.Context, tx *sqlx.Tx, quoteID string, eventNo int, e Event) error {
+	const query = `
+	UPDATE
+		quotes
+	SET
+		status = $2
+	WHERE
+		quote_id = $1`
+
+	if _, err := tx.ExecContext(ctx, query, quoteID, e.To); err != nil {
+		return fmt.Errorf("updating status of quote %q: %w", quoteID, err)
+	}
+	return addEvent(ctx, tx, quoteID, eventNo, e)
+}
+
+// addEvent records e as event number eventNo of the quote with quoteID.
+func addEvent(ctx context.Context, tx *sqlx.Tx, quoteID string, eventNo int, e Event) error {
+	const query = `
+	INSERT INTO quote_events
+		(quote_id, event_no, from_status, to_status, created_at)
+	VALUES
+		($1, $2, $3, $4, $5)`
+
+	if _, err := tx.ExecContext(ctx, query, quoteID, eventNo, e.From, e.To, e.At); err != nil {
+		return fmt.Errorf("inserting event: %w", err)
+	}
+	return nil
+}
+
+// QueryEvents gets the status transitions of the quote with quoteID, oldest
+// first.
+func (q Quote) QueryEvents(ctx context.Context, quoteID string) ([]Event, error) {
+
+	const query = `
+	SELECT
+		from_status, to_status, created_at
 	FROM
-		quotes`
+		quote_events
+	WHERE
+		quote_id = $1
+	ORDER BY
+		event_no`
+
+	queryEvents := []struct {
+		From string    `db:"from_status"`
+		To   string    `db:"to_status"`
+		At   time.Time `db:"created_at"`
+	}{}
+	if err := q.db.SelectContext(ctx, &queryEvents, query, quoteID); err != nil {
+		return nil, fmt.Errorf("selecting events of quote %q: %w", quoteID, err)
+	}
+
+	events := []Event{}
+	for _, qe := range queryEvents {
+		events = append(events, Event{From: qe.From, To: qe.To, At: qe.At.UTC()})
+	}
+
+	return events, nil
+}
+
+// Offers prices the shipment of nq at every offered service level, from
+// slowest to fastest. The offers are not stored, and nq.ServiceLevel is
+// ignored. If nq has a promo code, the promotion is applied without being
+// redeemed.
+func (q Quote) Offers(ctx context.Context, nq NewQuote) ([]Offer, error) {
+
+	nq, err := q.resolve(ctx, nq)
+	if err != nil {
+		return nil, err
+	}
+	shipment, err := q.shipment(ctx, nq)
+	if err != nil {
+		return nil, err
+	}
+
+	var promo *pricing.Promotion
+	if nq.PromoCode != "" {
+		p, err := promotion.Check(ctx, q.db, nq.PromoCode, nq.customer(), q.clock.Now())
+		if err != nil {
+			return nil, fmt.Errorf("checking promo code: %w", err)
+		}
+		pp := p.Promotion()
+		promo = &pp
+	}
+
+	offers := []Offer{}
+	for _, level := range q.calc.ServiceLevels() {
+		shipment.ServiceLevel = level.Name
+		offer, _, err := q.offer(ctx, shipment, nq.Currency, promo)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, offer)
+	}
+
+	return offers, nil
+}
+
+// resolve returns nq with the customers referenced by ID looked up in the
+// address book of the account of nq, and with normalized addresses. Returns
+// ErrAnonymousCustomer if nq references a customer but has no account, and
+// customer.ErrNotFound if a customer is not in the address book.
+func (q Quote) resolve(ctx context.Context, nq NewQuote) (NewQuote, error) {
+	if nq.AccountID == "" && (nq.ToCustomerID != "" || nq.FromCustomerID != "") {
+		return NewQuote{}, ErrAnonymousCustomer
+	}
+	customers := customer.New(q.db, q.clock)
+	if nq.ToCustomerID != "" {
+		c, err := customers.QueryByID(ctx, nq.AccountID, nq.ToCustomerID)
+		if err != nil {
+			return NewQuote{}, fmt.Errorf("looking up receiver: %w", err)
+		}
+		nq.To = toCustomer(c)
+	}
+	if nq.FromCustomerID != "" {
+		c, err := customers.QueryByID(ctx, nq.AccountID, nq.FromCustomerID)
+		if err != nil {
+			return NewQuote{}, fmt.Errorf("looking up sender: %w", err)
+		}
+		nq.From = toCustomer(c)
+	}
+	if nq.To == nil || nq.From == nil {
+		return NewQuote{}, errors.New("validating data: receiver and sender are required")
+	}
+	return nq.Normalize(), nil
+}
+
+// locate returns the coordinates of a, or nil if a cannot be located. Quotes
+// are priced without coordinates, so failing to locate a, for whatever reason,
+// is not an error, but failures other than a not being found are logged.
+func (q Quote) locate(ctx context.Context, a address.Address) *geocode.Point {
+	p, err := q.geocoder.Geocode(ctx, a)
+	if errors.Is(err, geocode.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		q.log.Printf("quote: Locating address in %s: %s", a.CountryCode, err)
+		return nil
+	}
+	return &p
+}
+
+// toCustomer returns the quote customer of the address book customer c.
+func toCustomer(c customer.Info) *Customer {
+	return &Customer{
+		Name:      c.Name,
+		Email:     c.Email,
+		Address:   c.Address,
+		VATNumber: c.VATNumber,
+	}
+}
+
+// ensureCustomer returns customerID if set, and otherwise the ID of c in the
+// address book of the account with accountID within tx, adding c at now if it
+// is not in the address book.
+func ensureCustomer(ctx context.Context, tx *sqlx.Tx, accountID, customerID string, c Customer, now time.Time) (string, error) {
+	if customerID != "" {
+		return customerID, nil
+	}
+	id, err := customer.Ensure(ctx, tx, customer.NewCustomer{
+		AccountID: accountID,
+		Name:      c.Name,
+		Email:     c.Email,
+		Address:   c.Address,
+		VATNumber: c.VATNumber,
+	}, now)
+	if err != nil {
+		return "", fmt.Errorf("saving customer: %w", err)
+	}
+	return id, nil
+}
+
+// shipment translates nq into the shipment to price, including the contract
+// of the account of nq if any.
+func (q Quote) shipment(ctx context.Context, nq NewQuote) (pricing.Shipment, error) {
+	from, err := q.countries.Country(nq.From.Address.CountryCode)
+	if err != nil {
+		return pricing.Shipment{}, fmt.Errorf("region translation: %w", err)
+	}
+	to, err := q.countries.Country(nq.To.Address.CountryCode)
+	if err != nil {
+		return pricing.Shipment{}, fmt.Errorf("region translation: %w", err)
+	}
+
+	shipment := pricing.Shipment{
+		Parcels:        toPricingParcels(nq.parcels()),
+		From:           from,
+		To:             to,
+		VATNumber:      nq.From.VATNumber,
+		ToPostalCode:   nq.To.Address.PostalCode,
+		DangerousGoods: nq.DangerousGoods,
+	}
+
+	if nq.AccountID != "" {
+		c, err := q.contracts.ContractOf(ctx, nq.AccountID)
+		switch {
+		case err == nil:
+			shipment.Contract = &c
+		case !errors.Is(err, contract.ErrNotFound):
+			return pricing.Shipment{}, fmt.Errorf("looking up contract: %w", err)
+		}
+	}
+	return shipment, nil
+}
+
+// Shipment returns the shipment of quote qi at the service level it was
+// quoted at, e.g. to book it with the carrier that rated it.
+func (q Quote) Shipment(qi Info) (pricing.Shipment, error) {
+	from, err := q.countries.Country(qi.From.Address.CountryCode)
+	if err != nil {
+		return pricing.Shipment{}, fmt.Errorf("region translation: %w", err)
+	}
+	to, err := q.countries.Country(qi.To.Address.CountryCode)
+	if err != nil {
+		return pricing.Shipment{}, fmt.Errorf("region translation: %w", err)
+	}
+
+	return pricing.Shipment{
+		Parcels:        toPricingParcels(qi.Parcels),
+		From:           from,
+		To:             to,
+		VATNumber:      qi.From.VATNumber,
+		ServiceLevel:   qi.ServiceLevel,
+		ToPostalCode:   qi.To.Address.PostalCode,
+		DangerousGoods: qi.DangerousGoods,
+	}, nil
+}
+
+// toPricingParcels translates parcels into the parcels of a shipment to price.
+func toPricingParcels(parcels []Parcel) []pricing.Parcel {
+	pp := make([]pricing.Parcel, len(parcels))
+	for i, p := range parcels {
+		pp[i] = pricing.Parcel{Weight: p.Weight, Quantity: p.Quantity}
+		if d := p.Dimensions; d != nil {
+			pp[i].Dimensions = pricing.Dimensions{Length: d.Length, Width: d.Width, Height: d.Height}
+		}
+	}
+	return pp
+}
+
+// offer prices shipment including VAT and estimates its delivery. If promo is
+// set, its discount is taken off the shipment cost before VAT. If currency is
+// set, the total cost is also converted to currency.
+func (q Quote) offer(ctx context.Context, shipment pricing.Shipment, currency string, promo *pricing.Promotion) (Offer, pricing.Cost, error) {
+	cost, err := pricing.ShipmentCostContext(ctx, q.calc, shipment)
+	if err != nil {
+		return Offer{}, pricing.Cost{}, fmt.Errorf("calculating shipment cost: %w", err)
+	}
+	if promo != nil {
+		if cost, err = promo.Apply(cost); err != nil {
+			return Offer{}, pricing.Cost{}, fmt.Errorf("applying promotion: %w", err)
+		}
+	}
+
+	tax, taxItem := pricing.VAT(shipment, cost.Amount)
+	total, err := cost.Amount.Add(tax.Amount)
+	if err != nil {
+		return Offer{}, pricing.Cost{}, fmt.Errorf("adding tax: %w", err)
+	}
+
+	estimate := q.estimator.Estimate(shipment, cost.ServiceLevel)
+
+	offer := Offer{
+		ServiceLevel:     cost.ServiceLevel.Name,
+		TransitDays:      estimate.TransitDays,
+		PickupDate:       estimate.Pickup,
+		DeliveryDate:     estimate.Delivery,
+		ChargeableWeight: cost.ChargeableWeight,
+		ShipmentCost:     cost.Amount,
+		Tax:              tax,
+		TotalCost:        total,
+		Lane:             cost.Lane.String(),
+		Carrier:          cost.Carrier,
+		Breakdown:        append(cost.Breakdown, taxItem),
+	}
+
+	if currency != "" && currency != total.Currency {
+		rate, err := q.rates.Rate(ctx, total.Currency, currency)
+		if err != nil {
+			return Offer{}, pricing.Cost{}, fmt.Errorf("exchange rate: %w", err)
+		}
+		converted, err := rate.Convert(total)
+		if err != nil {
+			return Offer{}, pricing.Cost{}, fmt.Errorf("converting total cost: %w", err)
+		}
+		offer.Converted = &Conversion{Cost: converted, Rate: rate}
+	}
+
+	return offer, cost, nil
+}
+
+// quoteColumns are the columns of quotes scanned into queryQuote, which leaves
+// out the search column.
+const quoteColumns = `
+		quote_id, status, created_at, valid_until, account_id, contract_id, contract_version, promo_code, package_weight, dangerous_goods, chargeable_weight, service_level, transit_days, pickup_date, delivery_date, shipment_cost, shipment_currency, tax_rate, tax_amount, reverse_charge, tax_country, total_cost, lane, carrier, breakdown,
+		converted_cost, converted_currency, exchange_rate, exchange_rate_at,
+		to_customer_id, to_name, to_email, to_address, to_postal_code, to_city, to_region, to_country_code, to_vat_number, to_lat, to_lon,
+		from_customer_id, from_name, from_email, from_address, from_postal_code, from_city, from_region, from_country_code, from_vat_number, from_lat, from_lon`
+
+// Query retrieves the page of existing quotes selected by filter from the
+// database, and the cursor of the next page. The cursor is empty on the last
+// page. Returns ErrInvalidCursor if the cursor of filter is not valid for its
+// sort order.
+func (q Quote) Query(ctx context.Context, filter Filter) ([]Info, string, error) {
+	if err := validate.Check(filter); err != nil {
+		return nil, "", fmt.Errorf("validating data: %w", err)
+	}
+	where, args, err := filter.where()
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := `
+	SELECT` + quoteColumns + `
+	FROM
+		quotes
+	` + where
 
 	queryQuotes := []queryQuote{}
-	if err := q.db.SelectContext(ctx, &queryQuotes, query); err != nil {
-		return nil, fmt.Errorf("selecting quotes: %w", err)
+	if err := q.db.SelectContext(ctx, &queryQuotes, query, args...); err != nil {
+		return nil, "", fmt.Errorf("selecting quotes: %w", err)
+	}
+
+	var next string
+	if len(queryQuotes) > filter.limit() {
+		queryQuotes = queryQuotes[:filter.limit()]
+		next = filter.cursorOf(queryQuotes[len(queryQuotes)-1]).encode()
+	}
+
+	ids := make([]string, len(queryQuotes))
+	for i, qq := range queryQuotes {
+		ids[i] = qq.ID
+	}
+	parcels, err := q.queryParcels(ctx, ids...)
+	if err != nil {
+		return nil, "", err
 	}
 
+	now := q.clock.Now()
 	quotes := []Info{}
 	for _, qq := range queryQuotes {
-		quotes = append(quotes, qq.toInfo())
+		info, err := qq.toInfo(parcels[qq.ID], now)
+		if err != nil {
+			return nil, "", err
+		}
+		quotes = append(quotes, info)
 	}
 
-	return quotes, nil
+	return quotes, next, nil
 }
 
 // QueryByID gets the specified quote from the database.
 func (q Quote) QueryByID(ctx context.Context, quoteID string) (Info, error) {
 
 	const query = `
-	SELECT
-		*
+	SELECT` + quoteColumns + `
 	FROM
 		quotes
 	WHERE 
@@ -97,69 +704,299 @@ func (q Quote) QueryByID(ctx context.Context, quoteID string) (Info, error) {
 		return Info{}, fmt.Errorf("selecting quote %q: %w", quoteID, err)
 	}
 
-	return queryQuote.toInfo(), nil
+	parcels, err := q.queryParcels(ctx, quoteID)
+	if err != nil {
+		return Info{}, err
+	}
+
+	return queryQuote.toInfo(parcels[quoteID], q.clock.Now())
+}
+
+// queryParcels gets the parcels of the quotes with quoteIDs, keyed by quote ID.
+func (q Quote) queryParcels(ctx context.Context, quoteIDs ...string) (map[string][]Parcel, error) {
+
+	const query = `
+	SELECT
+		*
+	FROM
+		quote_parcels
+	WHERE
+		quote_id = ANY($1)
+	ORDER BY
+		quote_id, parcel_no`
+
+	queryParcels := []queryParcel{}
+	if err := q.db.SelectContext(ctx, &queryParcels, query, pq.Array(quoteIDs)); err != nil {
+		return nil, fmt.Errorf("selecting parcels: %w", err)
+	}
+
+	parcels := make(map[string][]Parcel)
+	for _, qp := range queryParcels {
+		parcels[qp.QuoteID] = append(parcels[qp.QuoteID], qp.toParcel())
+	}
+
+	return parcels, nil
 }
 
 type queryQuote struct {
-	ID              string  `db:"quote_id"`
-	Weight          int     `db:"package_weight"`
-	ShipmentCost    float64 `db:"shipment_cost"`
-	ToName          string  `db:"to_name"`
-	ToEmail         string  `db:"to_email"`
-	ToAddress       string  `db:"to_address"`
-	ToCountryCode   string  `db:"to_country_code"`
-	FromName        string  `db:"from_name"`
-	FromEmail       string  `db:"from_email"`
-	FromAddress     string  `db:"from_address"`
-	FromCountryCode string  `db:"from_country_code"`
-}
-
-func (qq queryQuote) toInfo() Info {
-	return Info{
-		ID:           qq.ID,
-		Weight:       qq.Weight,
-		ShipmentCost: qq.ShipmentCost,
+	ID                string     `db:"quote_id"`
+	Status            string     `db:"status"`
+	CreatedAt         time.Time  `db:"created_at"`
+	ValidUntil        time.Time  `db:"valid_until"`
+	AccountID         *string    `db:"account_id"`
+	ContractID        *string    `db:"contract_id"`
+	ContractVersion   *int       `db:"contract_version"`
+	PromoCode         string     `db:"promo_code"`
+	Weight            int        `db:"package_weight"`
+	DangerousGoods    bool       `db:"dangerous_goods"`
+	ChargeableWeight  int        `db:"chargeable_weight"`
+	ServiceLevel      string     `db:"service_level"`
+	TransitDays       int        `db:"transit_days"`
+	PickupDate        *time.Time `db:"pickup_date"`
+	DeliveryDate      *time.Time `db:"delivery_date"`
+	ShipmentCost      int64      `db:"shipment_cost"`
+	ShipmentCurrency  string     `db:"shipment_currency"`
+	TaxRate           float64    `db:"tax_rate"`
+	TaxAmount         int64      `db:"tax_amount"`
+	ReverseCharge     bool       `db:"reverse_charge"`
+	TaxCountry        string     `db:"tax_country"`
+	TotalCost         int64      `db:"total_cost"`
+	Lane              string     `db:"lane"`
+	Carrier           string     `db:"carrier"`
+	Breakdown         []byte     `db:"breakdown"`
+	ConvertedCost     *int64     `db:"converted_cost"`
+	ConvertedCurrency *string    `db:"converted_currency"`
+	ExchangeRate      *float64   `db:"exchange_rate"`
+	ExchangeRateAt    *time.Time `db:"exchange_rate_at"`
+	ToCustomerID      *string    `db:"to_customer_id"`
+	ToName            string     `db:"to_name"`
+	ToEmail           string     `db:"to_email"`
+	ToAddress         string     `db:"to_address"`
+	ToPostalCode      string     `db:"to_postal_code"`
+	ToCity            string     `db:"to_city"`
+	ToRegion          string     `db:"to_region"`
+	ToCountryCode     string     `db:"to_country_code"`
+	ToVATNumber       string     `db:"to_vat_number"`
+	ToLat             *float64   `db:"to_lat"`
+	ToLon             *float64   `db:"to_lon"`
+	FromCustomerID    *string    `db:"from_customer_id"`
+	FromName          string     `db:"from_name"`
+	FromEmail         string     `db:"from_email"`
+	FromAddress       string     `db:"from_address"`
+	FromPostalCode    string     `db:"from_postal_code"`
+	FromCity          string     `db:"from_city"`
+	FromRegion        string     `db:"from_region"`
+	FromCountryCode   string     `db:"from_country_code"`
+	FromVATNumber     string     `db:"from_vat_number"`
+	FromLat           *float64   `db:"from_lat"`
+	FromLon           *float64   `db:"from_lon"`
+}
+
+func toQueryQuote(info Info) (queryQuote, error) {
+	breakdown, err := json.Marshal(info.Breakdown)
+	if err != nil {
+		return queryQuote{}, fmt.Errorf("encoding breakdown: %w", err)
+	}
+	qq := queryQuote{
+		ID:               info.ID,
+		Status:           info.Status,
+		CreatedAt:        info.CreatedAt,
+		ValidUntil:       info.ValidUntil,
+		PromoCode:        info.PromoCode,
+		Weight:           info.Weight,
+		DangerousGoods:   info.DangerousGoods,
+		ChargeableWeight: info.ChargeableWeight,
+		ServiceLevel:     info.ServiceLevel,
+		TransitDays:      info.TransitDays,
+		PickupDate:       info.PickupDate,
+		DeliveryDate:     info.DeliveryDate,
+		ShipmentCost:     info.ShipmentCost.Amount,
+		ShipmentCurrency: info.ShipmentCost.Currency,
+		TaxRate:          info.Tax.Rate,
+		TaxAmount:        info.Tax.Amount.Amount,
+		ReverseCharge:    info.Tax.ReverseCharge,
+		TaxCountry:       info.Tax.Country,
+		TotalCost:        info.TotalCost.Amount,
+		Lane:             info.Lane,
+		Carrier:          info.Carrier,
+		Breakdown:        breakdown,
+		ToName:           info.To.Name,
+		ToEmail:          info.To.Email,
+		ToAddress:        strings.Join(info.To.Address.Lines, "\n"),
+		ToPostalCode:     info.To.Address.PostalCode,
+		ToCity:           info.To.Address.City,
+		ToRegion:         info.To.Address.Region,
+		ToCountryCode:    info.To.Address.CountryCode,
+		ToVATNumber:      info.To.VATNumber,
+		FromName:         info.From.Name,
+		FromEmail:        info.From.Email,
+		FromAddress:      strings.Join(info.From.Address.Lines, "\n"),
+		FromPostalCode:   info.From.Address.PostalCode,
+		FromCity:         info.From.Address.City,
+		FromRegion:       info.From.Address.Region,
+		FromCountryCode:  info.From.Address.CountryCode,
+		FromVATNumber:    info.From.VATNumber,
+	}
+	if info.AccountID != "" {
+		qq.AccountID = &info.AccountID
+	}
+	if info.ToCustomerID != "" {
+		qq.ToCustomerID = &info.ToCustomerID
+	}
+	if info.FromCustomerID != "" {
+		qq.FromCustomerID = &info.FromCustomerID
+	}
+	if p := info.ToLocation; p != nil {
+		qq.ToLat, qq.ToLon = &p.Lat, &p.Lon
+	}
+	if p := info.FromLocation; p != nil {
+		qq.FromLat, qq.FromLon = &p.Lat, &p.Lon
+	}
+	if c := info.Contract; c != nil {
+		qq.ContractID, qq.ContractVersion = &c.ID, &c.Version
+	}
+	if c := info.Converted; c != nil {
+		qq.ConvertedCost, qq.ConvertedCurrency = &c.Cost.Amount, &c.Cost.Currency
+		qq.ExchangeRate, qq.ExchangeRateAt = &c.Rate.Value, &c.Rate.Timestamp
+	}
+	return qq, nil
+}
+
+// toInfo returns the quote with its status at now.
+func (qq queryQuote) toInfo(parcels []Parcel, now time.Time) (Info, error) {
+	var breakdown pricing.Breakdown
+	if err := json.Unmarshal(qq.Breakdown, &breakdown); err != nil {
+		return Info{}, fmt.Errorf("decoding breakdown of quote %q: %w", qq.ID, err)
+	}
+	var converted *Conversion
+	if qq.ConvertedCost != nil {
+		converted = &Conversion{
+			Cost: money.New(*qq.ConvertedCost, *qq.ConvertedCurrency),
+			Rate: exchange.Rate{
+				From:      qq.ShipmentCurrency,
+				To:        *qq.ConvertedCurrency,
+				Value:     *qq.ExchangeRate,
+				Timestamp: qq.ExchangeRateAt.UTC(),
+			},
+		}
+	}
+	info := Info{
+		ID:               qq.ID,
+		Status:           qq.Status,
+		CreatedAt:        qq.CreatedAt.UTC(),
+		ValidUntil:       qq.ValidUntil.UTC(),
+		PromoCode:        qq.PromoCode,
+		Weight:           qq.Weight,
+		Parcels:          parcels,
+		DangerousGoods:   qq.DangerousGoods,
+		ChargeableWeight: qq.ChargeableWeight,
+		ServiceLevel:     qq.ServiceLevel,
+		TransitDays:      qq.TransitDays,
+		PickupDate:       utc(qq.PickupDate),
+		DeliveryDate:     utc(qq.DeliveryDate),
+		ShipmentCost:     money.New(qq.ShipmentCost, qq.ShipmentCurrency),
+		Tax: pricing.Tax{
+			Rate:          qq.TaxRate,
+			Amount:        money.New(qq.TaxAmount, qq.ShipmentCurrency),
+			ReverseCharge: qq.ReverseCharge,
+			Country:       qq.TaxCountry,
+		},
+		TotalCost: money.New(qq.TotalCost, qq.ShipmentCurrency),
+		Lane:      qq.Lane,
+		Carrier:   qq.Carrier,
+		Breakdown: breakdown,
+		Converted: converted,
 		To: Customer{
-			Name:        qq.ToName,
-			Email:       qq.ToEmail,
-			Address:     qq.ToAddress,
-			CountryCode: qq.ToCountryCode,
+			Name:  qq.ToName,
+			Email: qq.ToEmail,
+			Address: address.Address{
+				Lines:       strings.Split(qq.ToAddress, "\n"),
+				PostalCode:  qq.ToPostalCode,
+				City:        qq.ToCity,
+				Region:      qq.ToRegion,
+				CountryCode: qq.ToCountryCode,
+			},
+			VATNumber: qq.ToVATNumber,
 		},
 		From: Customer{
-			Name:        qq.FromName,
-			Email:       qq.FromEmail,
-			Address:     qq.FromAddress,
-			CountryCode: qq.FromCountryCode,
+			Name:  qq.FromName,
+			Email: qq.FromEmail,
+			Address: address.Address{
+				Lines:       strings.Split(qq.FromAddress, "\n"),
+				PostalCode:  qq.FromPostalCode,
+				City:        qq.FromCity,
+				Region:      qq.FromRegion,
+				CountryCode: qq.FromCountryCode,
+			},
+			VATNumber: qq.FromVATNumber,
 		},
 	}
+	if qq.AccountID != nil {
+		info.AccountID = *qq.AccountID
+	}
+	if qq.ToCustomerID != nil {
+		info.ToCustomerID = *qq.ToCustomerID
+	}
+	if qq.FromCustomerID != nil {
+		info.FromCustomerID = *qq.FromCustomerID
+	}
+	if qq.ToLat != nil {
+		info.ToLocation = &geocode.Point{Lat: *qq.ToLat, Lon: *qq.ToLon}
+	}
+	if qq.FromLat != nil {
+		info.FromLocation = &geocode.Point{Lat: *qq.FromLat, Lon: *qq.FromLon}
+	}
+	if qq.ContractID != nil {
+		info.Contract = &Contract{ID: *qq.ContractID, Version: *qq.ContractVersion}
+	}
+	if info.Status == StatusIssued && !now.Before(info.ValidUntil) {
+		info.Status = StatusExpired
+	}
+	return info, nil
 }
 
-// calcShipmentCost calculates shipment cost as the multiplication of a package's
-// weight class factor and a region factor determined by the country code.
-// Errors if country code not supported or if package not within a valid weight
-// class.
-//
-// We have four classes of weight: small (0 - 10kg), 100sek; medium (10 - 25kg),
-// 300sek; large (25 - 50kg), 500sek; huge (50 - 1000kg), 2000sek. If country
-// code is Nordic, weight class is multiplied with 1, within EU with 1.5 and
-// outside EU with 2.5.
-func calcShipmentCost(weight int, ccode string) (float64, error) {
-	if weight < 0 || weight > 1000 {
-		return 0, errors.New("invalid weight")
+type queryParcel struct {
+	QuoteID          string `db:"quote_id"`
+	ParcelNo         int    `db:"parcel_no"`
+	Weight           int    `db:"package_weight"`
+	Length           *int   `db:"length_cm"`
+	Width            *int   `db:"width_cm"`
+	Height           *int   `db:"height_cm"`
+	Quantity         int    `db:"quantity"`
+	ChargeableWeight int    `db:"chargeable_weight"`
+}
+
+func toQueryParcel(quoteID string, parcelNo int, p Parcel) queryParcel {
+	qp := queryParcel{
+		QuoteID:          quoteID,
+		ParcelNo:         parcelNo,
+		Weight:           p.Weight,
+		Quantity:         p.Quantity,
+		ChargeableWeight: p.ChargeableWeight,
 	}
-	r, err := region.From(ccode)
-	if err != nil {
-		return 0, fmt.Errorf("region translatation: %w", err)
+	if d := p.Dimensions; d != nil {
+		qp.Length, qp.Width, qp.Height = &d.Length, &d.Width, &d.Height
 	}
-	regionFactor := float64(r)
-	if weight <= 10 {
-		return 100 * regionFactor, nil
+	return qp
+}
+
+func (qp queryParcel) toParcel() Parcel {
+	p := Parcel{
+		Weight:           qp.Weight,
+		Quantity:         qp.Quantity,
+		ChargeableWeight: qp.ChargeableWeight,
 	}
-	if weight <= 25 {
-		return 300 * regionFactor, nil
+	if qp.Length != nil {
+		p.Dimensions = &Dimensions{Length: *qp.Length, Width: *qp.Width, Height: *qp.Height}
 	}
-	if weight <= 50 {
-		return 500 * regionFactor, nil
+	return p
+}
+
+// utc returns t in UTC, or nil if t is nil.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
 	}
-	return 2000 * regionFactor, nil
+	u := t.UTC()
+	return &u
 }
@@ -1,127 +1,462 @@
 package quote
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/johanronkko/quote-service/internal/business/address"
+	"github.com/johanronkko/quote-service/internal/business/data/account"
+	"github.com/johanronkko/quote-service/internal/business/data/contract"
+	"github.com/johanronkko/quote-service/internal/business/data/country"
+	"github.com/johanronkko/quote-service/internal/business/data/customer"
+	"github.com/johanronkko/quote-service/internal/business/data/promotion"
 	"github.com/johanronkko/quote-service/internal/business/data/schema"
+	"github.com/johanronkko/quote-service/internal/business/delivery"
+	"github.com/johanronkko/quote-service/internal/business/exchange"
+	"github.com/johanronkko/quote-service/internal/business/geocode"
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/region"
 	"github.com/johanronkko/quote-service/internal/business/tests"
+	"github.com/johanronkko/quote-service/internal/business/validate"
 	"github.com/matryer/is"
 )
 
+// clock is a delivery.Clock stopped at a fixed time.
+type clock time.Time
+
+func (c clock) Now() time.Time {
+	return time.Time(c)
+}
+
 func TestQuote(t *testing.T) {
 	is := is.New(t)
 
 	db := tests.NewUnit(t)
-	q := New(db)
 
 	ctx := context.Background()
 
+	regions := region.NewCache(country.New(db))
+	is.NoErr(regions.Refresh(ctx))
+
+	rates := exchange.File{
+		Base:      "SEK",
+		Timestamp: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+		Rates:     map[string]float64{"EUR": 0.1},
+	}
+
+	// Monday morning, every quote is picked up the same day.
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	estimator := delivery.NewEstimator(clock(now), delivery.Transit{CutoffHour: 15})
+
+	contracts := contract.New(db)
+
+	// Only Swedish addresses can be located.
+	farsta := geocode.Point{Lat: 59.24, Lon: 18.09}
+	geocoder := geocode.File{Centroids: map[string]map[string]geocode.Point{"SE": {"123": farsta}}}
+
+	validity := 7 * 24 * time.Hour
+	q := New(log.New(ioutil.Discard, "", 0), db, regions, pricing.FlatRate(), rates, estimator, contracts, geocoder, clock(now), validity)
+
 	// Query empty database.
-	quotes, err := q.Query(ctx)
+	quotes, next, err := q.Query(ctx, Filter{AccountID: validate.GenerateID()})
 	is.NoErr(err)
 	is.Equal(len(quotes), 0)
+	is.Equal(next, "")
 
 	// Create quote.
 	nq := NewQuote{
-		To: Customer{
-			Name:        "Sven Svensson",
-			Email:       "sven.svensson@test.com",
-			Address:     "Testgatan 42B, Göteborg 12345",
-			CountryCode: "SV",
+		To: &Customer{
+			Name:  "Sven Svensson",
+			Email: "sven.svensson@test.com",
+			Address: address.Address{
+				Lines:       []string{"Testgatan 42B"},
+				PostalCode:  "12345",
+				City:        "Göteborg",
+				CountryCode: "SE",
+			},
 		},
-		From: Customer{
-			Name:        "John Doe",
-			Email:       "john.doe@test.com",
-			Address:     "Teststreet 4242, Blaine 55434",
-			CountryCode: "US",
+		From: &Customer{
+			Name:  "John Doe",
+			Email: "john.doe@test.com",
+			Address: address.Address{
+				Lines:       []string{"Teststreet 4242"},
+				PostalCode:  "55434",
+				City:        "Blaine",
+				Region:      "MN",
+				CountryCode: "US",
+			},
 		},
 		Weight: 500,
 	}
 	quote, err := q.Create(ctx, nq)
 	is.NoErr(err)
+	is.Equal(quote.Status, StatusIssued)
+	is.True(quote.ToCustomerID != "") // Added to the address book.
+	is.True(quote.FromCustomerID != "")
+	is.Equal(quote.CreatedAt, now)
+	is.Equal(quote.ValidUntil, now.Add(validity))
+	is.Equal(quote.Lane, "outside_eu:nordic")
+	is.Equal(quote.ChargeableWeight, nq.Weight)
+	is.Equal(quote.ServiceLevel, pricing.ServiceStandard)
+	is.Equal(quote.TransitDays, 3)
+	is.Equal(*quote.PickupDate, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+	is.Equal(*quote.DeliveryDate, time.Date(2026, 10, 22, 0, 0, 0, 0, time.UTC))
+	is.Equal(quote.Parcels, []Parcel{{Weight: nq.Weight, Quantity: 1, ChargeableWeight: nq.Weight}})
+	is.Equal(quote.ShipmentCost, money.New(2.5*2000_00, "SEK")) // From outside EU * huge package.
+	is.Equal(quote.Tax.Amount, money.New(0, "SEK"))             // Exports are zero-rated.
+	is.Equal(quote.TotalCost, quote.ShipmentCost)
+	is.Equal(*quote.ToLocation, farsta)
+	is.True(quote.FromLocation == nil) // Quoted although not located.
+	total, err := quote.Breakdown.Total()
+	is.NoErr(err)
+	is.Equal(total, quote.TotalCost)
 
 	// Query by ID returns correct quote.
 	saved, err := q.QueryByID(ctx, quote.ID)
 	is.NoErr(err)
 	is.Equal(quote, saved)
 
-	// Query database with 1 newly added quote and 3 seeded quotes.
-	err = schema.Seed(db)
+	// Quotes expire after their validity.
+	later := New(log.New(ioutil.Discard, "", 0), db, regions, pricing.FlatRate(), rates, estimator, contracts, geocoder, clock(now.Add(validity)), validity)
+	expired, err := later.QueryByID(ctx, quote.ID)
 	is.NoErr(err)
-	quotes, err = q.Query(ctx)
+	is.Equal(expired.Status, StatusExpired)
+
+	// Create quote in another currency.
+	nq.Currency = "EUR"
+	converted, err := q.Create(ctx, nq)
 	is.NoErr(err)
-	is.Equal(len(quotes), 1+3)
-}
+	is.Equal(converted.ShipmentCost, quote.ShipmentCost)
+	is.Equal(converted.Converted.Cost, money.New(500_00, "EUR"))
+	is.Equal(converted.Converted.Rate.Timestamp, rates.Timestamp)
+	saved, err = q.QueryByID(ctx, converted.ID)
+	is.NoErr(err)
+	is.Equal(converted, saved)
 
-func TestCalcShipmentCost(t *testing.T) {
-	t.Run("valid", func(t *testing.T) {
-		cases := []struct {
-			Name string
-
-			CountryCode string
-			Weight      int
-
-			Want float64
-		}{
-			{"small nordic", "no", 0, 100},
-			{"medium nordic", "sv", 11, 300},
-			{"large nordic", "dk", 26, 500},
-			{"huge nordic", "fi", 51, 2000},
-
-			{"small within EU", "fr", 10, 150},
-			{"medium within EU", "de", 25, 450},
-			{"large within EU", "lt", 50, 750},
-			{"huge within EU", "cz", 1000, 3000},
-
-			{"small outide EU", "us", 7, 250},
-			{"medium outide EU", "ca", 18, 750},
-			{"large outide EU", "br", 29, 1250},
-			{"huge outide EU", "jp", 777, 5000},
-		}
-		for _, tc := range cases {
-			t.Run(tc.Name, func(t *testing.T) {
-				is := is.New(t)
-				got, err := calcShipmentCost(tc.Weight, tc.CountryCode)
-				is.NoErr(err)
-				is.Equal(got, tc.Want)
-			})
-		}
-	})
+	// Unsupported currencies are rejected.
+	nq.Currency = "XXX"
+	_, err = q.Create(ctx, nq)
+	is.True(errors.Is(err, exchange.ErrUnsupportedCurrency))
 
-	t.Run("invalid country code", func(t *testing.T) {
-		cases := []struct {
-			Name string
+	// Domestic shipments are charged VAT of the origin country.
+	domestic := nq
+	domestic.Currency = ""
+	from := *nq.From
+	from.Address.CountryCode = "SE"
+	from.VATNumber = "SE556677889901"
+	domestic.From = &from
+	taxed, err := q.Create(ctx, domestic)
+	is.NoErr(err)
+	is.Equal(taxed.Tax.Rate, 0.25)
+	is.Equal(taxed.Tax.Country, "SE")
+	is.Equal(taxed.TotalCost, money.New(2000_00+500_00, "SEK"))
+	total, err = taxed.Breakdown.Total()
+	is.NoErr(err)
+	is.Equal(total, taxed.TotalCost)
+	saved, err = q.QueryByID(ctx, taxed.ID)
+	is.NoErr(err)
+	is.Equal(taxed, saved)
 
-			CountryCode string
-		}{
-			{"not supported", "nn"},
-			{"bad format", "banana"},
-		}
-		for _, tc := range cases {
-			t.Run(tc.Name, func(t *testing.T) {
-				is := is.New(t)
-				_, err := calcShipmentCost(42, tc.CountryCode)
-				is.True(err != nil)
-			})
-		}
+	// Bulky packages are priced by their volumetric weight.
+	bulky := domestic
+	bulky.Weight = 5
+	bulky.Dimensions = &Dimensions{Length: 50, Width: 40, Height: 30}
+	voluminous, err := q.Create(ctx, bulky)
+	is.NoErr(err)
+	is.Equal(voluminous.ChargeableWeight, 12)
+	is.Equal(voluminous.ShipmentCost, money.New(300_00, "SEK")) // Nordic * medium package.
+	saved, err = q.QueryByID(ctx, voluminous.ID)
+	is.NoErr(err)
+	is.Equal(voluminous, saved)
+
+	// Shipments of several parcels are priced together.
+	pallet := domestic
+	pallet.Weight = 0
+	pallet.Parcels = []Parcel{
+		{Weight: 5, Quantity: 4},
+		{Weight: 5, Dimensions: &Dimensions{Length: 50, Width: 40, Height: 30}, Quantity: 1},
+	}
+	multi, err := q.Create(ctx, pallet)
+	is.NoErr(err)
+	is.Equal(multi.Weight, 25)
+	is.Equal(multi.ChargeableWeight, 32)
+	is.Equal(len(multi.Parcels), 2)
+	is.Equal(multi.Parcels[1].ChargeableWeight, 12)
+	is.Equal(multi.ShipmentCost, money.New(4*100_00+300_00, "SEK"))
+	saved, err = q.QueryByID(ctx, multi.ID)
+	is.NoErr(err)
+	is.Equal(multi, saved)
+
+	// Express shipments are more expensive.
+	fast := domestic
+	fast.ServiceLevel = pricing.ServiceExpress
+	express, err := q.Create(ctx, fast)
+	is.NoErr(err)
+	is.Equal(express.ServiceLevel, pricing.ServiceExpress)
+	is.Equal(express.ShipmentCost, money.New(1.5*2000_00, "SEK"))
+	saved, err = q.QueryByID(ctx, express.ID)
+	is.NoErr(err)
+	is.Equal(express, saved)
+
+	// Offers price the shipment at every service level without storing it.
+	offers, err := q.Offers(ctx, domestic)
+	is.NoErr(err)
+	is.Equal(len(offers), 3)
+	is.Equal(offers[0].ServiceLevel, pricing.ServiceEconomy)
+	is.Equal(offers[0].ShipmentCost, money.New(0.8*2000_00, "SEK"))
+	is.Equal(offers[1].ShipmentCost, taxed.ShipmentCost)
+	is.Equal(offers[1].TotalCost, taxed.TotalCost)
+	is.Equal(offers[2].ShipmentCost, express.ShipmentCost)
+
+	// Surcharges are added for remote areas and dangerous goods.
+	rules := pricing.SurchargeRules{Rules: []pricing.SurchargeRule{
+		{Name: "remote area", When: pricing.SurchargeCondition{Countries: []string{"SE"}, PostalCodes: []string{"98*"}}, Amount: 150},
+		{Name: "dangerous goods", When: pricing.SurchargeCondition{DangerousGoods: true}, Amount: 500},
+	}}
+	sq := New(log.New(ioutil.Discard, "", 0), db, regions, pricing.NewSurcharges(pricing.FlatRate(), rules), rates, estimator, contracts, geocoder, clock(now), validity)
+	remote := domestic
+	to := *domestic.To
+	to.Address.PostalCode = "981 31"
+	remote.To = &to
+	remote.DangerousGoods = true
+	surcharged, err := sq.Create(ctx, remote)
+	is.NoErr(err)
+	is.True(surcharged.DangerousGoods)
+	is.Equal(surcharged.ShipmentCost, money.New(2000_00+150_00+500_00, "SEK"))
+	saved, err = q.QueryByID(ctx, surcharged.ID)
+	is.NoErr(err)
+	is.Equal(surcharged, saved)
+
+	// Accounts without a contract pay the list price.
+	acme, _, err := account.New(db).Create(ctx, account.NewAccount{Name: "Acme AB"})
+	is.NoErr(err)
+	billed := domestic
+	billed.AccountID = acme.ID
+	listed, err := q.Create(ctx, billed)
+	is.NoErr(err)
+	is.Equal(listed.AccountID, acme.ID)
+	is.Equal(listed.Contract, nil)
+	is.Equal(listed.ShipmentCost, taxed.ShipmentCost)
+
+	// Accounts with a contract are priced under its current version.
+	_, err = contracts.Create(ctx, contract.NewContract{ID: "acme", AccountID: acme.ID, Discount: 10})
+	is.NoErr(err)
+	_, err = contracts.Create(ctx, contract.NewContract{ID: "acme", AccountID: acme.ID, Discount: 20})
+	is.NoErr(err)
+	discounted, err := q.Create(ctx, billed)
+	is.NoErr(err)
+	is.Equal(discounted.Contract, &Contract{ID: "acme", Version: 2})
+	is.Equal(discounted.ShipmentCost, money.New(0.8*2000_00, "SEK"))
+	saved, err = q.QueryByID(ctx, discounted.ID)
+	is.NoErr(err)
+	is.Equal(discounted, saved)
+
+	// Issued quotes are accepted or rejected once.
+	accepted, err := q.Accept(ctx, taxed.ID)
+	is.NoErr(err)
+	is.Equal(accepted.Status, StatusAccepted)
+	_, err = q.Reject(ctx, taxed.ID)
+	is.True(errors.Is(err, ErrInvalidTransition))
+	rejected, err := q.Reject(ctx, converted.ID)
+	is.NoErr(err)
+	is.Equal(rejected.Status, StatusRejected)
+	_, err = q.Accept(ctx, converted.ID)
+	is.True(errors.Is(err, ErrInvalidTransition))
+	_, err = q.Accept(ctx, "00000000-0000-0000-0000-000000000000")
+	is.Equal(err, ErrNotFound)
+
+	// Expired quotes cannot be accepted.
+	_, err = later.Accept(ctx, quote.ID)
+	is.Equal(err, ErrExpired)
+	_, err = q.Accept(ctx, quote.ID)
+	is.Equal(err, ErrExpired) // Expired for good.
+
+	// Every transition is recorded.
+	events, err := q.QueryEvents(ctx, quote.ID)
+	is.NoErr(err)
+	is.Equal(events, []Event{
+		{From: StatusDraft, To: StatusIssued, At: now},
+		{From: StatusIssued, To: StatusExpired, At: now.Add(validity)},
 	})
+	events, err = q.QueryEvents(ctx, taxed.ID)
+	is.NoErr(err)
+	is.Equal(events[1], Event{From: StatusIssued, To: StatusAccepted, At: now})
 
-	t.Run("invalid weight", func(t *testing.T) {
-		cases := []struct {
-			Name string
+	// Promo codes are redeemed within their limits.
+	_, err = promotion.New(db).Create(ctx, promotion.NewPromotion{
+		Code:             "SPRING25",
+		DiscountType:     promotion.DiscountPercent,
+		Value:            25,
+		ValidFrom:        now.Add(-time.Hour),
+		ValidUntil:       now.Add(time.Hour),
+		MaxRedemptions:   2,
+		PerCustomerLimit: 1,
+	})
+	is.NoErr(err)
+	promoted := domestic
+	promoted.PromoCode = "spring25"
+	offers, err = q.Offers(ctx, promoted)
+	is.NoErr(err)
+	is.Equal(offers[1].ShipmentCost, money.New(0.75*2000_00, "SEK"))
+	redeemed, err := q.Create(ctx, promoted)
+	is.NoErr(err)
+	is.Equal(redeemed.PromoCode, "SPRING25")
+	is.Equal(redeemed.ShipmentCost, money.New(0.75*2000_00, "SEK"))
+	saved, err = q.QueryByID(ctx, redeemed.ID)
+	is.NoErr(err)
+	is.Equal(redeemed, saved)
 
-			Weight int
-		}{
-			{"below 0", -1},
-			{"above 1000", 1001},
+	_, err = q.Create(ctx, promoted)
+	is.True(errors.Is(err, promotion.ErrLimitReached)) // Per customer.
+	promoted.AccountID = acme.ID
+	_, err = q.Create(ctx, promoted)
+	is.NoErr(err)
+	promoted.AccountID = ""
+	someoneElse := *promoted.From
+	someoneElse.Email = "someone.else@example.com"
+	promoted.From = &someoneElse
+	_, err = q.Create(ctx, promoted)
+	is.True(errors.Is(err, promotion.ErrLimitReached)) // In total.
+	promoted.PromoCode = "WINTER10"
+	_, err = q.Create(ctx, promoted)
+	is.True(errors.Is(err, promotion.ErrInvalidCode))
+
+	// Query database with 3 newly added quotes of acme, 3 seeded quotes of
+	// another account and anonymous quotes, which are never listed.
+	err = schema.Seed(db)
+	is.NoErr(err)
+	const seededAccount = "9d3a1b6e-2f4c-4b8e-a0d1-6c5e7f8a9b10"
+	quotes, _, err = q.Query(ctx, Filter{})
+	is.NoErr(err)
+	is.Equal(len(quotes), 0)
+	quotes, next, err = q.Query(ctx, Filter{AccountID: acme.ID})
+	is.NoErr(err)
+	is.Equal(len(quotes), 3)
+	is.Equal(next, "")
+	for _, quote := range quotes {
+		is.Equal(quote.AccountID, acme.ID)
+		is.True(len(quote.Parcels) > 0) // Every quote has its parcels.
+	}
+
+	// Page through quotes, newest first. Quotes created at the same time are
+	// ordered by ID.
+	filter := Filter{AccountID: seededAccount, Descending: true, Limit: 2}
+	seen := make(map[string]bool)
+	var paged []Info
+	for {
+		page, next, err := q.Query(ctx, filter)
+		is.NoErr(err)
+		is.True(len(page) <= filter.Limit)
+		for _, quote := range page {
+			is.True(!seen[quote.ID]) // Every quote is listed once.
+			seen[quote.ID] = true
 		}
-		for _, tc := range cases {
-			t.Run(tc.Name, func(t *testing.T) {
-				is := is.New(t)
-				_, err := calcShipmentCost(tc.Weight, "sv")
-				is.True(err != nil)
-			})
+		paged = append(paged, page...)
+		if next == "" {
+			break
 		}
-	})
+		filter.Cursor = next
+	}
+	is.Equal(len(paged), 3)
+	is.Equal(paged[0].ID, "32b0639f-2cc6-44b8-b97b-15d69dbb511e")
+	is.Equal(paged[len(paged)-1].ID, "1cf37266-3473-4006-984f-9325122678b7")
+
+	// Cursors are bound to their sort order.
+	_, _, err = q.Query(ctx, Filter{AccountID: seededAccount, Sort: SortWeight, Descending: true, Cursor: filter.Cursor})
+	is.True(errors.Is(err, ErrInvalidCursor))
+	_, _, err = q.Query(ctx, Filter{AccountID: seededAccount, Cursor: "banana"})
+	is.True(errors.Is(err, ErrInvalidCursor))
+
+	// Filter quotes.
+	seeded, _, err := q.Query(ctx, Filter{AccountID: seededAccount, CreatedUntil: &now})
+	is.NoErr(err)
+	is.Equal(len(seeded), 3)
+	byEmail, _, err := q.Query(ctx, Filter{AccountID: seededAccount, Email: "Rose.Doe@example.com"})
+	is.NoErr(err)
+	is.Equal(len(byEmail), 1)
+	byEmail, _, err = q.Query(ctx, Filter{AccountID: acme.ID, Email: "Rose.Doe@example.com"})
+	is.NoErr(err)
+	is.Equal(len(byEmail), 0) // Not a customer of acme.
+	minCost, maxCost := int64(50000), int64(90000)
+	byCost, _, err := q.Query(ctx, Filter{AccountID: seededAccount, MinCost: &minCost, MaxCost: &maxCost, Sort: SortTotalCost})
+	is.NoErr(err)
+	is.Equal(len(byCost), 2)
+	is.Equal(byCost[0].TotalCost, money.New(50000, "SEK"))
+	byCountry, _, err := q.Query(ctx, Filter{AccountID: seededAccount, FromCountryCodes: []string{"FR", "US"}, ToCountryCodes: []string{"SE"}})
+	is.NoErr(err)
+	is.Equal(len(byCountry), 2)
+	heaviest, _, err := q.Query(ctx, Filter{AccountID: acme.ID, Sort: SortWeight, Descending: true, Limit: 1})
+	is.NoErr(err)
+	is.Equal(heaviest[0].Weight, billed.Weight)
+
+	// Search quotes by customer.
+	matches, err := q.Search(ctx, seededAccount, "rose teststr", 0)
+	is.NoErr(err)
+	is.Equal(len(matches), 1)
+	is.Equal(matches[0].Quote.ID, "32b0639f-2cc6-44b8-b97b-15d69dbb511e") // Seeded quote.
+	is.True(matches[0].Rank > 0)
+	is.True(strings.Contains(matches[0].From, "<b>Rose</b>"))
+	is.True(strings.Contains(matches[0].To, "<b>Teststreet</b>"))
+	matches, err = q.Search(ctx, seededAccount, "svensson", 2)
+	is.NoErr(err)
+	is.Equal(len(matches), 2)
+	matches, err = q.Search(ctx, acme.ID, "rose", 0)
+	is.NoErr(err)
+	is.Equal(len(matches), 0) // Not a customer of acme.
+	matches, err = q.Search(ctx, "", "svensson", 0)
+	is.NoErr(err)
+	is.Equal(len(matches), 0) // Anonymous quotes are never found.
+	_, err = q.Search(ctx, seededAccount, " & ", 0)
+	is.Equal(err, ErrEmptySearch)
+
+	// Create quote of saved customers.
+	byID, err := q.Create(ctx, NewQuote{AccountID: acme.ID, ToCustomerID: discounted.ToCustomerID, FromCustomerID: discounted.FromCustomerID, Weight: 500})
+	is.NoErr(err)
+	is.Equal(byID.To, discounted.To)
+	is.Equal(byID.From, discounted.From)
+	is.Equal(byID.ToCustomerID, discounted.ToCustomerID)
+	is.Equal(byID.FromCustomerID, discounted.FromCustomerID)
+	is.Equal(byID.TotalCost, discounted.TotalCost)
+	_, err = q.Create(ctx, NewQuote{AccountID: acme.ID, ToCustomerID: validate.GenerateID(), From: nq.From, Weight: 500})
+	is.True(errors.Is(err, customer.ErrNotFound))
+	_, err = q.Create(ctx, NewQuote{AccountID: acme.ID, ToCustomerID: quote.ToCustomerID, From: nq.From, Weight: 500})
+	is.True(errors.Is(err, customer.ErrNotFound)) // Not in the address book of acme.
+	_, err = q.Create(ctx, NewQuote{ToCustomerID: quote.ToCustomerID, FromCustomerID: quote.FromCustomerID, Weight: 500})
+	is.Equal(err, ErrAnonymousCustomer)
+	_, err = q.Offers(ctx, NewQuote{To: nq.To, FromCustomerID: quote.FromCustomerID, Weight: 500})
+	is.Equal(err, ErrAnonymousCustomer)
+
+	// Addresses are normalized before they are located and stored.
+	untidy := *nq.To
+	untidy.Address.Lines = []string{"  Testgatan   42B "}
+	untidy.Address.PostalCode = " 12345"
+	tidy, err := q.Create(ctx, NewQuote{To: &untidy, From: nq.From, Weight: 500})
+	is.NoErr(err)
+	is.Equal(tidy.To.Address, nq.To.Address)
+	is.Equal(tidy.ToCustomerID, quote.ToCustomerID) // Same customer.
+	is.Equal(*tidy.ToLocation, farsta)
+	is.Equal(untidy.Address.PostalCode, " 12345") // Not normalized in place.
+
+	// Quotes are added without coordinates if the geocoder fails, which is
+	// logged.
+	var logged bytes.Buffer
+	unavailable := New(log.New(&logged, "", 0), db, regions, pricing.FlatRate(), rates, estimator, contracts, failingGeocoder{}, clock(now), validity)
+	unlocated, err := unavailable.Create(ctx, nq)
+	is.NoErr(err)
+	is.True(unlocated.ToLocation == nil)
+	is.True(strings.Contains(logged.String(), "geocoder unavailable"))
+}
+
+// failingGeocoder is a geocode.Geocoder that is unavailable.
+type failingGeocoder struct{}
+
+func (failingGeocoder) Geocode(ctx context.Context, a address.Address) (geocode.Point, error) {
+	return geocode.Point{}, errors.New("geocoder unavailable")
 }
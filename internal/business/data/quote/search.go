@@ -0,0 +1,134 @@
+package quote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// ErrEmptySearch occurs when searching for quotes without any search terms.
+var ErrEmptySearch = errors.New("empty search")
+
+// Search result sizes.
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// Match is a quote matching a search. Rank is the relevance of the match,
+// higher is more relevant. To and From are the name, street address, city and
+// email of the receiver and the sender as HTML, with the matching terms
+// highlighted by <b> and </b>.
+type Match struct {
+	Quote Info    `json:"quote"`
+	Rank  float64 `json:"rank"`
+	To    string  `json:"to"`
+	From  string  `json:"from"`
+}
+
+// Search finds the quotes of the account with accountID with customers
+// matching the terms of text, most relevant first. Every term must match the start of a word of the name,
+// address, email or VAT number of the sender or the receiver, and matches of
+// names rank above matches of street addresses, cities and regions, which
+// rank above the rest. At most limit matches are returned, DefaultSearchLimit
+// if limit is 0. Returns ErrEmptySearch if text has no terms.
+func (q Quote) Search(ctx context.Context, accountID, text string, limit int) ([]Match, error) {
+	terms := tsQuery(text)
+	if terms == "" {
+		return nil, ErrEmptySearch
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	// Snippets are highlighted by control characters, which are removed from
+	// the customers first, and are escaped as HTML before the highlights are
+	// replaced by tags.
+	const query = `
+	SELECT` + quoteColumns + `,
+		ts_rank(search, terms) AS rank,
+		ts_headline('simple', translate(concat_ws(', ', to_name, replace(to_address, E'\n', ', '), nullif(to_city, ''), to_email), $3, ''), terms, $4) AS to_snippet,
+		ts_headline('simple', translate(concat_ws(', ', from_name, replace(from_address, E'\n', ', '), nullif(from_city, ''), from_email), $3, ''), terms, $4) AS from_snippet
+	FROM
+		quotes,
+		to_tsquery('simple', $1) terms
+	WHERE
+		account_id = $5
+		AND search @@ terms
+	ORDER BY
+		rank DESC, created_at DESC, quote_id
+	LIMIT $2`
+
+	queryMatches := []struct {
+		queryQuote
+		Rank        float64 `db:"rank"`
+		ToSnippet   string  `db:"to_snippet"`
+		FromSnippet string  `db:"from_snippet"`
+	}{}
+	options := "HighlightAll=true, StartSel=" + startSel + ", StopSel=" + stopSel
+	if err := q.db.SelectContext(ctx, &queryMatches, query, terms, limit, startSel+stopSel, options, accountID); err != nil {
+		return nil, fmt.Errorf("searching quotes: %w", err)
+	}
+
+	ids := make([]string, len(queryMatches))
+	for i, qm := range queryMatches {
+		ids[i] = qm.ID
+	}
+	parcels, err := q.queryParcels(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.clock.Now()
+	matches := []Match{}
+	for _, qm := range queryMatches {
+		info, err := qm.toInfo(parcels[qm.ID], now)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, Match{Quote: info, Rank: qm.Rank, To: highlight(qm.ToSnippet), From: highlight(qm.FromSnippet)})
+	}
+
+	return matches, nil
+}
+
+// Selection markers of the matching terms of snippets.
+const (
+	startSel = "\x01"
+	stopSel  = "\x02"
+)
+
+// highlighter replaces the selection markers of escaped snippets by tags.
+var highlighter = strings.NewReplacer(startSel, "<b>", stopSel, "</b>")
+
+// highlight returns snippet as HTML, with the matching terms selected by
+// startSel and stopSel highlighted by <b> and </b>.
+func highlight(snippet string) string {
+	return highlighter.Replace(html.EscapeString(snippet))
+}
+
+// tsQuery translates the terms of text into a PostgreSQL text search query
+// matching documents with words starting with every term, e.g.
+// "Sven Göteborg" into "'sven':* & 'göteborg':*". Characters with a meaning
+// in queries are dropped, so any text is a valid query. Returns an empty
+// string if text has no terms.
+func tsQuery(text string) string {
+	var terms []string
+	for _, term := range strings.Fields(strings.ToLower(text)) {
+		term = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`'\:&|!()<>*`, r) {
+				return -1
+			}
+			return r
+		}, term)
+		if term != "" {
+			terms = append(terms, "'"+term+"':*")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,53 @@
+package quote
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestTSQuery(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Text string
+
+		Want string
+	}{
+		{"empty", "  ", ""},
+		{"single term", "Svensson", "'svensson':*"},
+		{"several terms", "Sven  Göteborg", "'sven':* & 'göteborg':*"},
+		{"email", "sven.svensson@test.com", "'sven.svensson@test.com':*"},
+		{"operators", "sven & !(göteborg) | 'x':*", "'sven':* & 'göteborg':* & 'x':*"},
+		{"only operators", "& | !", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			is.Equal(tsQuery(tc.Text), tc.Want)
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Snippet string
+
+		Want string
+	}{
+		{"plain", "Sven Svensson, Vasagatan 5B", "Sven Svensson, Vasagatan 5B"},
+		{"highlighted", "\x01Sven\x02 \x01Svensson\x02, Vasagatan 5B", "<b>Sven</b> <b>Svensson</b>, Vasagatan 5B"},
+		{"markup", "\x01Sven\x02 <script>alert(1)</script> & Co", "<b>Sven</b> &lt;script&gt;alert(1)&lt;/script&gt; &amp; Co"},
+		{"tags", "<b>\x01Sven\x02</b>", "&lt;b&gt;<b>Sven</b>&lt;/b&gt;"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			is.Equal(highlight(tc.Snippet), tc.Want)
+		})
+	}
+}
@@ -47,7 +47,13 @@ func Seed(db *sqlx.DB) error {
 	return tx.Commit()
 }
 
-// parseMigrations parses SQL text into []darwin.Migration.
+// keepCaseFrom is the version of the first migration that is parsed as
+// written. Earlier migrations were applied lower cased, and are still lower
+// cased so that their checksums match the applied ones.
+const keepCaseFrom = 1.6
+
+// parseMigrations parses SQL text into []darwin.Migration. The version and
+// description headers are matched regardless of case.
 func parseMigrations(s string) []darwin.Migration {
 	var migs []darwin.Migration
 
@@ -57,9 +63,13 @@ func parseMigrations(s string) []darwin.Migration {
 	var mig darwin.Migration
 	var script string
 	for scanner.Scan() {
-		v := strings.ToLower(scanner.Text())
+		line := scanner.Text()
+		v := strings.ToLower(line)
+		if mig.Version < keepCaseFrom {
+			line = v
+		}
 		switch {
-		case len(v) >= 5 && (v[:6] == "-- ver" || v[:5] == "--ver"):
+		case strings.HasPrefix(v, "-- ver") || strings.HasPrefix(v, "--ver"):
 			mig.Script = script
 			migs = append(migs, mig)
 
@@ -72,11 +82,11 @@ func parseMigrations(s string) []darwin.Migration {
 			}
 			mig.Version = f
 
-		case len(v) >= 5 && (v[:6] == "-- des" || v[:5] == "--des"):
-			mig.Description = strings.Trim(v[15:], " ")
+		case strings.HasPrefix(v, "-- des") || strings.HasPrefix(v, "--des"):
+			mig.Description = strings.Trim(line[15:], " ")
 
 		default:
-			script += v + "\n"
+			script += line + "\n"
 		}
 	}
 
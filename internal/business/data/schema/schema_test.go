@@ -11,16 +11,23 @@ func TestParse(t *testing.T) {
 	migs := parseMigrations(schemaDoc)
 	var buf bytes.Buffer
 	for _, mig := range migs {
-		buf.WriteString(fmt.Sprintf("-- version: %.1f\n", mig.Version))
-		buf.WriteString(fmt.Sprintf("-- description: %s\n", mig.Description))
+		header := fmt.Sprintf("-- Version: %.1f\n-- Description: %s\n", mig.Version, mig.Description)
+		if mig.Version < keepCaseFrom {
+			header = strings.ToLower(header)
+		}
+		buf.WriteString(header)
 		buf.WriteString(mig.Script)
 	}
 
-	sql := strings.ToLower(schemaDoc)
+	// Migrations before keepCaseFrom are parsed lower cased.
+	sql := schemaDoc
+	if i := strings.Index(sql, fmt.Sprintf("-- Version: %.1f\n", keepCaseFrom)); i >= 0 {
+		sql = strings.ToLower(sql[:i]) + sql[i:]
+	}
 	if sql != buf.String() {
 		// Don't forget to add an extra line at the end of the .sql file in case
 		// got and exp looks identical.
-		t.Logf("got: %v", string(buf.Bytes()))
-		t.Logf("exp: %v", string([]byte(sql)))
+		t.Errorf("got: %v", buf.String())
+		t.Errorf("exp: %v", sql)
 	}
 }
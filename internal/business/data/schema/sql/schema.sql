@@ -14,3 +14,381 @@ CREATE TABLE quotes (
     from_country_code   TEXT NOT NULL,
 	PRIMARY KEY (quote_id)
 );
+-- Version: 1.2
+-- Description: Create table countries
+CREATE TABLE countries (
+	alpha2      TEXT,
+	alpha3      TEXT NOT NULL UNIQUE,
+	name        TEXT NOT NULL,
+	region      TEXT NOT NULL,
+	active      BOOLEAN NOT NULL DEFAULT TRUE,
+	PRIMARY KEY (alpha2)
+);
+INSERT INTO countries (alpha2, alpha3, name, region, active) VALUES
+	('sv', 'swe', 'sweden', 'nordic', TRUE),
+	('no', 'nor', 'norway', 'nordic', TRUE),
+	('dk', 'dnk', 'denmark', 'nordic', TRUE),
+	('fi', 'fin', 'finland', 'nordic', TRUE),
+	('fr', 'fra', 'france', 'within_eu', TRUE),
+	('de', 'deu', 'germany', 'within_eu', TRUE),
+	('nl', 'nld', 'netherlands', 'within_eu', TRUE),
+	('it', 'ita', 'italy', 'within_eu', TRUE),
+	('pt', 'prt', 'portugal', 'within_eu', TRUE),
+	('at', 'aut', 'austria', 'within_eu', TRUE),
+	('be', 'bel', 'belgium', 'within_eu', TRUE),
+	('lv', 'lva', 'latvia', 'within_eu', TRUE),
+	('bg', 'bgr', 'bulgaria', 'within_eu', TRUE),
+	('lt', 'ltu', 'lithuania', 'within_eu', TRUE),
+	('hr', 'hrv', 'croatia', 'within_eu', TRUE),
+	('lu', 'lux', 'luxembourg', 'within_eu', TRUE),
+	('cy', 'cyp', 'cyprus', 'within_eu', TRUE),
+	('mt', 'mlt', 'malta', 'within_eu', TRUE),
+	('cz', 'cze', 'czechia', 'within_eu', TRUE),
+	('pl', 'pol', 'poland', 'within_eu', TRUE),
+	('ee', 'est', 'estonia', 'within_eu', TRUE),
+	('ro', 'rou', 'romania', 'within_eu', TRUE),
+	('sk', 'svk', 'slovakia', 'within_eu', TRUE),
+	('si', 'svn', 'slovenia', 'within_eu', TRUE),
+	('gr', 'grc', 'greece', 'within_eu', TRUE),
+	('es', 'esp', 'spain', 'within_eu', TRUE),
+	('hu', 'hun', 'hungary', 'within_eu', TRUE),
+	('ie', 'irl', 'ireland', 'within_eu', TRUE),
+	('us', 'usa', 'united states of america', 'outside_eu', TRUE),
+	('ca', 'can', 'canada', 'outside_eu', TRUE),
+	('cn', 'chn', 'china', 'outside_eu', TRUE),
+	('jp', 'jpn', 'japan', 'outside_eu', TRUE),
+	('th', 'tha', 'thailand', 'outside_eu', TRUE),
+	('br', 'bra', 'brazil', 'outside_eu', TRUE),
+	('ar', 'arg', 'argentina', 'outside_eu', TRUE);
+-- Version: 1.3
+-- Description: Add membership flags to countries
+-- Rows override the embedded ISO 3166-1 dataset, and NULL columns are taken
+-- from the dataset.
+ALTER TABLE countries
+	ALTER COLUMN alpha3 DROP NOT NULL,
+	ALTER COLUMN name DROP NOT NULL,
+	ADD COLUMN nordic          BOOLEAN,
+	ADD COLUMN eu              BOOLEAN,
+	ADD COLUMN eea             BOOLEAN,
+	ADD COLUMN customs_union   BOOLEAN;
+-- SV is the code of El Salvador, not Sweden.
+UPDATE countries SET alpha2 = 'se' WHERE alpha2 = 'sv';
+-- The region of a row is kept as the flags it is derived from. EU membership
+-- of Nordic countries is taken from the dataset.
+UPDATE countries SET nordic = (region = 'nordic');
+UPDATE countries SET eu = (region = 'within_eu') WHERE region <> 'nordic';
+ALTER TABLE countries DROP COLUMN region;
+-- Version: 1.4
+-- Description: Add lane to quotes
+ALTER TABLE quotes
+	ADD COLUMN lane TEXT NOT NULL DEFAULT '';
+-- Version: 1.5
+-- Description: Add cost breakdown to quotes
+ALTER TABLE quotes
+	ADD COLUMN breakdown JSONB NOT NULL DEFAULT '[]';
+-- Version: 1.6
+-- Description: Store shipment cost in minor units with currency
+ALTER TABLE quotes
+	ALTER COLUMN shipment_cost TYPE BIGINT USING ROUND(shipment_cost::NUMERIC * 100),
+	ADD COLUMN shipment_currency TEXT NOT NULL DEFAULT 'SEK';
+ALTER TABLE quotes
+	ALTER COLUMN shipment_currency DROP DEFAULT;
+UPDATE quotes SET breakdown = (
+	SELECT
+		COALESCE(jsonb_agg(item || jsonb_build_object('amount', jsonb_build_object('amount', ROUND((item->>'amount')::NUMERIC * 100), 'currency', 'SEK')) ORDER BY idx), '[]')
+	FROM
+		jsonb_array_elements(breakdown) WITH ORDINALITY AS items(item, idx)
+);
+-- Version: 1.7
+-- Description: Add shipment cost converted to a requested currency to quotes
+ALTER TABLE quotes
+	ADD COLUMN converted_cost       BIGINT,
+	ADD COLUMN converted_currency   TEXT,
+	ADD COLUMN exchange_rate        DOUBLE PRECISION,
+	ADD COLUMN exchange_rate_at     TIMESTAMP;
+-- Version: 1.8
+-- Description: Add VAT to countries and quotes
+ALTER TABLE countries
+	ADD COLUMN vat_rate DOUBLE PRECISION;
+ALTER TABLE quotes
+	ADD COLUMN tax_rate          DOUBLE PRECISION NOT NULL DEFAULT 0,
+	ADD COLUMN tax_amount        BIGINT NOT NULL DEFAULT 0,
+	ADD COLUMN reverse_charge    BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD COLUMN tax_country       TEXT NOT NULL DEFAULT '',
+	ADD COLUMN total_cost        BIGINT,
+	ADD COLUMN to_vat_number     TEXT NOT NULL DEFAULT '',
+	ADD COLUMN from_vat_number   TEXT NOT NULL DEFAULT '';
+UPDATE quotes SET total_cost = shipment_cost;
+ALTER TABLE quotes
+	ALTER COLUMN total_cost SET NOT NULL;
+-- Version: 1.9
+-- Description: Add package dimensions and chargeable weight to quotes
+ALTER TABLE quotes
+	ADD COLUMN length_cm         INT,
+	ADD COLUMN width_cm          INT,
+	ADD COLUMN height_cm         INT,
+	ADD COLUMN chargeable_weight INT;
+UPDATE quotes SET chargeable_weight = package_weight;
+ALTER TABLE quotes
+	ALTER COLUMN chargeable_weight SET NOT NULL;
+-- Version: 2.0
+-- Description: Create table quote_parcels
+CREATE TABLE quote_parcels (
+	quote_id            TEXT REFERENCES quotes(quote_id) ON DELETE CASCADE,
+	parcel_no           INT,
+	package_weight      INT NOT NULL,
+	length_cm           INT,
+	width_cm            INT,
+	height_cm           INT,
+	quantity            INT NOT NULL,
+	chargeable_weight   INT NOT NULL,
+	PRIMARY KEY (quote_id, parcel_no)
+);
+INSERT INTO quote_parcels (quote_id, parcel_no, package_weight, length_cm, width_cm, height_cm, quantity, chargeable_weight)
+	SELECT quote_id, 0, package_weight, length_cm, width_cm, height_cm, 1, chargeable_weight FROM quotes;
+ALTER TABLE quotes
+	DROP COLUMN length_cm,
+	DROP COLUMN width_cm,
+	DROP COLUMN height_cm;
+-- Version: 2.1
+-- Description: Add service level to quotes
+ALTER TABLE quotes
+	ADD COLUMN service_level     TEXT NOT NULL DEFAULT 'standard',
+	ADD COLUMN transit_days      INT NOT NULL DEFAULT 0;
+ALTER TABLE quotes
+	ALTER COLUMN service_level DROP DEFAULT,
+	ALTER COLUMN transit_days DROP DEFAULT;
+-- Version: 2.2
+-- Description: Add estimated pickup and delivery dates to quotes
+ALTER TABLE quotes
+	ADD COLUMN pickup_date       DATE,
+	ADD COLUMN delivery_date     DATE;
+-- Version: 2.3
+-- Description: Add postal codes and dangerous goods to quotes
+ALTER TABLE quotes
+	ADD COLUMN dangerous_goods   BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD COLUMN to_postal_code    TEXT NOT NULL DEFAULT '',
+	ADD COLUMN from_postal_code  TEXT NOT NULL DEFAULT '';
+-- Version: 2.4
+-- Description: Create tables accounts and contracts
+CREATE TABLE accounts (
+	account_id          TEXT,
+	name                TEXT NOT NULL,
+	api_key_hash        TEXT NOT NULL UNIQUE,
+	PRIMARY KEY (account_id)
+);
+CREATE TABLE contracts (
+	contract_id         TEXT,
+	version             INT NOT NULL,
+	account_id          TEXT NOT NULL REFERENCES accounts ON DELETE CASCADE,
+	discount            DOUBLE PRECISION NOT NULL DEFAULT 0,
+	lanes               JSONB NOT NULL DEFAULT '[]',
+	created_at          TIMESTAMP NOT NULL,
+	PRIMARY KEY (contract_id, version)
+);
+CREATE INDEX contracts_account_id_idx ON contracts (account_id, created_at);
+ALTER TABLE quotes
+	ADD COLUMN account_id        TEXT REFERENCES accounts,
+	ADD COLUMN contract_id       TEXT,
+	ADD COLUMN contract_version  INT,
+	ADD FOREIGN KEY (contract_id, contract_version) REFERENCES contracts;
+-- Version: 2.5
+-- Description: Create tables promotions and promotion_redemptions
+CREATE TABLE promotions (
+	code                TEXT,
+	discount_type       TEXT NOT NULL,
+	value               DOUBLE PRECISION NOT NULL,
+	valid_from          TIMESTAMP NOT NULL,
+	valid_until         TIMESTAMP NOT NULL,
+	max_redemptions     INT NOT NULL DEFAULT 0,
+	per_customer_limit  INT NOT NULL DEFAULT 0,
+	redemptions         INT NOT NULL DEFAULT 0,
+	PRIMARY KEY (code)
+);
+CREATE TABLE promotion_redemptions (
+	quote_id            TEXT REFERENCES quotes(quote_id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+	code                TEXT NOT NULL REFERENCES promotions,
+	customer            TEXT NOT NULL,
+	redeemed_at         TIMESTAMP NOT NULL,
+	PRIMARY KEY (quote_id)
+);
+CREATE INDEX promotion_redemptions_code_idx ON promotion_redemptions (code, customer);
+ALTER TABLE quotes
+	ADD COLUMN promo_code        TEXT NOT NULL DEFAULT '';
+-- Version: 2.6
+-- Description: Add creation time and validity to quotes
+ALTER TABLE quotes
+	ADD COLUMN created_at        TIMESTAMP,
+	ADD COLUMN valid_until       TIMESTAMP;
+-- Promotions are redeemed when the quote is created, so those quotes keep
+-- their creation time and the default validity of 7 days. The creation time of
+-- other quotes is unknown: they are dated to the migration and expire at once,
+-- rather than becoming valid again.
+UPDATE quotes SET created_at = r.redeemed_at, valid_until = r.redeemed_at + INTERVAL '7 days'
+	FROM promotion_redemptions r WHERE r.quote_id = quotes.quote_id;
+UPDATE quotes SET created_at = NOW(), valid_until = NOW() WHERE created_at IS NULL;
+ALTER TABLE quotes
+	ALTER COLUMN created_at SET NOT NULL,
+	ALTER COLUMN valid_until SET NOT NULL;
+-- Version: 2.7
+-- Description: Add status to quotes and create table quote_events
+ALTER TABLE quotes
+	ADD COLUMN status            TEXT NOT NULL DEFAULT 'issued';
+ALTER TABLE quotes
+	ALTER COLUMN status DROP DEFAULT;
+CREATE TABLE quote_events (
+	quote_id            TEXT REFERENCES quotes(quote_id) ON DELETE CASCADE,
+	event_no            INT,
+	from_status         TEXT NOT NULL,
+	to_status           TEXT NOT NULL,
+	created_at          TIMESTAMP NOT NULL,
+	PRIMARY KEY (quote_id, event_no)
+);
+INSERT INTO quote_events (quote_id, event_no, from_status, to_status, created_at)
+	SELECT quote_id, 0, 'draft', 'issued', created_at FROM quotes;
+-- Version: 2.8
+-- Description: Create table bookings
+CREATE TABLE bookings (
+	booking_id          TEXT,
+	reference           TEXT NOT NULL UNIQUE,
+	quote_id            TEXT NOT NULL UNIQUE REFERENCES quotes(quote_id),
+	pickup_from         TIMESTAMP NOT NULL,
+	pickup_until        TIMESTAMP NOT NULL,
+	created_at          TIMESTAMP NOT NULL,
+	PRIMARY KEY (booking_id)
+);
+-- Version: 2.9
+-- Description: Add carrier to quotes
+ALTER TABLE quotes
+	ADD COLUMN carrier           TEXT NOT NULL DEFAULT '';
+-- Version: 3.0
+-- Description: Add indexes for listing quotes
+CREATE INDEX quotes_created_at_idx ON quotes (created_at, quote_id);
+CREATE INDEX quotes_total_cost_idx ON quotes (total_cost, quote_id);
+CREATE INDEX quotes_package_weight_idx ON quotes (package_weight, quote_id);
+-- Version: 3.1
+-- Description: Add full-text search over the customers of quotes
+ALTER TABLE quotes
+	ADD COLUMN search            TSVECTOR GENERATED ALWAYS AS (
+		setweight(to_tsvector('simple', to_name || ' ' || from_name), 'A') ||
+		setweight(to_tsvector('simple', to_address || ' ' || from_address), 'B') ||
+		setweight(to_tsvector('simple', to_email || ' ' || from_email || ' ' || to_postal_code || ' ' || from_postal_code || ' ' || to_vat_number || ' ' || from_vat_number || ' ' || to_country_code || ' ' || from_country_code), 'C')
+	) STORED;
+CREATE INDEX quotes_search_idx ON quotes USING GIN (search);
+-- Version: 3.2
+-- Description: Create table customers and reference them from quotes
+CREATE TABLE customers (
+	customer_id         TEXT,
+	name                TEXT NOT NULL,
+	email               TEXT NOT NULL,
+	address             TEXT NOT NULL,
+	postal_code         TEXT NOT NULL DEFAULT '',
+	country_code        TEXT NOT NULL,
+	vat_number          TEXT NOT NULL DEFAULT '',
+	created_at          TIMESTAMP NOT NULL,
+	updated_at          TIMESTAMP NOT NULL,
+	PRIMARY KEY (customer_id)
+);
+CREATE UNIQUE INDEX customers_email_address_idx ON customers (lower(email), lower(address));
+INSERT INTO customers (customer_id, name, email, address, postal_code, country_code, vat_number, created_at, updated_at)
+	SELECT DISTINCT ON (lower(email), lower(address))
+		gen_random_uuid()::text, name, email, address, postal_code, country_code, vat_number, first_seen, last_seen
+	FROM (
+		SELECT
+			*,
+			MIN(created_at) OVER (PARTITION BY lower(email), lower(address)) AS first_seen,
+			MAX(created_at) OVER (PARTITION BY lower(email), lower(address)) AS last_seen
+		FROM (
+			SELECT to_name AS name, to_email AS email, to_address AS address, to_postal_code AS postal_code, to_country_code AS country_code, to_vat_number AS vat_number, created_at FROM quotes
+			UNION ALL
+			SELECT from_name, from_email, from_address, from_postal_code, from_country_code, from_vat_number, created_at FROM quotes
+		) AS c
+	) AS c
+	ORDER BY lower(email), lower(address), created_at DESC;
+ALTER TABLE quotes
+	ADD COLUMN to_customer_id    TEXT REFERENCES customers(customer_id) ON DELETE SET NULL,
+	ADD COLUMN from_customer_id  TEXT REFERENCES customers(customer_id) ON DELETE SET NULL;
+UPDATE quotes q SET to_customer_id = c.customer_id
+	FROM customers c WHERE lower(c.email) = lower(q.to_email) AND lower(c.address) = lower(q.to_address);
+UPDATE quotes q SET from_customer_id = c.customer_id
+	FROM customers c WHERE lower(c.email) = lower(q.from_email) AND lower(c.address) = lower(q.from_address);
+-- Version: 3.3
+-- Description: Split addresses into street lines, postal code, city and region
+CREATE FUNCTION pg_temp.split_address(address TEXT, OUT lines TEXT, OUT postal_code TEXT, OUT city TEXT) AS $$
+	SELECT
+		coalesce(regexp_replace(substring(address FROM '^(.*),'), '\s*,\s*', E'\n', 'g'), address),
+		coalesce((regexp_match(tail, '^[^0-9]*[^0-9 ]\s+([0-9][0-9 -]*[0-9])$'))[1], (regexp_match(tail, '^([0-9][0-9 -]*[0-9])\s+[^0-9]+$'))[1], ''),
+		coalesce((regexp_match(tail, '^([^0-9]*[^0-9 ])\s+[0-9][0-9 -]*[0-9]$'))[1], (regexp_match(tail, '^[0-9][0-9 -]*[0-9]\s+([^0-9]+)$'))[1], tail, '')
+	FROM (SELECT trim(substring(address FROM ',([^,]*)$')) AS tail) AS t
+$$ LANGUAGE SQL;
+ALTER TABLE quotes
+	DROP COLUMN search,
+	ADD COLUMN to_city           TEXT NOT NULL DEFAULT '',
+	ADD COLUMN to_region         TEXT NOT NULL DEFAULT '',
+	ADD COLUMN from_city         TEXT NOT NULL DEFAULT '',
+	ADD COLUMN from_region       TEXT NOT NULL DEFAULT '';
+UPDATE quotes SET (to_address, to_postal_code, to_city) = (
+	SELECT lines, CASE WHEN to_postal_code = '' THEN postal_code ELSE to_postal_code END, city FROM pg_temp.split_address(to_address));
+UPDATE quotes SET (from_address, from_postal_code, from_city) = (
+	SELECT lines, CASE WHEN from_postal_code = '' THEN postal_code ELSE from_postal_code END, city FROM pg_temp.split_address(from_address));
+ALTER TABLE quotes
+	ADD COLUMN search            TSVECTOR GENERATED ALWAYS AS (
+		setweight(to_tsvector('simple', to_name || ' ' || from_name), 'A') ||
+		setweight(to_tsvector('simple', to_address || ' ' || from_address || ' ' || to_city || ' ' || from_city || ' ' || to_region || ' ' || from_region), 'B') ||
+		setweight(to_tsvector('simple', to_email || ' ' || from_email || ' ' || to_postal_code || ' ' || from_postal_code || ' ' || to_vat_number || ' ' || from_vat_number || ' ' || to_country_code || ' ' || from_country_code), 'C')
+	) STORED;
+CREATE INDEX quotes_search_idx ON quotes USING GIN (search);
+ALTER TABLE customers
+	ADD COLUMN city              TEXT NOT NULL DEFAULT '',
+	ADD COLUMN region            TEXT NOT NULL DEFAULT '';
+UPDATE customers SET (address, postal_code, city) = (
+	SELECT lines, CASE WHEN customers.postal_code = '' THEN s.postal_code ELSE customers.postal_code END, s.city FROM pg_temp.split_address(address) AS s);
+DROP INDEX customers_email_address_idx;
+CREATE UNIQUE INDEX customers_email_address_idx ON customers (lower(email), lower(address), lower(postal_code), lower(city), country_code);
+DROP FUNCTION pg_temp.split_address;
+-- Version: 3.4
+-- Description: Add the coordinates of the customers of quotes
+ALTER TABLE quotes
+	ADD COLUMN to_lat            DOUBLE PRECISION,
+	ADD COLUMN to_lon            DOUBLE PRECISION,
+	ADD COLUMN from_lat          DOUBLE PRECISION,
+	ADD COLUMN from_lon          DOUBLE PRECISION;
+-- Version: 3.5
+-- Description: Add the reference of the booking at the carrier to bookings
+ALTER TABLE bookings
+	ADD COLUMN carrier_reference TEXT NOT NULL DEFAULT '';
+-- Version: 3.6
+-- Description: Keep an address book of customers per account
+-- Customers only on quotes of a single account move to the address book of
+-- the account, the rest stay in the address book of anonymous quotes.
+ALTER TABLE customers
+	ADD COLUMN account_id        TEXT REFERENCES accounts ON DELETE CASCADE;
+UPDATE customers c SET account_id = a.account_id
+	FROM (
+		SELECT customer_id, MIN(account_id) AS account_id
+		FROM (
+			SELECT to_customer_id AS customer_id, account_id FROM quotes WHERE to_customer_id IS NOT NULL
+			UNION ALL
+			SELECT from_customer_id, account_id FROM quotes WHERE from_customer_id IS NOT NULL
+		) AS q
+		GROUP BY customer_id
+		HAVING COUNT(DISTINCT account_id) = 1 AND COUNT(*) = COUNT(account_id)
+	) AS a
+	WHERE c.customer_id = a.customer_id;
+DROP INDEX customers_email_address_idx;
+CREATE UNIQUE INDEX customers_email_address_idx ON customers (coalesce(account_id, ''), lower(email), lower(address), lower(postal_code), lower(city), country_code);
+CREATE INDEX customers_account_id_idx ON customers (account_id, name, customer_id);
+-- Version: 3.7
+-- Description: Add status to bookings
+ALTER TABLE bookings
+	ADD COLUMN status            TEXT NOT NULL DEFAULT 'confirmed';
+ALTER TABLE bookings
+	ALTER COLUMN status DROP DEFAULT;
+-- Version: 3.8
+-- Description: Index quotes for listing by account
+DROP INDEX quotes_created_at_idx;
+DROP INDEX quotes_total_cost_idx;
+DROP INDEX quotes_package_weight_idx;
+CREATE INDEX quotes_created_at_idx ON quotes (account_id, created_at, quote_id);
+CREATE INDEX quotes_total_cost_idx ON quotes (account_id, total_cost, quote_id);
+CREATE INDEX quotes_package_weight_idx ON quotes (account_id, package_weight, quote_id);
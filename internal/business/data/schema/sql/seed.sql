@@ -1,5 +1,29 @@
-INSERT INTO quotes (quote_id, package_weight, shipment_cost, to_name, to_email, to_address, to_country_code, from_name, from_email, from_address, from_country_code) VALUES
-	('1cf37266-3473-4006-984f-9325122678b7', 45, 1250, 'Sven Svensson', 'sven.svensson@example.com', 'Teststreet 42A, CityA 12345', 'SE', 'John Doe', 'john.doe@example.com', 'Teststreet 42B, CityB 12345', 'US'),
-    ('25b5fbd3-755f-4379-8f07-a58d4a30fa2f', 45, 500, 'Johan Doe', 'john.doe@example.com', 'Teststreet 42B, CityB 12345', 'US', 'Sven Svensson', 'sven.svensson@example.com', 'Teststreet 42A, CityA 12345', 'SE'),
-    ('32b0639f-2cc6-44b8-b97b-15d69dbb511e', 45, 750, 'Karin Svensson', 'karin.svensson@example.com', 'Teststreet 19A, CityD 12345', 'SE', 'Rose Doe', 'rose.doe@example.com', 'Teststreet 19B, CityF 12345', 'FR')
+-- The API key of the seeded account is acme-test-key. The seeded customers and
+-- quotes belong to the account.
+INSERT INTO accounts (account_id, name, api_key_hash) VALUES
+	('9d3a1b6e-2f4c-4b8e-a0d1-6c5e7f8a9b10', 'Acme AB', 'ebfbfd0414bb0cb52b149c7596a65b6892c759178bdc540e50a3c9b3575775e3')
+	ON CONFLICT DO NOTHING;
+INSERT INTO contracts (contract_id, version, account_id, discount, lanes, created_at) VALUES
+	('acme', 1, '9d3a1b6e-2f4c-4b8e-a0d1-6c5e7f8a9b10', 10, '[]', '2021-04-01 00:00:00')
+	ON CONFLICT DO NOTHING;
+INSERT INTO customers (customer_id, account_id, name, email, address, postal_code, city, country_code, created_at, updated_at) VALUES
+	('5e0c1f2a-8b7d-4c3e-9f1a-2d6b8e4c7a01', '9d3a1b6e-2f4c-4b8e-a0d1-6c5e7f8a9b10', 'Sven Svensson', 'sven.svensson@example.com', 'Teststreet 42A', '12345', 'CityA', 'SE', '2021-04-01 00:00:00', '2021-04-01 00:00:00'),
+	('7a2d9c4e-1f3b-4e8a-b6c5-3d9f0e1a2b02', '9d3a1b6e-2f4c-4b8e-a0d1-6c5e7f8a9b10', 'John Doe', 'john.doe@example.com', 'Teststreet 42B', '12345', 'CityB', 'US', '2021-04-01 00:00:00', '2021-04-01 00:00:00'),
+	('8c4e1a3f-6d2b-4f9c-a7e8-4b0c1d2e3f03', '9d3a1b6e-2f4c-4b8e-a0d1-6c5e7f8a9b10', 'Karin Svensson', 'karin.svensson@example.com', 'Teststreet 19A', '12345', 'CityD', 'SE', '2021-04-01 00:00:00', '2021-04-01 00:00:00'),
+	('9f6a3c5e-2b8d-4a1f-8c9e-5d1e2f3a4b04', '9d3a1b6e-2f4c-4b8e-a0d1-6c5e7f8a9b10', 'Rose Doe', 'rose.doe@example.com', 'Teststreet 19B', '12345', 'CityF', 'FR', '2021-04-01 00:00:00', '2021-04-01 00:00:00')
+	ON CONFLICT DO NOTHING;
+INSERT INTO quotes (quote_id, account_id, status, created_at, valid_until, package_weight, chargeable_weight, service_level, transit_days, shipment_cost, shipment_currency, tax_rate, tax_amount, tax_country, total_cost, lane, breakdown, to_name, to_email, to_address, to_postal_code, to_city, to_country_code, from_name, from_email, from_address, from_postal_code, from_city, from_country_code, to_customer_id, from_customer_id) VALUES
+	('1cf37266-3473-4006-984f-9325122678b7', '9d3a1b6e-2f4c-4b8e-a0d1-6c5e7f8a9b10', 'issued', '2021-04-01 00:00:00', '2021-04-08 00:00:00', 45, 45, 'standard', 3, 125000, 'SEK', 0, 0, '', 125000, 'outside_eu:nordic', '[{"kind": "base", "description": "weight class 26-50 kg", "amount": {"amount": 50000, "currency": "SEK"}}, {"kind": "factor", "description": "region outside_eu", "factor": 2.5, "amount": {"amount": 75000, "currency": "SEK"}}, {"kind": "tax", "description": "VAT zero-rated international shipment", "amount": {"amount": 0, "currency": "SEK"}}]', 'Sven Svensson', 'sven.svensson@example.com', 'Teststreet 42A', '12345', 'CityA', 'SE', 'John Doe', 'john.doe@example.com', 'Teststreet 42B', '12345', 'CityB', 'US', '5e0c1f2a-8b7d-4c3e-9f1a-2d6b8e4c7a01', '7a2d9c4e-1f3b-4e8a-b6c5-3d9f0e1a2b02'),
+    ('25b5fbd3-755f-4379-8f07-a58d4a30fa2f', '9d3a1b6e-2f4c-4b8e-a0d1-6c5e7f8a9b10', 'issued', '2021-04-01 00:00:00', '2021-04-08 00:00:00', 45, 45, 'standard', 3, 50000, 'SEK', 0, 0, '', 50000, 'nordic:outside_eu', '[{"kind": "base", "description": "weight class 26-50 kg", "amount": {"amount": 50000, "currency": "SEK"}}, {"kind": "factor", "description": "region nordic", "factor": 1, "amount": {"amount": 0, "currency": "SEK"}}, {"kind": "tax", "description": "VAT zero-rated international shipment", "amount": {"amount": 0, "currency": "SEK"}}]', 'Johan Doe', 'john.doe@example.com', 'Teststreet 42B', '12345', 'CityB', 'US', 'Sven Svensson', 'sven.svensson@example.com', 'Teststreet 42A', '12345', 'CityA', 'SE', '7a2d9c4e-1f3b-4e8a-b6c5-3d9f0e1a2b02', '5e0c1f2a-8b7d-4c3e-9f1a-2d6b8e4c7a01'),
+    ('32b0639f-2cc6-44b8-b97b-15d69dbb511e', '9d3a1b6e-2f4c-4b8e-a0d1-6c5e7f8a9b10', 'issued', '2021-04-01 00:00:00', '2021-04-08 00:00:00', 45, 45, 'standard', 3, 75000, 'SEK', 0.2, 15000, 'FR', 90000, 'within_eu:nordic', '[{"kind": "base", "description": "weight class 26-50 kg", "amount": {"amount": 50000, "currency": "SEK"}}, {"kind": "factor", "description": "region within_eu", "factor": 1.5, "amount": {"amount": 25000, "currency": "SEK"}}, {"kind": "tax", "description": "VAT FR 20%", "factor": 0.2, "amount": {"amount": 15000, "currency": "SEK"}}]', 'Karin Svensson', 'karin.svensson@example.com', 'Teststreet 19A', '12345', 'CityD', 'SE', 'Rose Doe', 'rose.doe@example.com', 'Teststreet 19B', '12345', 'CityF', 'FR', '8c4e1a3f-6d2b-4f9c-a7e8-4b0c1d2e3f03', '9f6a3c5e-2b8d-4a1f-8c9e-5d1e2f3a4b04')
+	ON CONFLICT DO NOTHING;
+INSERT INTO quote_parcels (quote_id, parcel_no, package_weight, quantity, chargeable_weight) VALUES
+	('1cf37266-3473-4006-984f-9325122678b7', 0, 45, 1, 45),
+	('25b5fbd3-755f-4379-8f07-a58d4a30fa2f', 0, 45, 1, 45),
+	('32b0639f-2cc6-44b8-b97b-15d69dbb511e', 0, 45, 1, 45)
+	ON CONFLICT DO NOTHING;
+INSERT INTO quote_events (quote_id, event_no, from_status, to_status, created_at) VALUES
+	('1cf37266-3473-4006-984f-9325122678b7', 0, 'draft', 'issued', '2021-04-01 00:00:00'),
+	('25b5fbd3-755f-4379-8f07-a58d4a30fa2f', 0, 'draft', 'issued', '2021-04-01 00:00:00'),
+	('32b0639f-2cc6-44b8-b97b-15d69dbb511e', 0, 'draft', 'issued', '2021-04-01 00:00:00')
 	ON CONFLICT DO NOTHING;
\ No newline at end of file
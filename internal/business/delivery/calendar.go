@@ -0,0 +1,229 @@
+package delivery
+
+import (
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// holidaysFS contains a CSV file of public holidays per country, named by the
+// lowercase ISO 3166-1 alpha-2 code of the country.
+//
+//go:embed holidays/*.csv
+var holidaysFS embed.FS
+
+// calendars are the parsed holiday calendars keyed by alpha-2 code.
+var calendars map[string]Calendar
+
+func init() {
+	var err error
+	calendars, err = parseCalendars(holidaysFS)
+	if err != nil {
+		panic(fmt.Sprintf("parsing embedded holiday calendars: %s", err))
+	}
+}
+
+// Calendar is the business day calendar of a country. Saturdays, Sundays and
+// public holidays are not business days.
+type Calendar struct {
+	holidays []holiday
+}
+
+// CalendarOf returns the calendar of the country with alpha-2 code ccode.
+// Countries without a holiday calendar only have weekends off.
+func CalendarOf(ccode string) Calendar {
+	return calendars[strings.ToLower(ccode)]
+}
+
+// Holiday returns the name of the public holiday on the date of t, if any.
+func (c Calendar) Holiday(t time.Time) (string, bool) {
+	for _, h := range c.holidays {
+		if h.on(t) {
+			return h.name, true
+		}
+	}
+	return "", false
+}
+
+// IsBusinessDay reports whether the date of t is a business day.
+func (c Calendar) IsBusinessDay(t time.Time) bool {
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	_, ok := c.Holiday(t)
+	return !ok
+}
+
+// NextBusinessDay returns the first business day on or after the date of t.
+func (c Calendar) NextBusinessDay(t time.Time) time.Time {
+	t = date(t)
+	for !c.IsBusinessDay(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// AddBusinessDays returns the date n business days after the date of t.
+func (c Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	t = date(t)
+	for n > 0 {
+		t = t.AddDate(0, 0, 1)
+		if c.IsBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
+
+// holiday is a public holiday recurring every year. The date of the holiday
+// is either fixed, relative to Easter Sunday, or the first weekday on or after
+// a fixed date.
+type holiday struct {
+	name string
+
+	month time.Month
+	day   int
+
+	// easter is set for holidays relative to Easter Sunday, in which case
+	// offset is the number of days from Easter Sunday.
+	easter bool
+	offset int
+
+	// weekday is set for holidays on the first weekday on or after the fixed
+	// date.
+	weekday *time.Weekday
+}
+
+// on reports whether the holiday falls on the date of t.
+func (h holiday) on(t time.Time) bool {
+	t = date(t)
+	return h.date(t.Year()).Equal(t)
+}
+
+// date returns the date of the holiday in year.
+func (h holiday) date(year int) time.Time {
+	if h.easter {
+		return easter(year).AddDate(0, 0, h.offset)
+	}
+	d := time.Date(year, h.month, h.day, 0, 0, 0, 0, time.UTC)
+	if h.weekday != nil {
+		d = d.AddDate(0, 0, (int(*h.weekday)-int(d.Weekday())+7)%7)
+	}
+	return d
+}
+
+// easter returns the date of Easter Sunday in year, using the anonymous
+// Gregorian algorithm.
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// date returns midnight UTC of the date of t.
+func date(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+var weekdays = map[string]time.Weekday{
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+	"sun": time.Sunday,
+}
+
+// parseCalendars parses every holiday calendar in fsys.
+func parseCalendars(fsys embed.FS) (map[string]Calendar, error) {
+	files, err := fsys.ReadDir("holidays")
+	if err != nil {
+		return nil, err
+	}
+	cals := make(map[string]Calendar, len(files))
+	for _, f := range files {
+		data, err := fsys.ReadFile(path.Join("holidays", f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		cal, err := parseCalendar(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+		cals[strings.TrimSuffix(f.Name(), ".csv")] = cal
+	}
+	return cals, nil
+}
+
+// parseCalendar parses a CSV formatted holiday calendar with a rule and a name
+// per holiday. A rule is a fixed date, e.g. "12-25", an offset from Easter
+// Sunday, e.g. "easter-2", or the first weekday on or after a fixed date, e.g.
+// "fri>=06-19".
+func parseCalendar(s string) (Calendar, error) {
+	records, err := csv.NewReader(strings.NewReader(s)).ReadAll()
+	if err != nil {
+		return Calendar{}, err
+	}
+	if len(records) == 0 {
+		return Calendar{}, fmt.Errorf("no header")
+	}
+
+	var cal Calendar
+	for i, r := range records[1:] {
+		if len(r) != 2 {
+			return Calendar{}, fmt.Errorf("line %d: expected 2 fields, got %d", i+2, len(r))
+		}
+		h, err := parseHoliday(r[0])
+		if err != nil {
+			return Calendar{}, fmt.Errorf("line %d: %w", i+2, err)
+		}
+		h.name = r[1]
+		cal.holidays = append(cal.holidays, h)
+	}
+	return cal, nil
+}
+
+// parseHoliday parses a holiday rule.
+func parseHoliday(rule string) (holiday, error) {
+	if strings.HasPrefix(rule, "easter") {
+		var offset int
+		if s := strings.TrimPrefix(rule, "easter"); s != "" {
+			var err error
+			if offset, err = strconv.Atoi(s); err != nil {
+				return holiday{}, fmt.Errorf("invalid easter offset in rule %q", rule)
+			}
+		}
+		return holiday{easter: true, offset: offset}, nil
+	}
+
+	var h holiday
+	if i := strings.Index(rule, ">="); i >= 0 {
+		wd, ok := weekdays[rule[:i]]
+		if !ok {
+			return holiday{}, fmt.Errorf("invalid weekday in rule %q", rule)
+		}
+		h.weekday = &wd
+		rule = rule[i+2:]
+	}
+	t, err := time.Parse("01-02", rule)
+	if err != nil {
+		return holiday{}, fmt.Errorf("invalid date in rule %q", rule)
+	}
+	h.month, h.day = t.Month(), t.Day()
+	return h, nil
+}
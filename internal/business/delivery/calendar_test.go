@@ -0,0 +1,110 @@
+package delivery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestEaster(t *testing.T) {
+	cases := []struct {
+		Year int
+		Want time.Time
+	}{
+		{2021, day(2021, time.April, 4)},
+		{2024, day(2024, time.March, 31)},
+		{2025, day(2025, time.April, 20)},
+		{2026, day(2026, time.April, 5)},
+		{2038, day(2038, time.April, 25)},
+	}
+	for _, tc := range cases {
+		is := is.New(t)
+		is.Equal(easter(tc.Year), tc.Want)
+	}
+}
+
+func TestCalendarHoliday(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		CountryCode string
+		Date        time.Time
+
+		WantHoliday string
+	}{
+		{"fixed date", "se", day(2026, time.June, 6), "National Day"},
+		{"relative to easter", "se", day(2026, time.April, 3), "Good Friday"},
+		{"first weekday on or after date", "se", day(2026, time.June, 19), "Midsummer Eve"},
+		{"thanksgiving", "us", day(2026, time.November, 26), "Thanksgiving Day"},
+		{"last monday in may", "gb", day(2026, time.May, 25), "Spring Bank Holiday"},
+		{"uppercase country code", "DE", day(2026, time.October, 3), "German Unity Day"},
+		{"not a holiday", "se", day(2026, time.June, 18), ""},
+		{"holiday in another country", "se", day(2026, time.May, 17), ""},
+		{"country without calendar", "jp", day(2026, time.January, 1), ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			got, ok := CalendarOf(tc.CountryCode).Holiday(tc.Date)
+			is.Equal(ok, tc.WantHoliday != "")
+			is.Equal(got, tc.WantHoliday)
+		})
+	}
+}
+
+func TestCalendarBusinessDays(t *testing.T) {
+	se := CalendarOf("se")
+
+	cases := []struct {
+		Name string
+
+		From time.Time
+		Days int
+
+		Want time.Time
+	}{
+		{"same week", day(2026, time.October, 19), 2, day(2026, time.October, 21)},
+		{"over weekend", day(2026, time.October, 23), 1, day(2026, time.October, 26)},
+		{"over easter", day(2026, time.April, 2), 1, day(2026, time.April, 7)},
+		{"over christmas", day(2026, time.December, 23), 2, day(2026, time.December, 29)},
+		{"zero days", day(2026, time.October, 19), 0, day(2026, time.October, 19)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(se.AddBusinessDays(tc.From, tc.Days), tc.Want)
+		})
+	}
+
+	is := is.New(t)
+	is.Equal(se.NextBusinessDay(day(2026, time.October, 24)), day(2026, time.October, 26)) // Saturday.
+	is.Equal(se.NextBusinessDay(day(2026, time.October, 26)), day(2026, time.October, 26)) // Monday.
+}
+
+func TestParseCalendar(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Calendar string
+	}{
+		{"no header", ""},
+		{"missing name", "rule,name\n01-01"},
+		{"bad date", "rule,name\n13-01,Nope"},
+		{"bad easter offset", "rule,name\neaster+x,Nope"},
+		{"bad weekday", "rule,name\nfoo>=01-01,Nope"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			_, err := parseCalendar(tc.Calendar)
+			is.True(err != nil)
+		})
+	}
+}
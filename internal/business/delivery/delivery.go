@@ -0,0 +1,63 @@
+// Package delivery contains functionality for estimating pickup and delivery
+// dates of shipments.
+package delivery
+
+import (
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+)
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is a Clock telling the time of the system.
+type SystemClock struct{}
+
+// Now returns the current time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Estimate is the estimated pickup and delivery date of a shipment.
+type Estimate struct {
+	TransitDays int
+	Pickup      time.Time
+	Delivery    time.Time
+}
+
+// Estimator estimates pickup and delivery dates of shipments.
+type Estimator struct {
+	clock   Clock
+	transit Transit
+}
+
+// NewEstimator constructs an Estimator telling the time with clock.
+func NewEstimator(clock Clock, transit Transit) Estimator {
+	return Estimator{clock, transit}
+}
+
+// Estimate estimates when shipment s, priced at level, is picked up and
+// delivered. Shipments are picked up the current day if it is a business day
+// in the origin country and the cutoff hour has not passed, otherwise the next
+// business day. Transit days are counted in business days of the destination
+// country.
+func (e Estimator) Estimate(s pricing.Shipment, level pricing.ServiceLevel) Estimate {
+	now := e.clock.Now().UTC()
+	origin, destination := CalendarOf(s.From.Alpha2), CalendarOf(s.To.Alpha2)
+
+	pickup := date(now)
+	if now.Hour() >= e.transit.CutoffHour {
+		pickup = pickup.AddDate(0, 0, 1)
+	}
+	pickup = origin.NextBusinessDay(pickup)
+
+	days := e.transit.TransitDays(s.Lane(), level)
+	return Estimate{
+		TransitDays: days,
+		Pickup:      pickup,
+		Delivery:    destination.AddBusinessDays(pickup, days),
+	}
+}
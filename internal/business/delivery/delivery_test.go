@@ -0,0 +1,66 @@
+package delivery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/region"
+	"github.com/matryer/is"
+)
+
+// clock is a Clock stopped at a fixed time.
+type clock time.Time
+
+func (c clock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestEstimate(t *testing.T) {
+	countries := region.NewCache(nil)
+	country := func(ccode string) region.Country {
+		c, err := countries.Country(ccode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	transit := Transit{
+		CutoffHour: 15,
+		Rules: []TransitRule{
+			{From: "nordic", To: "nordic", TransitDays: 2},
+		},
+	}
+	standard := pricing.ServiceLevel{Name: pricing.ServiceStandard, TransitDays: 5}
+
+	cases := []struct {
+		Name string
+
+		Now  time.Time
+		From string
+		To   string
+
+		WantPickup   time.Time
+		WantDelivery time.Time
+	}{
+		{"before cutoff", time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC), "se", "no", day(2026, time.October, 19), day(2026, time.October, 21)},
+		{"after cutoff", time.Date(2026, time.October, 19, 15, 0, 0, 0, time.UTC), "se", "no", day(2026, time.October, 20), day(2026, time.October, 22)},
+		{"friday after cutoff", time.Date(2026, time.October, 23, 16, 0, 0, 0, time.UTC), "se", "no", day(2026, time.October, 26), day(2026, time.October, 28)},
+		{"weekend", time.Date(2026, time.October, 24, 9, 0, 0, 0, time.UTC), "se", "no", day(2026, time.October, 26), day(2026, time.October, 28)},
+		{"origin holiday", time.Date(2026, time.June, 19, 9, 0, 0, 0, time.UTC), "se", "no", day(2026, time.June, 22), day(2026, time.June, 24)},
+		{"destination holiday", time.Date(2026, time.May, 22, 9, 0, 0, 0, time.UTC), "se", "no", day(2026, time.May, 22), day(2026, time.May, 27)},
+		{"transit days of service level", time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC), "us", "se", day(2026, time.October, 19), day(2026, time.October, 26)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			e := NewEstimator(clock(tc.Now), transit)
+			s := pricing.Shipment{From: country(tc.From), To: country(tc.To)}
+			got := e.Estimate(s, standard)
+			is.Equal(got.Pickup, tc.WantPickup)
+			is.Equal(got.Delivery, tc.WantDelivery)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/region"
+)
+
+// Transit contains the rules for estimating transit times.
+type Transit struct {
+	// CutoffHour is the hour of the day, in UTC, after which shipments are
+	// picked up the next business day. Zero means shipments are never picked
+	// up the same day.
+	CutoffHour int `json:"cutoff_hour"`
+	// Rules are the transit times of lanes per service level.
+	Rules []TransitRule `json:"rules"`
+}
+
+// TransitRule is the number of business days from pickup to delivery of
+// shipments on a lane. A domestic rule applies to shipments within a single
+// country of the From region, in which case To must equal From. An empty
+// ServiceLevel applies to every service level.
+type TransitRule struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Domestic     bool   `json:"domestic"`
+	ServiceLevel string `json:"service_level"`
+	TransitDays  int    `json:"transit_days"`
+}
+
+// LoadTransit reads and validates the transit rules file at path.
+func LoadTransit(path string) (Transit, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Transit{}, err
+	}
+	defer f.Close()
+
+	var t Transit
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&t); err != nil {
+		return Transit{}, fmt.Errorf("decoding transit rules: %w", err)
+	}
+	if err := t.Validate(); err != nil {
+		return Transit{}, err
+	}
+	return t, nil
+}
+
+// Validate checks that the transit rules are well formed.
+func (t Transit) Validate() error {
+	if t.CutoffHour < 0 || t.CutoffHour > 24 {
+		return fmt.Errorf("cutoff_hour: %d is not within 0-24", t.CutoffHour)
+	}
+	type key struct {
+		lane  pricing.Lane
+		level string
+	}
+	seen := map[key]int{}
+	for i, r := range t.Rules {
+		l, err := r.lane()
+		if err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		if r.TransitDays < 0 {
+			return fmt.Errorf("rules[%d]: negative transit days %d", i, r.TransitDays)
+		}
+		k := key{l, r.ServiceLevel}
+		if j, ok := seen[k]; ok {
+			return fmt.Errorf("rules[%d]: duplicate of rules[%d]", i, j)
+		}
+		seen[k] = i
+	}
+	return nil
+}
+
+// TransitDays returns the transit time in business days of a shipment on
+// lane at service level. Rules for the exact lane take precedence over rules
+// for the lane within the region, and rules for the service level over rules
+// for every service level. Returns the transit days of the service level if no
+// rule applies.
+func (t Transit) TransitDays(lane pricing.Lane, level pricing.ServiceLevel) int {
+	best, days := 0, level.TransitDays
+	for _, r := range t.Rules {
+		l, err := r.lane()
+		if err != nil || l.From != lane.From || l.To != lane.To || (l.Domestic && !lane.Domestic) {
+			continue
+		}
+		if r.ServiceLevel != "" && r.ServiceLevel != level.Name {
+			continue
+		}
+		score := 1
+		if l.Domestic {
+			score += 2
+		}
+		if r.ServiceLevel != "" {
+			score++
+		}
+		if score > best {
+			best, days = score, r.TransitDays
+		}
+	}
+	return days
+}
+
+// lane returns the lane the rule applies to.
+func (r TransitRule) lane() (pricing.Lane, error) {
+	from, err := region.Parse(r.From)
+	if err != nil {
+		return pricing.Lane{}, err
+	}
+	to, err := region.Parse(r.To)
+	if err != nil {
+		return pricing.Lane{}, err
+	}
+	if r.Domestic && from != to {
+		return pricing.Lane{}, fmt.Errorf("domestic rule from %q to %q", r.From, r.To)
+	}
+	return pricing.Lane{From: from, To: to, Domestic: r.Domestic}, nil
+}
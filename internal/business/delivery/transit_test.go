@@ -0,0 +1,112 @@
+package delivery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johanronkko/quote-service/internal/business/pricing"
+	"github.com/johanronkko/quote-service/internal/business/region"
+	"github.com/matryer/is"
+)
+
+func TestLoadTransit(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		is := is.New(t)
+
+		transit, err := LoadTransit(filepath.Join("..", "..", "..", "config", "transit.json"))
+		is.NoErr(err)
+		is.Equal(transit.CutoffHour, 15)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Transit string
+			ErrMsg  string
+		}{
+			{"not json", `banana`, "decoding transit rules"},
+			{"unknown field", `{"banana": 1}`, "decoding transit rules"},
+			{"bad rule", `{"rules": [{"from": "mars", "to": "nordic", "transit_days": 1}]}`, "rules[0]"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				path := filepath.Join(t.TempDir(), "transit.json")
+				err := os.WriteFile(path, []byte(tc.Transit), 0600)
+				is.NoErr(err)
+
+				_, err = LoadTransit(path)
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), tc.ErrMsg))
+			})
+		}
+	})
+}
+
+func TestTransitValidate(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Transit Transit
+		ErrMsg  string
+	}{
+		{"bad cutoff hour", Transit{CutoffHour: 25}, "cutoff_hour"},
+		{"unknown region", Transit{Rules: []TransitRule{{From: "nordic", To: "mars"}}}, "rules[0]: unknown region"},
+		{"domestic between regions", Transit{Rules: []TransitRule{{From: "nordic", To: "within_eu", Domestic: true}}}, "rules[0]: domestic rule"},
+		{"negative transit days", Transit{Rules: []TransitRule{{From: "nordic", To: "nordic", TransitDays: -1}}}, "rules[0]: negative transit days"},
+		{"duplicate", Transit{Rules: []TransitRule{{From: "nordic", To: "nordic"}, {From: "nordic", To: "nordic"}}}, "rules[1]: duplicate of rules[0]"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			err := tc.Transit.Validate()
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tc.ErrMsg))
+		})
+	}
+}
+
+func TestTransitDays(t *testing.T) {
+	transit := Transit{
+		Rules: []TransitRule{
+			{From: "nordic", To: "nordic", Domestic: true, ServiceLevel: pricing.ServiceExpress, TransitDays: 0},
+			{From: "nordic", To: "nordic", Domestic: true, TransitDays: 1},
+			{From: "nordic", To: "nordic", TransitDays: 2},
+			{From: "nordic", To: "nordic", ServiceLevel: pricing.ServiceEconomy, TransitDays: 4},
+		},
+	}
+
+	standard := pricing.ServiceLevel{Name: pricing.ServiceStandard, TransitDays: 9}
+	economy := pricing.ServiceLevel{Name: pricing.ServiceEconomy, TransitDays: 9}
+	express := pricing.ServiceLevel{Name: pricing.ServiceExpress, TransitDays: 9}
+	domestic := pricing.Lane{From: region.Nordic, To: region.Nordic, Domestic: true}
+	nordic := pricing.Lane{From: region.Nordic, To: region.Nordic}
+
+	cases := []struct {
+		Name string
+
+		Lane  pricing.Lane
+		Level pricing.ServiceLevel
+
+		Want int
+	}{
+		{"domestic service level", domestic, express, 0},
+		{"domestic any service level", domestic, standard, 1},
+		{"domestic over region service level", domestic, economy, 1},
+		{"region lane", nordic, standard, 2},
+		{"region lane service level", nordic, economy, 4},
+		{"domestic rule does not apply to region lane", nordic, express, 2},
+		{"no rule", pricing.Lane{From: region.OutsideEU, To: region.Nordic}, standard, 9},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(transit.TransitDays(tc.Lane, tc.Level), tc.Want)
+		})
+	}
+}
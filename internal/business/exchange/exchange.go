@@ -0,0 +1,114 @@
+// Package exchange provides exchange rates for converting amounts between
+// currencies.
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/money"
+)
+
+var (
+	// ErrUnsupportedCurrency occurs when no exchange rate is available for a
+	// currency.
+	ErrUnsupportedCurrency = errors.New("currency not supported")
+)
+
+// Provider provides exchange rates.
+type Provider interface {
+	// Rate returns the rate to convert from currency from to currency to.
+	// Returns ErrUnsupportedCurrency if either currency is not supported.
+	Rate(ctx context.Context, from, to string) (Rate, error)
+}
+
+// Rate is the price of one unit of currency From in currency To, as published
+// at Timestamp.
+type Rate struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Value     float64   `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Convert converts m to the To currency of the rate. The result is rounded to
+// the nearest minor unit. Errors if m is not in the From currency.
+func (r Rate) Convert(m money.Money) (money.Money, error) {
+	if m.Currency != r.From {
+		return money.Money{}, fmt.Errorf("converting %s with rate from %s: %w", m.Currency, r.From, money.ErrCurrencyMismatch)
+	}
+	return money.FromMajor(m.Major()*r.Value, r.To), nil
+}
+
+// File is a Provider with rates read from a file, for use in tests and when
+// running offline. Rates are given relative to a base currency, and rates
+// between two other currencies are derived through the base currency.
+type File struct {
+	// Base is the ISO 4217 code of the currency the rates are relative to.
+	Base string `json:"base"`
+	// Timestamp is when the rates were published.
+	Timestamp time.Time `json:"timestamp"`
+	// Rates maps currency codes to the price of one unit of Base in that
+	// currency.
+	Rates map[string]float64 `json:"rates"`
+}
+
+// LoadFile reads the exchange rate file at path.
+func LoadFile(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return File{}, fmt.Errorf("decoding rates: %w", err)
+	}
+	if f.Base == "" {
+		return File{}, errors.New("no base currency")
+	}
+	for currency, v := range f.Rates {
+		if v <= 0 {
+			return File{}, fmt.Errorf("rate for %s must be positive", currency)
+		}
+	}
+	return f, nil
+}
+
+// Rate implements Provider.
+func (f File) Rate(ctx context.Context, from, to string) (Rate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	fromBase, err := f.perBase(from)
+	if err != nil {
+		return Rate{}, err
+	}
+	toBase, err := f.perBase(to)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	return Rate{
+		From:      from,
+		To:        to,
+		Value:     toBase / fromBase,
+		Timestamp: f.Timestamp,
+	}, nil
+}
+
+// perBase returns the price of one unit of the base currency in currency.
+func (f File) perBase(currency string) (float64, error) {
+	if currency == strings.ToUpper(f.Base) {
+		return 1, nil
+	}
+	v, ok := f.Rates[currency]
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", currency, ErrUnsupportedCurrency)
+	}
+	return v, nil
+}
@@ -0,0 +1,90 @@
+package exchange_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/johanronkko/quote-service/internal/business/exchange"
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/matryer/is"
+)
+
+func TestFileRate(t *testing.T) {
+	ts := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	f := File{
+		Base:      "SEK",
+		Timestamp: ts,
+		Rates: map[string]float64{
+			"EUR": 0.1,
+			"USD": 0.125,
+		},
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			From string
+			To   string
+
+			Want float64
+		}{
+			{"same currency", "SEK", "SEK", 1},
+			{"from base", "SEK", "EUR", 0.1},
+			{"to base", "EUR", "SEK", 10},
+			{"cross rate", "EUR", "USD", 1.25},
+			{"lowercase", "sek", "usd", 0.125},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				got, err := f.Rate(context.Background(), tc.From, tc.To)
+				is.NoErr(err)
+				is.Equal(got.Value, tc.Want)
+				is.Equal(got.Timestamp, ts)
+			})
+		}
+	})
+
+	t.Run("unsupported currency", func(t *testing.T) {
+		is := is.New(t)
+
+		_, err := f.Rate(context.Background(), "SEK", "NOK")
+		is.True(errors.Is(err, ErrUnsupportedCurrency))
+	})
+}
+
+func TestRateConvert(t *testing.T) {
+	is := is.New(t)
+
+	r := Rate{From: "SEK", To: "EUR", Value: 0.0976}
+
+	got, err := r.Convert(money.New(1250_00, "SEK"))
+	is.NoErr(err)
+	is.Equal(got, money.New(122_00, "EUR"))
+
+	got, err = Rate{From: "SEK", To: "JPY", Value: 12.345}.Convert(money.New(100_00, "SEK"))
+	is.NoErr(err)
+	is.Equal(got, money.New(1235, "JPY"))
+
+	_, err = r.Convert(money.New(1, "USD"))
+	is.True(errors.Is(err, money.ErrCurrencyMismatch))
+}
+
+func TestLoadFile(t *testing.T) {
+	is := is.New(t)
+
+	f, err := LoadFile(filepath.Join("..", "..", "..", "config", "rates.json"))
+	is.NoErr(err)
+	is.Equal(f.Base, "SEK")
+
+	path := filepath.Join(t.TempDir(), "rates.json")
+	is.NoErr(os.WriteFile(path, []byte(`{"base": "SEK", "rates": {"EUR": -1}}`), 0600))
+	_, err = LoadFile(path)
+	is.True(err != nil)
+}
@@ -0,0 +1,110 @@
+// Package geocode locates postal addresses by their coordinates.
+package geocode
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/johanronkko/quote-service/internal/business/address"
+)
+
+var (
+	// ErrNotFound occurs when an address cannot be located.
+	ErrNotFound = errors.New("address not found")
+)
+
+// Geocoder locates addresses.
+type Geocoder interface {
+	// Geocode returns the coordinates of address a. Returns ErrNotFound if a
+	// cannot be located.
+	Geocode(ctx context.Context, a address.Address) (Point, error)
+}
+
+// Point is a location as WGS 84 latitude and longitude in degrees.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// File is a Geocoder with the centroids of postal code areas read from a file,
+// for use in tests and when running offline. Addresses are located at the
+// centroid of the area of their postal code, so addresses without a postal
+// code cannot be located.
+type File struct {
+	// Centroids maps country codes to the centroids of the postal code areas
+	// of the country by postal code. Postal codes are in upper case without
+	// spaces and dashes, and may be the prefix shared by the postal codes of
+	// an area, e.g. 981 for 981 xx. Addresses are located by the longest
+	// postal code their own postal code starts with.
+	Centroids map[string]map[string]Point
+}
+
+// LoadFile reads the CSV formatted centroid file at path, which has a header
+// and the columns country_code, postal_code, lat and lon.
+func LoadFile(path string) (File, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return File{}, err
+	}
+	defer r.Close()
+
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return File{}, fmt.Errorf("decoding centroids: %w", err)
+	}
+	if len(records) == 0 {
+		return File{}, errors.New("no header")
+	}
+
+	f := File{Centroids: make(map[string]map[string]Point)}
+	for i, rec := range records[1:] {
+		line := i + 2
+		if len(rec) != 4 {
+			return File{}, fmt.Errorf("line %d: expected 4 fields, got %d", line, len(rec))
+		}
+		country, code := strings.ToUpper(rec[0]), compact(rec[1])
+		if country == "" || code == "" {
+			return File{}, fmt.Errorf("line %d: country code and postal code are required", line)
+		}
+		var p Point
+		if p.Lat, err = strconv.ParseFloat(rec[2], 64); err != nil || p.Lat < -90 || p.Lat > 90 {
+			return File{}, fmt.Errorf("line %d: invalid latitude %q", line, rec[2])
+		}
+		if p.Lon, err = strconv.ParseFloat(rec[3], 64); err != nil || p.Lon < -180 || p.Lon > 180 {
+			return File{}, fmt.Errorf("line %d: invalid longitude %q", line, rec[3])
+		}
+		if f.Centroids[country] == nil {
+			f.Centroids[country] = make(map[string]Point)
+		}
+		f.Centroids[country][code] = p
+	}
+	return f, nil
+}
+
+// Geocode implements Geocoder.
+func (f File) Geocode(ctx context.Context, a address.Address) (Point, error) {
+	centroids := f.Centroids[strings.ToUpper(a.CountryCode)]
+	code := compact(a.PostalCode)
+	for n := len(code); n > 0; n-- {
+		if p, ok := centroids[code[:n]]; ok {
+			return p, nil
+		}
+	}
+	return Point{}, fmt.Errorf("postal code %q in %s: %w", a.PostalCode, a.CountryCode, ErrNotFound)
+}
+
+// compact returns postal code s in upper case without spaces and dashes.
+func compact(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
@@ -0,0 +1,85 @@
+package geocode_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/johanronkko/quote-service/internal/business/address"
+	. "github.com/johanronkko/quote-service/internal/business/geocode"
+	"github.com/matryer/is"
+)
+
+func TestFileGeocode(t *testing.T) {
+	gothenburg := Point{Lat: 57.7, Lon: 11.97}
+	central := Point{Lat: 57.7089, Lon: 11.9746}
+	oslo := Point{Lat: 59.9139, Lon: 10.7522}
+	f := File{
+		Centroids: map[string]map[string]Point{
+			"SE": {"41": gothenburg, "41124": central},
+			"NO": {"0": oslo},
+			"GB": {"SW1A": {Lat: 51.5014, Lon: -0.1419}},
+		},
+	}
+
+	t.Run("located", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Address address.Address
+
+			Want Point
+		}{
+			{"exact postal code", address.Address{PostalCode: "411 24", CountryCode: "SE"}, central},
+			{"postal code area", address.Address{PostalCode: "411 38", CountryCode: "SE"}, gothenburg},
+			{"without space", address.Address{PostalCode: "41138", CountryCode: "SE"}, gothenburg},
+			{"lowercase", address.Address{PostalCode: "sw1a 1aa", CountryCode: "gb"}, Point{Lat: 51.5014, Lon: -0.1419}},
+			{"single digit area", address.Address{PostalCode: "0150", CountryCode: "NO"}, oslo},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				got, err := f.Geocode(context.Background(), tc.Address)
+				is.NoErr(err)
+				is.Equal(got, tc.Want)
+			})
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Address address.Address
+		}{
+			{"unknown postal code", address.Address{PostalCode: "981 31", CountryCode: "SE"}},
+			{"unknown country", address.Address{PostalCode: "0150", CountryCode: "DK"}},
+			{"no postal code", address.Address{City: "Göteborg", CountryCode: "SE"}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				_, err := f.Geocode(context.Background(), tc.Address)
+				is.True(errors.Is(err, ErrNotFound))
+			})
+		}
+	})
+}
+
+func TestLoadFile(t *testing.T) {
+	is := is.New(t)
+
+	f, err := LoadFile(filepath.Join("..", "..", "..", "config", "postal-codes.csv"))
+	is.NoErr(err)
+	_, err = f.Geocode(context.Background(), address.Address{PostalCode: "411 24", CountryCode: "SE"})
+	is.NoErr(err)
+
+	path := filepath.Join(t.TempDir(), "postal-codes.csv")
+	is.NoErr(os.WriteFile(path, []byte("country_code,postal_code,lat,lon\nSE,411 24,91,11.97\n"), 0600))
+	_, err = LoadFile(path)
+	is.True(err != nil)
+}
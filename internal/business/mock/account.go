@@ -0,0 +1,27 @@
+package mock
+
+import (
+	"github.com/johanronkko/quote-service/internal/business/data/account"
+	"golang.org/x/net/context"
+)
+
+// Account is a mock implementation of account.Account.
+type Account struct {
+	AuthenticateCall struct {
+		Recieves struct {
+			Ctx    context.Context
+			APIKey string
+		}
+		Returns struct {
+			Info account.Info
+			Err  error
+		}
+	}
+}
+
+// Authenticate mocks the Authenticate func of account.Account.
+func (a *Account) Authenticate(ctx context.Context, apiKey string) (account.Info, error) {
+	a.AuthenticateCall.Recieves.Ctx = ctx
+	a.AuthenticateCall.Recieves.APIKey = apiKey
+	return a.AuthenticateCall.Returns.Info, a.AuthenticateCall.Returns.Err
+}
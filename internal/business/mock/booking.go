@@ -0,0 +1,61 @@
+package mock
+
+import (
+	"github.com/johanronkko/quote-service/internal/business/data/booking"
+	"golang.org/x/net/context"
+)
+
+// Booking is a mock implementation of booking.Booking.
+type Booking struct {
+	QueryCall struct {
+		Recieves struct {
+			Ctx       context.Context
+			AccountID string
+		}
+		Returns struct {
+			Bookings []booking.Info
+			Err      error
+		}
+	}
+	QueryByIDCall struct {
+		Recieves struct {
+			Ctx context.Context
+			ID  string
+		}
+		Returns struct {
+			Info booking.Info
+			Err  error
+		}
+	}
+	CreateCall struct {
+		Recieves struct {
+			Ctx context.Context
+			Nb  booking.NewBooking
+		}
+		Returns struct {
+			Info booking.Info
+			Err  error
+		}
+	}
+}
+
+// Query mocks the Query func of booking.Booking.
+func (b *Booking) Query(ctx context.Context, accountID string) ([]booking.Info, error) {
+	b.QueryCall.Recieves.Ctx = ctx
+	b.QueryCall.Recieves.AccountID = accountID
+	return b.QueryCall.Returns.Bookings, b.QueryCall.Returns.Err
+}
+
+// QueryByID mocks the QueryByID func of booking.Booking.
+func (b *Booking) QueryByID(ctx context.Context, id string) (booking.Info, error) {
+	b.QueryByIDCall.Recieves.Ctx = ctx
+	b.QueryByIDCall.Recieves.ID = id
+	return b.QueryByIDCall.Returns.Info, b.QueryByIDCall.Returns.Err
+}
+
+// Create mocks the Create func of booking.Booking.
+func (b *Booking) Create(ctx context.Context, nb booking.NewBooking) (booking.Info, error) {
+	b.CreateCall.Recieves.Ctx = ctx
+	b.CreateCall.Recieves.Nb = nb
+	return b.CreateCall.Returns.Info, b.CreateCall.Returns.Err
+}
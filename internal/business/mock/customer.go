@@ -0,0 +1,101 @@
+package mock
+
+import (
+	"github.com/johanronkko/quote-service/internal/business/data/customer"
+	"golang.org/x/net/context"
+)
+
+// Customer is a mock implementation of customer.Customer.
+type Customer struct {
+	QueryCall struct {
+		Recieves struct {
+			Ctx    context.Context
+			Filter customer.Filter
+		}
+		Returns struct {
+			Customers  []customer.Info
+			NextCursor string
+			Err        error
+		}
+	}
+	QueryByIDCall struct {
+		Recieves struct {
+			Ctx       context.Context
+			AccountID string
+			ID        string
+		}
+		Returns struct {
+			Info customer.Info
+			Err  error
+		}
+	}
+	CreateCall struct {
+		Recieves struct {
+			Ctx context.Context
+			Nc  customer.NewCustomer
+		}
+		Returns struct {
+			Info customer.Info
+			Err  error
+		}
+	}
+	UpdateCall struct {
+		Recieves struct {
+			Ctx context.Context
+			ID  string
+			Nc  customer.NewCustomer
+		}
+		Returns struct {
+			Info customer.Info
+			Err  error
+		}
+	}
+	DeleteCall struct {
+		Recieves struct {
+			Ctx       context.Context
+			AccountID string
+			ID        string
+		}
+		Returns struct {
+			Err error
+		}
+	}
+}
+
+// Query mocks the Query func of customer.Customer.
+func (c *Customer) Query(ctx context.Context, filter customer.Filter) ([]customer.Info, string, error) {
+	c.QueryCall.Recieves.Ctx = ctx
+	c.QueryCall.Recieves.Filter = filter
+	return c.QueryCall.Returns.Customers, c.QueryCall.Returns.NextCursor, c.QueryCall.Returns.Err
+}
+
+// QueryByID mocks the QueryByID func of customer.Customer.
+func (c *Customer) QueryByID(ctx context.Context, accountID, id string) (customer.Info, error) {
+	c.QueryByIDCall.Recieves.Ctx = ctx
+	c.QueryByIDCall.Recieves.AccountID = accountID
+	c.QueryByIDCall.Recieves.ID = id
+	return c.QueryByIDCall.Returns.Info, c.QueryByIDCall.Returns.Err
+}
+
+// Create mocks the Create func of customer.Customer.
+func (c *Customer) Create(ctx context.Context, nc customer.NewCustomer) (customer.Info, error) {
+	c.CreateCall.Recieves.Ctx = ctx
+	c.CreateCall.Recieves.Nc = nc
+	return c.CreateCall.Returns.Info, c.CreateCall.Returns.Err
+}
+
+// Update mocks the Update func of customer.Customer.
+func (c *Customer) Update(ctx context.Context, id string, nc customer.NewCustomer) (customer.Info, error) {
+	c.UpdateCall.Recieves.Ctx = ctx
+	c.UpdateCall.Recieves.ID = id
+	c.UpdateCall.Recieves.Nc = nc
+	return c.UpdateCall.Returns.Info, c.UpdateCall.Returns.Err
+}
+
+// Delete mocks the Delete func of customer.Customer.
+func (c *Customer) Delete(ctx context.Context, accountID, id string) error {
+	c.DeleteCall.Recieves.Ctx = ctx
+	c.DeleteCall.Recieves.AccountID = accountID
+	c.DeleteCall.Recieves.ID = id
+	return c.DeleteCall.Returns.Err
+}
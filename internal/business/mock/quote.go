@@ -9,11 +9,13 @@ import (
 type Quote struct {
 	QueryCall struct {
 		Recieves struct {
-			Ctx context.Context
+			Ctx    context.Context
+			Filter quote.Filter
 		}
 		Returns struct {
-			Quotes []quote.Info
-			Err    error
+			Quotes     []quote.Info
+			NextCursor string
+			Err        error
 		}
 	}
 	QueryByIDCall struct {
@@ -26,6 +28,18 @@ type Quote struct {
 			Err  error
 		}
 	}
+	SearchCall struct {
+		Recieves struct {
+			Ctx       context.Context
+			AccountID string
+			Text      string
+			Limit     int
+		}
+		Returns struct {
+			Matches []quote.Match
+			Err     error
+		}
+	}
 	CreateCall struct {
 		Recieves struct {
 			Ctx context.Context
@@ -36,11 +50,43 @@ type Quote struct {
 			Err  error
 		}
 	}
+	OffersCall struct {
+		Recieves struct {
+			Ctx context.Context
+			Nq  quote.NewQuote
+		}
+		Returns struct {
+			Offers []quote.Offer
+			Err    error
+		}
+	}
+	AcceptCall struct {
+		Recieves struct {
+			Ctx context.Context
+			ID  string
+		}
+		Returns struct {
+			Info quote.Info
+			Err  error
+		}
+	}
+	RejectCall struct {
+		Recieves struct {
+			Ctx context.Context
+			ID  string
+		}
+		Returns struct {
+			Info quote.Info
+			Err  error
+		}
+	}
 }
 
 // Query mocks the Query func of quote.Quote.
-func (q *Quote) Query(ctx context.Context) ([]quote.Info, error) {
-	return q.QueryCall.Returns.Quotes, q.QueryCall.Returns.Err
+func (q *Quote) Query(ctx context.Context, filter quote.Filter) ([]quote.Info, string, error) {
+	q.QueryCall.Recieves.Ctx = ctx
+	q.QueryCall.Recieves.Filter = filter
+	return q.QueryCall.Returns.Quotes, q.QueryCall.Returns.NextCursor, q.QueryCall.Returns.Err
 }
 
 // QueryByID mocks the QueryByID func of quote.Quote.
@@ -50,9 +96,39 @@ func (q *Quote) QueryByID(ctx context.Context, id string) (quote.Info, error) {
 	return q.QueryByIDCall.Returns.Info, q.QueryByIDCall.Returns.Err
 }
 
+// Search mocks the Search func of quote.Quote.
+func (q *Quote) Search(ctx context.Context, accountID, text string, limit int) ([]quote.Match, error) {
+	q.SearchCall.Recieves.Ctx = ctx
+	q.SearchCall.Recieves.AccountID = accountID
+	q.SearchCall.Recieves.Text = text
+	q.SearchCall.Recieves.Limit = limit
+	return q.SearchCall.Returns.Matches, q.SearchCall.Returns.Err
+}
+
 // Create mocks the Create func of quote.Quote.
 func (q *Quote) Create(ctx context.Context, nq quote.NewQuote) (quote.Info, error) {
 	q.QueryByIDCall.Recieves.Ctx = ctx
 	q.CreateCall.Recieves.Nq = nq
 	return q.CreateCall.Returns.Info, q.CreateCall.Returns.Err
 }
+
+// Offers mocks the Offers func of quote.Quote.
+func (q *Quote) Offers(ctx context.Context, nq quote.NewQuote) ([]quote.Offer, error) {
+	q.OffersCall.Recieves.Ctx = ctx
+	q.OffersCall.Recieves.Nq = nq
+	return q.OffersCall.Returns.Offers, q.OffersCall.Returns.Err
+}
+
+// Accept mocks the Accept func of quote.Quote.
+func (q *Quote) Accept(ctx context.Context, id string) (quote.Info, error) {
+	q.AcceptCall.Recieves.Ctx = ctx
+	q.AcceptCall.Recieves.ID = id
+	return q.AcceptCall.Returns.Info, q.AcceptCall.Returns.Err
+}
+
+// Reject mocks the Reject func of quote.Quote.
+func (q *Quote) Reject(ctx context.Context, id string) (quote.Info, error) {
+	q.RejectCall.Recieves.Ctx = ctx
+	q.RejectCall.Recieves.ID = id
+	return q.RejectCall.Returns.Info, q.RejectCall.Returns.Err
+}
@@ -0,0 +1,83 @@
+// Package money provides an exact representation of monetary amounts.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	// ErrCurrencyMismatch occurs when combining amounts of different currencies.
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money is an amount in the minor unit of its currency, e.g. öre for SEK or
+// cents for EUR, together with the ISO 4217 currency code.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// New returns amount minor units of currency.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// FromMajor converts an amount in the major unit of currency, e.g. kronor or
+// euros, to Money. The amount is rounded to the nearest minor unit.
+func FromMajor(v float64, currency string) Money {
+	scale := math.Pow10(Exponent(currency))
+	return New(int64(math.Round(v*scale)), currency)
+}
+
+// Exponent returns the number of digits of the minor unit of currency.
+func Exponent(currency string) int {
+	if e, ok := exponents[strings.ToUpper(currency)]; ok {
+		return e
+	}
+	return 2
+}
+
+// exponents contains the currencies which minor unit doesn't have two digits.
+var exponents = map[string]int{
+	"BHD": 3, "CLP": 0, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0,
+	"KWD": 3, "LYD": 3, "OMR": 3, "PYG": 0, "TND": 3, "UGX": 0, "VND": 0,
+}
+
+// Add returns the sum of m and o. Errors if the currencies differ.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("adding %s to %s: %w", o.Currency, m.Currency, ErrCurrencyMismatch)
+	}
+	return New(m.Amount+o.Amount, m.Currency), nil
+}
+
+// Sub returns the difference between m and o. Errors if the currencies differ.
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+// Neg returns m with the sign of the amount flipped.
+func (m Money) Neg() Money {
+	return New(-m.Amount, m.Currency)
+}
+
+// Mul returns m multiplied with f, rounded half away from zero to the nearest
+// minor unit.
+func (m Money) Mul(f float64) Money {
+	return New(int64(math.Round(float64(m.Amount)*f)), m.Currency)
+}
+
+// Major returns the amount in the major unit of the currency. Use for display
+// purposes only.
+func (m Money) Major() float64 {
+	return float64(m.Amount) / math.Pow10(Exponent(m.Currency))
+}
+
+// String returns the amount in major units followed by the currency, e.g.
+// "1250.00 SEK".
+func (m Money) String() string {
+	return fmt.Sprintf("%.*f %s", Exponent(m.Currency), m.Major(), m.Currency)
+}
@@ -0,0 +1,61 @@
+package money_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/matryer/is"
+)
+
+func TestFromMajor(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Major    float64
+		Currency string
+
+		Want Money
+	}{
+		{"whole", 1250, "SEK", New(125000, "SEK")},
+		{"fraction", 0.1 + 0.2, "EUR", New(30, "EUR")},
+		{"round half away from zero", 10.005, "USD", New(1001, "USD")},
+		{"no minor unit", 1250, "JPY", New(1250, "JPY")},
+		{"three digit minor unit", 1.2345, "KWD", New(1235, "KWD")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(FromMajor(tc.Major, tc.Currency), tc.Want)
+		})
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	is := is.New(t)
+
+	a := New(10050, "SEK")
+	b := New(950, "SEK")
+
+	sum, err := a.Add(b)
+	is.NoErr(err)
+	is.Equal(sum, New(11000, "SEK"))
+
+	diff, err := a.Sub(b)
+	is.NoErr(err)
+	is.Equal(diff, New(9100, "SEK"))
+
+	is.Equal(a.Mul(1.5), New(15075, "SEK"))
+	is.Equal(New(1, "SEK").Mul(0.5), New(1, "SEK"))
+	is.Equal(New(-1, "SEK").Mul(0.5), New(-1, "SEK"))
+
+	_, err = a.Add(New(1, "EUR"))
+	is.True(errors.Is(err, ErrCurrencyMismatch))
+}
+
+func TestString(t *testing.T) {
+	is := is.New(t)
+	is.Equal(New(125000, "SEK").String(), "1250.00 SEK")
+	is.Equal(New(-5, "EUR").String(), "-0.05 EUR")
+	is.Equal(New(1250, "JPY").String(), "1250 JPY")
+}
@@ -0,0 +1,118 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Contract is the negotiated rate of a customer account. Discount is the
+// percentage off the list price on every lane. Lanes override the discount,
+// or the tariff the list price is calculated with, on specific lanes.
+// Contracts are versioned, and a new version is added when the rates are
+// renegotiated.
+type Contract struct {
+	ID       string         `json:"id"`
+	Version  int            `json:"version"`
+	Discount float64        `json:"discount"`
+	Lanes    []ContractLane `json:"lanes"`
+}
+
+// ContractLane is the negotiated rate on a lane. Discount is the percentage
+// off the list price on the lane. If Tariff is set, the list price on the lane
+// is calculated with it instead of the tariff of the calculator. A domestic
+// contract lane applies to shipments within a single country of the From
+// region, in which case To must equal From.
+type ContractLane struct {
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Domestic bool    `json:"domestic"`
+	Discount float64 `json:"discount"`
+	Tariff   *Tariff `json:"tariff,omitempty"`
+}
+
+// Validate checks that the contract is well formed.
+func (c Contract) Validate() error {
+	if c.ID == "" {
+		return errors.New("missing id")
+	}
+	if c.Version < 1 {
+		return fmt.Errorf("version %d is not positive", c.Version)
+	}
+	if err := validDiscount(c.Discount); err != nil {
+		return err
+	}
+	seen := map[Lane]int{}
+	for i, cl := range c.Lanes {
+		l, err := parseLane(cl.From, cl.To, cl.Domestic)
+		if err != nil {
+			return fmt.Errorf("lanes[%d]: %w", i, err)
+		}
+		if err := validDiscount(cl.Discount); err != nil {
+			return fmt.Errorf("lanes[%d]: %w", i, err)
+		}
+		if cl.Tariff != nil {
+			if err := cl.Tariff.Validate(); err != nil {
+				return fmt.Errorf("lanes[%d]: tariff: %w", i, err)
+			}
+		}
+		if j, ok := seen[l]; ok {
+			return fmt.Errorf("lanes[%d]: duplicate of lanes[%d] %s", i, j, l)
+		}
+		seen[l] = i
+	}
+	return nil
+}
+
+// validDiscount checks that discount is a percentage below 100.
+func validDiscount(discount float64) error {
+	if discount < 0 || discount >= 100 {
+		return fmt.Errorf("discount %v is not within 0-100", discount)
+	}
+	return nil
+}
+
+// rate returns the discount and override tariff of the contract on lane.
+// Domestic lanes without a domestic contract lane use the contract lane within
+// the region. Lanes without a contract lane get the discount of the contract.
+func (c Contract) rate(lane Lane) (float64, *Tariff) {
+	candidates := []Lane{lane}
+	if lane.Domestic {
+		candidates = append(candidates, Lane{From: lane.From, To: lane.To})
+	}
+	for _, candidate := range candidates {
+		for _, cl := range c.Lanes {
+			if l, err := parseLane(cl.From, cl.To, cl.Domestic); err == nil && l == candidate {
+				return cl.Discount, cl.Tariff
+			}
+		}
+	}
+	return c.Discount, nil
+}
+
+// Apply returns cost discounted by the discount of the contract on the lane
+// of cost, with the discount added to the breakdown. The tariffs of contract
+// lanes are not applied, as cost is already rated.
+func (c Contract) Apply(cost Cost) (Cost, error) {
+	discount, _ := c.rate(cost.Lane)
+	if discount <= 0 {
+		return cost, nil
+	}
+	discounted := cost.Amount.Mul(1 - discount/100)
+	amount, err := discounted.Sub(cost.Amount)
+	if err != nil {
+		return Cost{}, err
+	}
+	cost.Amount = discounted
+	cost.Breakdown = append(cost.Breakdown, Item{
+		Kind:        ItemDiscount,
+		Description: "contract " + c.String(),
+		Factor:      discount / 100,
+		Amount:      amount,
+	})
+	return cost, nil
+}
+
+// String returns the name of the contract version, e.g. "acme v2".
+func (c Contract) String() string {
+	return fmt.Sprintf("%s v%d", c.ID, c.Version)
+}
@@ -0,0 +1,92 @@
+package pricing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/matryer/is"
+)
+
+func TestContractValidate(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Contract Contract
+		ErrMsg   string
+	}{
+		{"missing id", Contract{Version: 1}, "missing id"},
+		{"zero version", Contract{ID: "acme"}, "version 0 is not positive"},
+		{"negative discount", Contract{ID: "acme", Version: 1, Discount: -1}, "discount -1 is not within 0-100"},
+		{"full discount", Contract{ID: "acme", Version: 1, Discount: 100}, "discount 100 is not within 0-100"},
+		{"unknown region", Contract{ID: "acme", Version: 1, Lanes: []ContractLane{{From: "mars", To: "nordic"}}}, "lanes[0]"},
+		{"bad lane discount", Contract{ID: "acme", Version: 1, Lanes: []ContractLane{{From: "nordic", To: "nordic", Discount: 120}}}, "lanes[0]: discount 120"},
+		{"bad tariff", Contract{ID: "acme", Version: 1, Lanes: []ContractLane{{From: "nordic", To: "nordic", Tariff: &Tariff{Currency: "SEK"}}}}, "lanes[0]: tariff: no weight brackets"},
+		{"duplicate lane", Contract{ID: "acme", Version: 1, Lanes: []ContractLane{{From: "nordic", To: "nordic"}, {From: "nordic", To: "nordic", Discount: 5}}}, "lanes[1]: duplicate of lanes[0]"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			err := tc.Contract.Validate()
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tc.ErrMsg))
+		})
+	}
+}
+
+func TestTariffContract(t *testing.T) {
+	override := FlatRate()
+	override.Brackets = []Bracket{{MinWeight: 0, MaxWeight: 1000, Price: 50}}
+
+	contract := &Contract{
+		ID:       "acme",
+		Version:  2,
+		Discount: 10,
+		Lanes: []ContractLane{
+			{From: "nordic", To: "nordic", Discount: 20},
+			{From: "within_eu", To: "within_eu", Domestic: true},
+			{From: "within_eu", To: "nordic", Tariff: &override},
+		},
+	}
+	if err := contract.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Name string
+
+		From     string
+		To       string
+		Contract *Contract
+
+		WantAmount   int64
+		WantDiscount bool
+	}{
+		{"no contract", "us", "se", nil, 250_00, false},
+		{"contract discount", "us", "se", contract, 225_00, true},
+		{"lane discount", "se", "no", contract, 80_00, true},
+		{"domestic falls back to region lane", "se", "se", contract, 80_00, true},
+		{"domestic lane without discount", "fr", "fr", contract, 150_00, false},
+		{"override tariff", "fr", "se", contract, 75_00, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			s := Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, tc.From), To: country(t, tc.To), Contract: tc.Contract}
+			got, err := FlatRate().ShipmentCost(s)
+			is.NoErr(err)
+			is.Equal(got.Amount, money.New(tc.WantAmount, "SEK"))
+
+			last := got.Breakdown[len(got.Breakdown)-1]
+			is.Equal(last.Kind == ItemDiscount, tc.WantDiscount)
+			if tc.WantDiscount {
+				is.Equal(last.Description, "contract acme v2")
+			}
+			total, err := got.Breakdown.Total()
+			is.NoErr(err)
+			is.Equal(total, got.Amount)
+		})
+	}
+}
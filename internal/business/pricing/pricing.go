@@ -0,0 +1,242 @@
+// Package pricing contains functionality for calculating the cost of shipments.
+package pricing
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/johanronkko/quote-service/internal/business/region"
+)
+
+var (
+	// ErrInvalidWeight occurs when a package weight is not within any of the
+	// supported weight classes.
+	ErrInvalidWeight = errors.New("invalid weight")
+
+	// ErrTariffNotValid occurs when a tariff is used outside of its validity
+	// period.
+	ErrTariffNotValid = errors.New("tariff not valid")
+
+	// ErrNoParcels occurs when a shipment has no parcels.
+	ErrNoParcels = errors.New("no parcels")
+
+	// ErrInvalidQuantity occurs when the quantity of a parcel is not positive.
+	ErrInvalidQuantity = errors.New("invalid quantity")
+
+	// ErrUnsupportedServiceLevel occurs when a shipment is priced at a service
+	// level that is not offered.
+	ErrUnsupportedServiceLevel = errors.New("unsupported service level")
+)
+
+// ShipmentCostCalculator calculates the cost of a shipment. Implementations
+// can be swapped to support e.g. tariff tables per carrier or customer
+// contract.
+type ShipmentCostCalculator interface {
+	// ShipmentCost returns the cost of shipping s.
+	ShipmentCost(s Shipment) (Cost, error)
+	// ServiceLevels returns the service levels offered, from slowest to
+	// fastest.
+	ServiceLevels() []ServiceLevel
+}
+
+// ContextCalculator is a ShipmentCostCalculator whose calculation can be
+// canceled, e.g. because it asks other services for the cost.
+type ContextCalculator interface {
+	ShipmentCostCalculator
+	// ShipmentCostContext returns the cost of shipping s. The calculation is
+	// canceled when ctx is done.
+	ShipmentCostContext(ctx context.Context, s Shipment) (Cost, error)
+}
+
+// ShipmentCostContext returns the cost of shipping s calculated by calc, and
+// cancels the calculation when ctx is done if calc is a ContextCalculator.
+func ShipmentCostContext(ctx context.Context, calc ShipmentCostCalculator, s Shipment) (Cost, error) {
+	if c, ok := calc.(ContextCalculator); ok {
+		return c.ShipmentCostContext(ctx, s)
+	}
+	return calc.ShipmentCost(s)
+}
+
+// Shipment contains the information needed to price a shipment.
+type Shipment struct {
+	// Parcels are the packages of the shipment.
+	Parcels []Parcel
+	// From is the origin and To the destination country of the shipment.
+	From region.Country
+	To   region.Country
+	// VATNumber is the VAT number of the sender if the sender is a business.
+	VATNumber string
+	// ServiceLevel is the name of the requested service level. Empty means
+	// ServiceStandard.
+	ServiceLevel string
+	// ToPostalCode is the postal code of the receiver, if known.
+	ToPostalCode string
+	// DangerousGoods is set if the shipment contains dangerous goods.
+	DangerousGoods bool
+	// Contract is the contract of the customer, if any.
+	Contract *Contract
+}
+
+// Lane returns the lane of the shipment.
+func (s Shipment) Lane() Lane {
+	return Lane{
+		From:     s.From.Region(),
+		To:       s.To.Region(),
+		Domestic: strings.EqualFold(s.From.Alpha2, s.To.Alpha2),
+	}
+}
+
+// Weight returns the total actual weight of the parcels of the shipment in kg.
+func (s Shipment) Weight() int {
+	var weight int
+	for _, p := range s.Parcels {
+		weight += p.Weight * p.Quantity
+	}
+	return weight
+}
+
+// Parcel is one or more identical packages of a shipment.
+type Parcel struct {
+	// Weight of a single package in kg.
+	Weight int
+	// Dimensions of a single package. Zero if unknown.
+	Dimensions Dimensions
+	// Quantity is the number of packages.
+	Quantity int
+}
+
+// Dimensions are the outer dimensions of a package in cm.
+type Dimensions struct {
+	Length int
+	Width  int
+	Height int
+}
+
+// Volume returns the volume in cubic cm.
+func (d Dimensions) Volume() int {
+	return d.Length * d.Width * d.Height
+}
+
+// VolumetricWeight returns the volumetric weight in kg of a package with
+// dimensions d, rounded up to the nearest kg. divisor is the number of cubic
+// cm per kg, commonly 5000. Returns 0 if divisor is not positive.
+func VolumetricWeight(d Dimensions, divisor int) int {
+	if divisor <= 0 {
+		return 0
+	}
+	return (d.Volume() + divisor - 1) / divisor
+}
+
+// Cost is the calculated cost of a shipment.
+type Cost struct {
+	Amount money.Money
+	// ChargeableWeight is the total weight in kg the shipment was priced by.
+	// The chargeable weight of a package is the greater of its actual and
+	// volumetric weight.
+	ChargeableWeight int
+	// ChargeableWeights are the chargeable weights in kg of a single package
+	// of each parcel, in the order of Shipment.Parcels.
+	ChargeableWeights []int
+	// Lane is the lane of the shipment. The tariff may not have a rate for
+	// it, in which case the factor in Breakdown tells which rate was used.
+	Lane Lane
+	// ServiceLevel is the service level the shipment was priced at.
+	ServiceLevel ServiceLevel
+	// Breakdown explains how Amount was derived. The amounts of its items add
+	// up to Amount.
+	Breakdown Breakdown
+	// Carrier is the name of the carrier that rated the shipment, if rated by
+	// a carrier.
+	Carrier string
+}
+
+// Kinds of breakdown items.
+const (
+	ItemBase      = "base"      // Price of the weight class of a parcel.
+	ItemFee       = "fee"       // Fixed price per shipment.
+	ItemFactor    = "factor"    // Lane, region or service level multiplier.
+	ItemSurcharge = "surcharge" // Additional charge, e.g. fuel.
+	ItemDiscount  = "discount"  // Reduction, e.g. contract rate.
+	ItemTax       = "tax"       // Tax, e.g. VAT.
+)
+
+// Item is a line in a cost breakdown. Amount is what the item adds to the
+// total cost and is negative for discounts. Factor is set for items that are
+// derived by multiplication.
+type Item struct {
+	Kind        string      `json:"kind"`
+	Description string      `json:"description"`
+	Factor      float64     `json:"factor,omitempty"`
+	Amount      money.Money `json:"amount"`
+}
+
+// Breakdown is an itemised explanation of a shipment cost.
+type Breakdown []Item
+
+// Total returns the sum of the amounts of the items. Errors if the items are
+// in different currencies.
+func (b Breakdown) Total() (money.Money, error) {
+	if len(b) == 0 {
+		return money.Money{}, nil
+	}
+	total := money.New(0, b[0].Amount.Currency)
+	for _, item := range b {
+		var err error
+		if total, err = total.Add(item.Amount); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Lane is a route between an origin and a destination region. A domestic lane
+// is a route within a single country.
+type Lane struct {
+	From     region.Region
+	To       region.Region
+	Domestic bool
+}
+
+// String returns the name of the lane, e.g. "nordic:outside_eu" or
+// "nordic:domestic".
+func (l Lane) String() string {
+	if l.Domestic {
+		return l.From.String() + ":domestic"
+	}
+	return l.From.String() + ":" + l.To.String()
+}
+
+// FlatRate returns the built-in tariff. Shipment cost is calculated as the
+// multiplication of the sum of the packages' weight class factors and a region
+// factor of the sender. It has no lane specific multipliers. Packages are
+// charged by the greater of their actual and volumetric weight, using a
+// divisor of 5000.
+//
+// We have four classes of weight: small (0 - 10kg), 100sek; medium (10 - 25kg),
+// 300sek; large (25 - 50kg), 500sek; huge (50 - 1000kg), 2000sek. If region
+// is Nordic, weight class is multiplied with 1, within EU with 1.5 and
+// outside EU with 2.5.
+func FlatRate() Tariff {
+	return Tariff{
+		Currency:          "SEK",
+		VolumetricDivisor: 5000,
+		Brackets: []Bracket{
+			{MinWeight: 0, MaxWeight: 10, Price: 100},
+			{MinWeight: 11, MaxWeight: 25, Price: 300},
+			{MinWeight: 26, MaxWeight: 50, Price: 500},
+			{MinWeight: 51, MaxWeight: 1000, Price: 2000},
+		},
+		Regions: map[string]float64{
+			"nordic":     1,
+			"within_eu":  1.5,
+			"outside_eu": 2.5,
+		},
+		Levels: []ServiceLevel{
+			{Name: ServiceEconomy, Multiplier: 0.8, TransitDays: 5},
+			{Name: ServiceStandard, Multiplier: 1, TransitDays: 3},
+			{Name: ServiceExpress, Multiplier: 1.5, TransitDays: 1},
+		},
+	}
+}
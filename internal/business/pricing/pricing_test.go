@@ -0,0 +1,110 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/johanronkko/quote-service/internal/business/region"
+	"github.com/matryer/is"
+)
+
+// countries is the catalogue used to look up countries in tests.
+var countries = region.NewCache(nil)
+
+func country(tb testing.TB, ccode string) region.Country {
+	c, err := countries.Country(ccode)
+	if err != nil {
+		tb.Fatalf("country %q: %s", ccode, err)
+	}
+	return c
+}
+
+func TestCalcShipmentCost(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			CountryCode string
+			Weight      int
+
+			Want money.Money
+		}{
+			{"small nordic", "no", 0, money.New(100_00, "SEK")},
+			{"medium nordic", "se", 11, money.New(300_00, "SEK")},
+			{"large nordic", "dk", 26, money.New(500_00, "SEK")},
+			{"huge nordic", "fi", 51, money.New(2000_00, "SEK")},
+
+			{"small within EU", "fr", 10, money.New(150_00, "SEK")},
+			{"medium within EU", "de", 25, money.New(450_00, "SEK")},
+			{"large within EU", "lt", 50, money.New(750_00, "SEK")},
+			{"huge within EU", "cz", 1000, money.New(3000_00, "SEK")},
+
+			{"small outide EU", "us", 7, money.New(250_00, "SEK")},
+			{"medium outide EU", "ca", 18, money.New(750_00, "SEK")},
+			{"large outide EU", "br", 29, money.New(1250_00, "SEK")},
+			{"huge outide EU", "jp", 777, money.New(5000_00, "SEK")},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+				s := Shipment{
+					Parcels: []Parcel{{Weight: tc.Weight, Quantity: 1}},
+					From:    country(t, tc.CountryCode),
+					To:      country(t, "jp"),
+				}
+				got, err := FlatRate().ShipmentCost(s)
+				is.NoErr(err)
+				is.Equal(got.Amount, tc.Want)
+				total, err := got.Breakdown.Total()
+				is.NoErr(err)
+				is.Equal(total, tc.Want)
+			})
+		}
+	})
+
+	t.Run("invalid weight", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Weight int
+		}{
+			{"below 0", -1},
+			{"above 1000", 1001},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+				s := Shipment{
+					Parcels: []Parcel{{Weight: tc.Weight, Quantity: 1}},
+					From:    country(t, "se"),
+					To:      country(t, "se"),
+				}
+				_, err := FlatRate().ShipmentCost(s)
+				is.Equal(err, ErrInvalidWeight)
+			})
+		}
+	})
+}
+
+func TestShipmentLane(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		From string
+		To   string
+
+		Want string
+	}{
+		{"domestic", "se", "SE", "nordic:domestic"},
+		{"within region", "se", "no", "nordic:nordic"},
+		{"nordic to outside EU", "se", "jp", "nordic:outside_eu"},
+		{"outside EU to within EU", "us", "fr", "outside_eu:within_eu"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+			s := Shipment{From: country(t, tc.From), To: country(t, tc.To)}
+			is.Equal(s.Lane().String(), tc.Want)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package pricing
+
+import "github.com/johanronkko/quote-service/internal/business/money"
+
+// Promotion is a campaign discount on the cost of a shipment, identified by a
+// promo code. The discount is either Percent off the cost, or a flat Amount in
+// the major unit of the currency of the cost. The discount never exceeds the
+// cost.
+type Promotion struct {
+	Code    string
+	Percent float64
+	Amount  float64
+}
+
+// Apply returns cost with the discount of the promotion added to its
+// breakdown.
+func (p Promotion) Apply(cost Cost) (Cost, error) {
+	item := Item{Kind: ItemDiscount, Description: "promotion " + p.Code}
+	if p.Percent > 0 {
+		item.Factor = p.Percent / 100
+		item.Amount = cost.Amount.Mul(item.Factor).Neg()
+	} else {
+		item.Amount = money.FromMajor(p.Amount, cost.Amount.Currency).Neg()
+	}
+	if -item.Amount.Amount > cost.Amount.Amount {
+		item.Amount = cost.Amount.Neg()
+	}
+
+	amount, err := cost.Amount.Add(item.Amount)
+	if err != nil {
+		return Cost{}, err
+	}
+	cost.Amount = amount
+	cost.Breakdown = append(cost.Breakdown, item)
+	return cost, nil
+}
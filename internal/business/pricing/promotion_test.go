@@ -0,0 +1,44 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/matryer/is"
+)
+
+func TestPromotionApply(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Promotion Promotion
+
+		WantAmount   int64
+		WantDiscount int64
+	}{
+		{"percent", Promotion{Code: "SPRING25", Percent: 25}, 150_00, -50_00},
+		{"amount", Promotion{Code: "WELCOME", Amount: 49.5}, 150_50, -49_50},
+		{"amount above cost", Promotion{Code: "FREE", Amount: 1000}, 0, -200_00},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			s := Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 2}}, From: country(t, "se"), To: country(t, "no")}
+			cost, err := FlatRate().ShipmentCost(s)
+			is.NoErr(err)
+
+			got, err := tc.Promotion.Apply(cost)
+			is.NoErr(err)
+			is.Equal(got.Amount, money.New(tc.WantAmount, "SEK"))
+
+			item := got.Breakdown[len(got.Breakdown)-1]
+			is.Equal(item.Kind, ItemDiscount)
+			is.Equal(item.Description, "promotion "+tc.Promotion.Code)
+			is.Equal(item.Amount, money.New(tc.WantDiscount, "SEK"))
+			total, err := got.Breakdown.Total()
+			is.NoErr(err)
+			is.Equal(total, got.Amount)
+		})
+	}
+}
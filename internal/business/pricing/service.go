@@ -0,0 +1,45 @@
+package pricing
+
+import "fmt"
+
+// Service levels of a shipment, from slowest to fastest.
+const (
+	ServiceEconomy  = "economy"
+	ServiceStandard = "standard"
+	ServiceExpress  = "express"
+)
+
+// serviceLevels are the known service levels in order of speed.
+var serviceLevels = []string{ServiceEconomy, ServiceStandard, ServiceExpress}
+
+// ServiceLevel is the pricing of a delivery speed. The cost of a shipment at
+// the level is its lane adjusted cost multiplied with Multiplier plus Fee. Fee
+// is in the major unit of the tariff currency. TransitDays is the estimated
+// number of business days from pickup to delivery.
+type ServiceLevel struct {
+	Name        string  `json:"name"`
+	Multiplier  float64 `json:"multiplier"`
+	Fee         float64 `json:"fee"`
+	TransitDays int     `json:"transit_days"`
+}
+
+// validate checks that the service level is well formed.
+func (sl ServiceLevel) validate() error {
+	known := false
+	for _, name := range serviceLevels {
+		known = known || sl.Name == name
+	}
+	if !known {
+		return fmt.Errorf("unknown service level %q", sl.Name)
+	}
+	if sl.Multiplier <= 0 {
+		return fmt.Errorf("%s: multiplier must be positive", sl.Name)
+	}
+	if sl.Fee < 0 {
+		return fmt.Errorf("%s: negative fee %v", sl.Name, sl.Fee)
+	}
+	if sl.TransitDays < 0 {
+		return fmt.Errorf("%s: negative transit days %d", sl.Name, sl.TransitDays)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/johanronkko/quote-service/internal/business/money"
+)
+
+var alpha2Regex = regexp.MustCompile("^[A-Za-z]{2}$")
+
+// SurchargeRules are the surcharges added on top of the cost of a shipment,
+// e.g. fuel, remote area, dangerous goods and oversize surcharges.
+type SurchargeRules struct {
+	Rules []SurchargeRule `json:"rules"`
+}
+
+// SurchargeRule adds a surcharge to shipments matching When. The surcharge is
+// either a flat Amount, in the major unit of the currency of the shipment cost,
+// or a Percent of the shipment cost before surcharges.
+type SurchargeRule struct {
+	Name    string             `json:"name"`
+	When    SurchargeCondition `json:"when"`
+	Amount  float64            `json:"amount"`
+	Percent float64            `json:"percent"`
+}
+
+// SurchargeCondition selects the shipments a surcharge applies to. A shipment
+// matches if it meets every condition that is set, so an empty condition
+// matches every shipment.
+type SurchargeCondition struct {
+	// WeightAbove matches shipments with a total chargeable weight above the
+	// given kg.
+	WeightAbove int `json:"weight_above"`
+	// DimensionAbove matches shipments with a package side longer than the
+	// given cm.
+	DimensionAbove int `json:"dimension_above"`
+	// DangerousGoods matches shipments of dangerous goods.
+	DangerousGoods bool `json:"dangerous_goods"`
+	// Countries matches shipments to any of the countries with the given
+	// alpha-2 codes.
+	Countries []string `json:"countries"`
+	// PostalCodes matches shipments to any of the postal codes, e.g. a list of
+	// remote areas. A code ending with "*" matches every postal code with that
+	// prefix. Spaces are ignored and letters are case-insensitive.
+	PostalCodes []string `json:"postal_codes"`
+}
+
+// LoadSurchargeRules reads and validates the surcharge rules file at path.
+func LoadSurchargeRules(path string) (SurchargeRules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return SurchargeRules{}, err
+	}
+	defer f.Close()
+
+	var sr SurchargeRules
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&sr); err != nil {
+		return SurchargeRules{}, fmt.Errorf("decoding surcharge rules: %w", err)
+	}
+	if err := sr.Validate(); err != nil {
+		return SurchargeRules{}, err
+	}
+	return sr, nil
+}
+
+// Validate checks that the surcharge rules are well formed. Every rule must
+// have a unique name and exactly one of a positive amount and percent.
+func (sr SurchargeRules) Validate() error {
+	seen := map[string]int{}
+	for i, r := range sr.Rules {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		if j, ok := seen[r.Name]; ok {
+			return fmt.Errorf("rules[%d]: duplicate of rules[%d] %s", i, j, r.Name)
+		}
+		seen[r.Name] = i
+	}
+	return nil
+}
+
+// validate checks that the rule is well formed.
+func (r SurchargeRule) validate() error {
+	if r.Name == "" {
+		return errors.New("missing name")
+	}
+	if r.Amount < 0 || r.Percent < 0 {
+		return fmt.Errorf("%s: negative surcharge", r.Name)
+	}
+	if (r.Amount > 0) == (r.Percent > 0) {
+		return fmt.Errorf("%s: exactly one of amount and percent must be set", r.Name)
+	}
+	c := r.When
+	if c.WeightAbove < 0 {
+		return fmt.Errorf("%s: negative weight_above %d kg", r.Name, c.WeightAbove)
+	}
+	if c.DimensionAbove < 0 {
+		return fmt.Errorf("%s: negative dimension_above %d cm", r.Name, c.DimensionAbove)
+	}
+	for _, ccode := range c.Countries {
+		if !alpha2Regex.MatchString(ccode) {
+			return fmt.Errorf("%s: %q is not an alpha-2 country code", r.Name, ccode)
+		}
+	}
+	for _, code := range c.PostalCodes {
+		if p := postalCode(code); p == "" || p == "*" || strings.Contains(strings.TrimSuffix(p, "*"), "*") {
+			return fmt.Errorf("%s: invalid postal code %q", r.Name, code)
+		}
+	}
+	return nil
+}
+
+// matches reports whether shipment s, with a total chargeable weight of
+// weight kg, meets the condition.
+func (c SurchargeCondition) matches(s Shipment, weight int) bool {
+	if c.WeightAbove > 0 && weight <= c.WeightAbove {
+		return false
+	}
+	if c.DimensionAbove > 0 && longestSide(s.Parcels) <= c.DimensionAbove {
+		return false
+	}
+	if c.DangerousGoods && !s.DangerousGoods {
+		return false
+	}
+	if len(c.Countries) > 0 {
+		found := false
+		for _, ccode := range c.Countries {
+			found = found || strings.EqualFold(ccode, s.To.Alpha2)
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(c.PostalCodes) > 0 {
+		to, found := postalCode(s.ToPostalCode), false
+		for _, code := range c.PostalCodes {
+			code = postalCode(code)
+			if prefix := strings.TrimSuffix(code, "*"); prefix != code {
+				found = found || (to != "" && strings.HasPrefix(to, prefix))
+			} else {
+				found = found || to == code
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// longestSide returns the longest side in cm of the packages of parcels.
+func longestSide(parcels []Parcel) int {
+	var longest int
+	for _, p := range parcels {
+		for _, side := range []int{p.Dimensions.Length, p.Dimensions.Width, p.Dimensions.Height} {
+			if side > longest {
+				longest = side
+			}
+		}
+	}
+	return longest
+}
+
+// postalCode normalises a postal code for comparison.
+func postalCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(code, " ", ""))
+}
+
+// Surcharges is a ShipmentCostCalculator adding the surcharges of a set of
+// rules to the cost calculated by another calculator. The rules can be
+// replaced while in use, which makes changing surcharges a configuration
+// change rather than a restart.
+type Surcharges struct {
+	calc ShipmentCostCalculator
+
+	mu    sync.RWMutex
+	rules SurchargeRules
+}
+
+// NewSurcharges constructs Surcharges adding the surcharges of rules to the
+// cost calculated by calc. rules are expected to be valid, e.g. loaded with
+// LoadSurchargeRules.
+func NewSurcharges(calc ShipmentCostCalculator, rules SurchargeRules) *Surcharges {
+	return &Surcharges{calc: calc, rules: rules}
+}
+
+// Set replaces the surcharge rules. rules are expected to be valid.
+func (s *Surcharges) Set(rules SurchargeRules) {
+	s.mu.Lock()
+	s.rules = rules
+	s.mu.Unlock()
+}
+
+// ShipmentCost implements ShipmentCostCalculator. Every matching rule adds a
+// surcharge item to the breakdown, in the order of the rules. Percentages are
+// of the cost before surcharges.
+func (s *Surcharges) ShipmentCost(sh Shipment) (Cost, error) {
+	return s.ShipmentCostContext(context.Background(), sh)
+}
+
+// ShipmentCostContext implements ContextCalculator. The calculation of the
+// cost before surcharges is canceled when ctx is done. See ShipmentCost.
+func (s *Surcharges) ShipmentCostContext(ctx context.Context, sh Shipment) (Cost, error) {
+	cost, err := ShipmentCostContext(ctx, s.calc, sh)
+	if err != nil {
+		return Cost{}, err
+	}
+
+	s.mu.RLock()
+	rules := s.rules.Rules
+	s.mu.RUnlock()
+
+	base := cost.Amount
+	for _, r := range rules {
+		if !r.When.matches(sh, cost.ChargeableWeight) {
+			continue
+		}
+		item := Item{Kind: ItemSurcharge, Description: r.Name}
+		if r.Percent > 0 {
+			item.Factor = r.Percent / 100
+			item.Amount = base.Mul(item.Factor)
+		} else {
+			item.Amount = money.FromMajor(r.Amount, base.Currency)
+		}
+		if cost.Amount, err = cost.Amount.Add(item.Amount); err != nil {
+			return Cost{}, err
+		}
+		cost.Breakdown = append(cost.Breakdown, item)
+	}
+	return cost, nil
+}
+
+// ServiceLevels implements ShipmentCostCalculator.
+func (s *Surcharges) ServiceLevels() []ServiceLevel {
+	return s.calc.ServiceLevels()
+}
@@ -0,0 +1,180 @@
+package pricing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/matryer/is"
+)
+
+func TestLoadSurchargeRules(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		is := is.New(t)
+
+		sr, err := LoadSurchargeRules(filepath.Join("..", "..", "..", "config", "surcharges.json"))
+		is.NoErr(err)
+		is.True(len(sr.Rules) > 0)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Rules  string
+			ErrMsg string
+		}{
+			{"not json", `banana`, "decoding surcharge rules"},
+			{"unknown field", `{"rules": [], "banana": 1}`, "decoding surcharge rules"},
+			{"missing name", `{"rules": [{"percent": 5}]}`, "rules[0]: missing name"},
+			{"negative", `{"rules": [{"name": "fuel", "percent": -5}]}`, "rules[0]: fuel: negative surcharge"},
+			{"no surcharge", `{"rules": [{"name": "fuel"}]}`, "rules[0]: fuel: exactly one"},
+			{"amount and percent", `{"rules": [{"name": "fuel", "amount": 10, "percent": 5}]}`, "rules[0]: fuel: exactly one"},
+			{"negative weight", `{"rules": [{"name": "heavy", "when": {"weight_above": -1}, "amount": 10}]}`, "rules[0]: heavy: negative weight_above"},
+			{"negative dimension", `{"rules": [{"name": "oversize", "when": {"dimension_above": -1}, "amount": 10}]}`, "rules[0]: oversize: negative dimension_above"},
+			{"bad country", `{"rules": [{"name": "remote", "when": {"countries": ["NOR"]}, "amount": 10}]}`, "rules[0]: remote: \"NOR\" is not"},
+			{"bad postal code", `{"rules": [{"name": "remote", "when": {"postal_codes": ["9*1"]}, "amount": 10}]}`, "rules[0]: remote: invalid postal code"},
+			{"wildcard postal code", `{"rules": [{"name": "remote", "when": {"postal_codes": ["*"]}, "amount": 10}]}`, "rules[0]: remote: invalid postal code"},
+			{"duplicate", `{"rules": [{"name": "fuel", "percent": 5}, {"name": "fuel", "percent": 6}]}`, "rules[1]: duplicate of rules[0]"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				path := filepath.Join(t.TempDir(), "surcharges.json")
+				err := os.WriteFile(path, []byte(tc.Rules), 0600)
+				is.NoErr(err)
+
+				_, err = LoadSurchargeRules(path)
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), tc.ErrMsg))
+			})
+		}
+	})
+}
+
+func TestSurcharges(t *testing.T) {
+	rules := SurchargeRules{Rules: []SurchargeRule{
+		{Name: "fuel", Percent: 10},
+		{Name: "remote area", When: SurchargeCondition{Countries: []string{"NO"}, PostalCodes: []string{"9*", "8099"}}, Amount: 150},
+		{Name: "dangerous goods", When: SurchargeCondition{DangerousGoods: true}, Amount: 500},
+		{Name: "oversize", When: SurchargeCondition{DimensionAbove: 120}, Amount: 250},
+		{Name: "heavy", When: SurchargeCondition{WeightAbove: 50}, Percent: 5},
+	}}
+
+	cases := []struct {
+		Name string
+
+		Shipment Shipment
+
+		WantItems  []string
+		WantAmount int64
+	}{
+		{
+			"fuel only",
+			Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "se"), To: country(t, "no"), ToPostalCode: "0150"},
+			[]string{"fuel"},
+			100_00 + 10_00,
+		},
+		{
+			"remote area prefix",
+			Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "se"), To: country(t, "no"), ToPostalCode: "9170"},
+			[]string{"fuel", "remote area"},
+			100_00 + 10_00 + 150_00,
+		},
+		{
+			"remote area exact",
+			Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "se"), To: country(t, "no"), ToPostalCode: "80 99"},
+			[]string{"fuel", "remote area"},
+			100_00 + 10_00 + 150_00,
+		},
+		{
+			"remote postal code in other country",
+			Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "no"), To: country(t, "se"), ToPostalCode: "9170"},
+			[]string{"fuel"},
+			100_00 + 10_00,
+		},
+		{
+			"no postal code",
+			Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "se"), To: country(t, "no")},
+			[]string{"fuel"},
+			100_00 + 10_00,
+		},
+		{
+			"dangerous goods",
+			Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "se"), To: country(t, "se"), DangerousGoods: true},
+			[]string{"fuel", "dangerous goods"},
+			100_00 + 10_00 + 500_00,
+		},
+		{
+			"oversize",
+			Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 2}, {Weight: 5, Dimensions: Dimensions{Length: 121, Width: 10, Height: 10}, Quantity: 1}}, From: country(t, "se"), To: country(t, "se")},
+			[]string{"fuel", "oversize"},
+			300_00 + 30_00 + 250_00,
+		},
+		{
+			"not oversize",
+			Shipment{Parcels: []Parcel{{Weight: 5, Dimensions: Dimensions{Length: 120, Width: 10, Height: 10}, Quantity: 1}}, From: country(t, "se"), To: country(t, "se")},
+			[]string{"fuel"},
+			100_00 + 10_00,
+		},
+		{
+			"heavy",
+			Shipment{Parcels: []Parcel{{Weight: 51, Quantity: 1}}, From: country(t, "se"), To: country(t, "se")},
+			[]string{"fuel", "heavy"},
+			2000_00 + 200_00 + 100_00,
+		},
+		{
+			"not heavy",
+			Shipment{Parcels: []Parcel{{Weight: 25, Quantity: 2}}, From: country(t, "se"), To: country(t, "se")},
+			[]string{"fuel"},
+			600_00 + 60_00,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			calc := NewSurcharges(FlatRate(), rules)
+			got, err := calc.ShipmentCost(tc.Shipment)
+			is.NoErr(err)
+			is.Equal(got.Amount, money.New(tc.WantAmount, "SEK"))
+
+			var items []string
+			for _, item := range got.Breakdown {
+				if item.Kind == ItemSurcharge {
+					items = append(items, item.Description)
+				}
+			}
+			is.Equal(items, tc.WantItems)
+
+			total, err := got.Breakdown.Total()
+			is.NoErr(err)
+			is.Equal(total, got.Amount)
+		})
+	}
+}
+
+func TestSurchargesSet(t *testing.T) {
+	is := is.New(t)
+
+	calc := NewSurcharges(FlatRate(), SurchargeRules{})
+	is.Equal(calc.ServiceLevels(), FlatRate().ServiceLevels())
+
+	s := Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "se"), To: country(t, "se")}
+	got, err := calc.ShipmentCost(s)
+	is.NoErr(err)
+	is.Equal(got.Amount, money.New(100_00, "SEK"))
+
+	calc.Set(SurchargeRules{Rules: []SurchargeRule{{Name: "fuel", Amount: 25}}})
+	got, err = calc.ShipmentCost(s)
+	is.NoErr(err)
+	is.Equal(got.Amount, money.New(125_00, "SEK"))
+
+	// Errors of the underlying calculator are passed on.
+	s.Parcels = nil
+	_, err = calc.ShipmentCost(s)
+	is.Equal(err, ErrNoParcels)
+}
@@ -0,0 +1,397 @@
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/johanronkko/quote-service/internal/business/region"
+)
+
+var currencyRegex = regexp.MustCompile("^[A-Z]{3}$")
+
+// Tariff is a data driven ShipmentCostCalculator. The cost of a shipment is the
+// sum of the prices of the weight brackets its packages fall within and the
+// shipment fee, multiplied with the multiplier of the lane of the shipment and
+// adjusted by the requested service level. Lanes without a multiplier fall back
+// to the multiplier of the region of the sender. Packages with dimensions fall
+// within a bracket by the greater of their actual and volumetric weight.
+type Tariff struct {
+	// Currency is the ISO 4217 currency code of all prices in the tariff.
+	Currency string `json:"currency"`
+	// ValidFrom and ValidUntil define the period the tariff can be used. A
+	// zero ValidUntil means the tariff is valid indefinitely.
+	ValidFrom  time.Time `json:"valid_from"`
+	ValidUntil time.Time `json:"valid_until"`
+	// Brackets are the weight classes of the tariff, ordered by weight.
+	Brackets []Bracket `json:"brackets"`
+	// Regions maps region names, as returned by region.Region.String, to their
+	// price multiplier.
+	Regions map[string]float64 `json:"regions"`
+	// Lanes are multipliers for routes between an origin and a destination
+	// region, including domestic routes.
+	Lanes []LaneRate `json:"lanes"`
+	// VolumetricDivisor is the number of cubic cm per kg used to calculate
+	// the volumetric weight of a package. Zero disables volumetric weight.
+	VolumetricDivisor int `json:"volumetric_divisor"`
+	// ShipmentFee is a fixed price per shipment, on top of the prices of the
+	// packages, in the major unit of the tariff currency.
+	ShipmentFee float64 `json:"shipment_fee"`
+	// Levels are the offered service levels ordered from slowest to fastest,
+	// i.e. by non-increasing transit days.
+	// A tariff without service levels only offers ServiceStandard, without
+	// adjusting the cost.
+	Levels []ServiceLevel `json:"service_levels"`
+}
+
+// LaneRate is the price multiplier of a lane. A domestic lane rate applies to
+// shipments within a single country of the From region, in which case To must
+// equal From.
+type LaneRate struct {
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	Domestic   bool    `json:"domestic"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// Bracket is a weight class of a tariff. A package falls within the bracket if
+// its weight is within [MinWeight, MaxWeight] kg. Price is in the major unit
+// of the tariff currency, e.g. kronor, and is rounded to the nearest minor
+// unit when used.
+type Bracket struct {
+	MinWeight int     `json:"min_weight"`
+	MaxWeight int     `json:"max_weight"`
+	Price     float64 `json:"price"`
+}
+
+// LoadTariff reads and validates the tariff file at path.
+func LoadTariff(path string) (Tariff, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Tariff{}, err
+	}
+	defer f.Close()
+
+	var t Tariff
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&t); err != nil {
+		return Tariff{}, fmt.Errorf("decoding tariff: %w", err)
+	}
+	if err := t.Validate(); err != nil {
+		return Tariff{}, err
+	}
+	return t, nil
+}
+
+// Validate checks that the tariff is well formed. Brackets must be ordered,
+// contiguous and non-overlapping, and every region must have a multiplier.
+func (t Tariff) Validate() error {
+	if !currencyRegex.MatchString(t.Currency) {
+		return fmt.Errorf("currency %q is not an ISO 4217 currency code", t.Currency)
+	}
+	if !t.ValidUntil.IsZero() && !t.ValidUntil.After(t.ValidFrom) {
+		return errors.New("valid_until must be after valid_from")
+	}
+
+	if t.VolumetricDivisor < 0 {
+		return fmt.Errorf("volumetric_divisor: negative divisor %d", t.VolumetricDivisor)
+	}
+	if t.ShipmentFee < 0 {
+		return fmt.Errorf("shipment_fee: negative fee %v", t.ShipmentFee)
+	}
+
+	if len(t.Brackets) == 0 {
+		return errors.New("no weight brackets")
+	}
+	for i, b := range t.Brackets {
+		if b.MinWeight < 0 {
+			return fmt.Errorf("brackets[%d]: negative min weight %d kg", i, b.MinWeight)
+		}
+		if b.MaxWeight < b.MinWeight {
+			return fmt.Errorf("brackets[%d]: max weight %d kg is less than min weight %d kg", i, b.MaxWeight, b.MinWeight)
+		}
+		if b.Price < 0 {
+			return fmt.Errorf("brackets[%d]: negative price %v", i, b.Price)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := t.Brackets[i-1]
+		switch {
+		case b.MinWeight <= prev.MaxWeight:
+			return fmt.Errorf("brackets[%d]: %d-%d kg overlaps brackets[%d] %d-%d kg", i, b.MinWeight, b.MaxWeight, i-1, prev.MinWeight, prev.MaxWeight)
+		case b.MinWeight > prev.MaxWeight+1:
+			return fmt.Errorf("brackets[%d]: gap between brackets[%d] ending at %d kg and min weight %d kg", i, i-1, prev.MaxWeight, b.MinWeight)
+		}
+	}
+
+	for name, m := range t.Regions {
+		if _, err := region.Parse(name); err != nil {
+			return fmt.Errorf("regions: %w", err)
+		}
+		if m <= 0 {
+			return fmt.Errorf("regions: multiplier for %q must be positive", name)
+		}
+	}
+	for _, r := range region.All() {
+		if _, ok := t.Regions[r.String()]; !ok {
+			return fmt.Errorf("regions: missing multiplier for %q", r)
+		}
+	}
+
+	seen := map[Lane]int{}
+	for i, lr := range t.Lanes {
+		l, err := lr.lane()
+		if err != nil {
+			return fmt.Errorf("lanes[%d]: %w", i, err)
+		}
+		if lr.Multiplier <= 0 {
+			return fmt.Errorf("lanes[%d]: multiplier must be positive", i)
+		}
+		if j, ok := seen[l]; ok {
+			return fmt.Errorf("lanes[%d]: duplicate of lanes[%d] %s", i, j, l)
+		}
+		seen[l] = i
+	}
+
+	levels := map[string]int{}
+	for i, sl := range t.Levels {
+		if err := sl.validate(); err != nil {
+			return fmt.Errorf("service_levels[%d]: %w", i, err)
+		}
+		if j, ok := levels[sl.Name]; ok {
+			return fmt.Errorf("service_levels[%d]: duplicate of service_levels[%d] %s", i, j, sl.Name)
+		}
+		levels[sl.Name] = i
+		if i > 0 && sl.TransitDays > t.Levels[i-1].TransitDays {
+			return fmt.Errorf("service_levels[%d]: %s is slower than service_levels[%d] %s", i, sl.Name, i-1, t.Levels[i-1].Name)
+		}
+	}
+
+	return nil
+}
+
+// lane returns the lane the rate applies to.
+func (lr LaneRate) lane() (Lane, error) {
+	return parseLane(lr.From, lr.To, lr.Domestic)
+}
+
+// parseLane returns the lane between the regions named from and to.
+func parseLane(from, to string, domestic bool) (Lane, error) {
+	f, err := region.Parse(from)
+	if err != nil {
+		return Lane{}, err
+	}
+	t, err := region.Parse(to)
+	if err != nil {
+		return Lane{}, err
+	}
+	if domestic && f != t {
+		return Lane{}, fmt.Errorf("domestic lane from %q to %q", from, to)
+	}
+	return Lane{From: f, To: t, Domestic: domestic}, nil
+}
+
+// ShipmentCost implements ShipmentCostCalculator. Errors if the tariff is not
+// currently valid, if the region is not supported or if a package is not
+// within a weight bracket. Shipments under a contract are priced with the
+// tariff of the contract lane, if any, and discounted by the contract.
+func (t Tariff) ShipmentCost(s Shipment) (Cost, error) {
+	c := s.Contract
+	if c == nil {
+		return t.listCost(s)
+	}
+
+	_, tariff := c.rate(s.Lane())
+	if tariff == nil {
+		tariff = &t
+	}
+	cost, err := tariff.listCost(s)
+	if err != nil {
+		return Cost{}, err
+	}
+	return c.Apply(cost)
+}
+
+// listCost returns the cost of shipping s at the list price of the tariff.
+func (t Tariff) listCost(s Shipment) (Cost, error) {
+	now := time.Now()
+	if now.Before(t.ValidFrom) || (!t.ValidUntil.IsZero() && now.After(t.ValidUntil)) {
+		return Cost{}, ErrTariffNotValid
+	}
+	if len(s.Parcels) == 0 {
+		return Cost{}, ErrNoParcels
+	}
+	level, ok := t.serviceLevel(s.ServiceLevel)
+	if !ok {
+		return Cost{}, ErrUnsupportedServiceLevel
+	}
+	lane := s.Lane()
+	m, source, err := t.multiplier(lane)
+	if err != nil {
+		return Cost{}, err
+	}
+
+	cost := Cost{Lane: lane, ServiceLevel: level, ChargeableWeights: make([]int, len(s.Parcels))}
+	subtotal := money.New(0, t.Currency)
+	for i, p := range s.Parcels {
+		if p.Quantity < 1 {
+			return Cost{}, ErrInvalidQuantity
+		}
+		weight := t.chargeableWeight(p)
+		b, ok := t.bracket(weight)
+		if !ok {
+			return Cost{}, ErrInvalidWeight
+		}
+		cost.ChargeableWeights[i] = weight
+		cost.ChargeableWeight += weight * p.Quantity
+
+		class := fmt.Sprintf("weight class %d-%d kg", b.MinWeight, b.MaxWeight)
+		if weight != p.Weight {
+			class += fmt.Sprintf(", volumetric weight %d kg", weight)
+		}
+		if p.Quantity > 1 {
+			class = fmt.Sprintf("%d x %s", p.Quantity, class)
+		}
+		base := money.FromMajor(b.Price, t.Currency).Mul(float64(p.Quantity))
+		cost.Breakdown = append(cost.Breakdown, Item{
+			Kind:        ItemBase,
+			Description: class,
+			Amount:      base,
+		})
+		if subtotal, err = subtotal.Add(base); err != nil {
+			return Cost{}, err
+		}
+	}
+	if t.ShipmentFee > 0 {
+		fee := money.FromMajor(t.ShipmentFee, t.Currency)
+		cost.Breakdown = append(cost.Breakdown, Item{
+			Kind:        ItemFee,
+			Description: "shipment fee",
+			Amount:      fee,
+		})
+		if subtotal, err = subtotal.Add(fee); err != nil {
+			return Cost{}, err
+		}
+	}
+
+	cost.Amount = subtotal.Mul(m)
+	factor, err := cost.Amount.Sub(subtotal)
+	if err != nil {
+		return Cost{}, err
+	}
+	cost.Breakdown = append(cost.Breakdown, Item{
+		Kind:        ItemFactor,
+		Description: source,
+		Factor:      m,
+		Amount:      factor,
+	})
+
+	if level.Multiplier != 1 {
+		adjusted := cost.Amount.Mul(level.Multiplier)
+		factor, err := adjusted.Sub(cost.Amount)
+		if err != nil {
+			return Cost{}, err
+		}
+		cost.Amount = adjusted
+		cost.Breakdown = append(cost.Breakdown, Item{
+			Kind:        ItemFactor,
+			Description: "service " + level.Name,
+			Factor:      level.Multiplier,
+			Amount:      factor,
+		})
+	}
+	if level.Fee > 0 {
+		fee := money.FromMajor(level.Fee, t.Currency)
+		if cost.Amount, err = cost.Amount.Add(fee); err != nil {
+			return Cost{}, err
+		}
+		cost.Breakdown = append(cost.Breakdown, Item{
+			Kind:        ItemFee,
+			Description: level.Name + " fee",
+			Amount:      fee,
+		})
+	}
+	return cost, nil
+}
+
+// ServiceLevels implements ShipmentCostCalculator.
+func (t Tariff) ServiceLevels() []ServiceLevel {
+	if len(t.Levels) == 0 {
+		return []ServiceLevel{{Name: ServiceStandard, Multiplier: 1}}
+	}
+	return append([]ServiceLevel(nil), t.Levels...)
+}
+
+// serviceLevel returns the offered service level with name. An empty name
+// means ServiceStandard.
+func (t Tariff) serviceLevel(name string) (ServiceLevel, bool) {
+	if name == "" {
+		name = ServiceStandard
+	}
+	for _, sl := range t.ServiceLevels() {
+		if sl.Name == name {
+			return sl, true
+		}
+	}
+	return ServiceLevel{}, false
+}
+
+// chargeableWeight returns the greater of the actual and the volumetric weight
+// of a package of p. Packages without dimensions are charged by their actual
+// weight.
+func (t Tariff) chargeableWeight(p Parcel) int {
+	if p.Dimensions.Volume() <= 0 {
+		return p.Weight
+	}
+	if v := VolumetricWeight(p.Dimensions, t.VolumetricDivisor); v > p.Weight {
+		return v
+	}
+	return p.Weight
+}
+
+// multiplier returns the multiplier of lane together with a description of
+// the rate that was applied. Domestic lanes without a domestic rate use the
+// rate of the lane within the region, and the description tells when a rate
+// of another lane or the region of the sender was used instead.
+func (t Tariff) multiplier(lane Lane) (float64, string, error) {
+	if m, ok := t.laneRate(lane); ok {
+		return m, "lane " + lane.String(), nil
+	}
+	if lane.Domestic {
+		within := Lane{From: lane.From, To: lane.To}
+		if m, ok := t.laneRate(within); ok {
+			return m, fmt.Sprintf("lane %s, no rate for lane %s", within, lane), nil
+		}
+	}
+	m, ok := t.Regions[lane.From.String()]
+	if !ok {
+		return 0, "", fmt.Errorf("no multiplier for region %q", lane.From)
+	}
+	return m, fmt.Sprintf("region %s, no rate for lane %s", lane.From, lane), nil
+}
+
+// laneRate returns the multiplier of the rate of lane, if the tariff has one.
+func (t Tariff) laneRate(lane Lane) (float64, bool) {
+	for _, lr := range t.Lanes {
+		if l, err := lr.lane(); err == nil && l == lane {
+			return lr.Multiplier, true
+		}
+	}
+	return 0, false
+}
+
+// bracket returns the bracket weight falls within.
+func (t Tariff) bracket(weight int) (Bracket, bool) {
+	for _, b := range t.Brackets {
+		if weight >= b.MinWeight && weight <= b.MaxWeight {
+			return b, true
+		}
+	}
+	return Bracket{}, false
+}
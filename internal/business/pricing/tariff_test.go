@@ -0,0 +1,373 @@
+package pricing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/matryer/is"
+)
+
+func TestLoadTariff(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		is := is.New(t)
+
+		tariff, err := LoadTariff(filepath.Join("..", "..", "..", "config", "tariff.json"))
+		is.NoErr(err)
+		is.Equal(len(tariff.Brackets), 4)
+
+		// The shipped tariff has a rate for every lane, and domestic
+		// shipments are cheaper than shipments abroad.
+		codes := []string{"se", "no", "fr", "de", "us", "jp"}
+		for _, from := range codes {
+			for _, to := range codes {
+				s := Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, from), To: country(t, to)}
+				got, err := tariff.ShipmentCost(s)
+				is.NoErr(err)
+				is.Equal(got.Breakdown[1].Description, "lane "+got.Lane.String())
+			}
+		}
+		domestic, err := tariff.ShipmentCost(Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "se"), To: country(t, "se")})
+		is.NoErr(err)
+		abroad, err := tariff.ShipmentCost(Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "se"), To: country(t, "jp")})
+		is.NoErr(err)
+		is.True(domestic.Amount.Amount < abroad.Amount.Amount)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Tariff string
+			ErrMsg string
+		}{
+			{"not json", `banana`, "decoding tariff"},
+			{"unknown field", `{"currency": "SEK", "banana": 1}`, "decoding tariff"},
+			{"bad bracket", `{"currency": "SEK", "brackets": [{"min_weight": 10, "max_weight": 5, "price": 1}], "regions": {}}`, "brackets[0]"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				path := filepath.Join(t.TempDir(), "tariff.json")
+				err := os.WriteFile(path, []byte(tc.Tariff), 0600)
+				is.NoErr(err)
+
+				_, err = LoadTariff(path)
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), tc.ErrMsg))
+			})
+		}
+	})
+}
+
+func TestTariffValidate(t *testing.T) {
+	is := is.New(t)
+	is.NoErr(FlatRate().Validate())
+
+	cases := []struct {
+		Name string
+
+		Modify func(t *Tariff)
+		ErrMsg string
+	}{
+		{"bad currency", func(t *Tariff) { t.Currency = "sek" }, "currency"},
+		{"negative volumetric divisor", func(t *Tariff) { t.VolumetricDivisor = -1 }, "volumetric_divisor"},
+		{"negative shipment fee", func(t *Tariff) { t.ShipmentFee = -1 }, "shipment_fee"},
+		{"unknown service level", func(t *Tariff) { t.Levels[0].Name = "overnight" }, "service_levels[0]: unknown service level"},
+		{"zero service level multiplier", func(t *Tariff) { t.Levels[1].Multiplier = 0 }, "service_levels[1]: standard: multiplier"},
+		{"negative service level fee", func(t *Tariff) { t.Levels[2].Fee = -1 }, "service_levels[2]: express: negative fee"},
+		{"negative transit days", func(t *Tariff) { t.Levels[2].TransitDays = -1 }, "service_levels[2]: express: negative transit days"},
+		{"duplicate service level", func(t *Tariff) { t.Levels[2].Name = ServiceStandard }, "service_levels[2]: duplicate of service_levels[1]"},
+		{"service levels out of order", func(t *Tariff) { t.Levels[0].TransitDays = 2 }, "service_levels[1]: standard is slower than service_levels[0] economy"},
+		{"no brackets", func(t *Tariff) { t.Brackets = nil }, "no weight brackets"},
+		{"negative min weight", func(t *Tariff) { t.Brackets[0].MinWeight = -1 }, "brackets[0]: negative min weight"},
+		{"max below min", func(t *Tariff) { t.Brackets[1].MaxWeight = 5 }, "brackets[1]: max weight"},
+		{"negative price", func(t *Tariff) { t.Brackets[2].Price = -1 }, "brackets[2]: negative price"},
+		{"overlap", func(t *Tariff) { t.Brackets[2].MinWeight = 25 }, "brackets[2]: 25-50 kg overlaps brackets[1]"},
+		{"gap", func(t *Tariff) { t.Brackets[3].MinWeight = 52 }, "brackets[3]: gap"},
+		{"unknown region", func(t *Tariff) { t.Regions["mars"] = 10 }, "unknown region"},
+		{"missing region", func(t *Tariff) { delete(t.Regions, "nordic") }, "missing multiplier"},
+		{"zero multiplier", func(t *Tariff) { t.Regions["nordic"] = 0 }, "must be positive"},
+		{"unknown lane region", func(t *Tariff) { t.Lanes = []LaneRate{{From: "mars", To: "nordic", Multiplier: 1}} }, "lanes[0]: unknown region"},
+		{"domestic between regions", func(t *Tariff) {
+			t.Lanes = []LaneRate{{From: "nordic", To: "within_eu", Domestic: true, Multiplier: 1}}
+		}, "lanes[0]: domestic lane"},
+		{"zero lane multiplier", func(t *Tariff) { t.Lanes = []LaneRate{{From: "nordic", To: "nordic"}} }, "lanes[0]: multiplier"},
+		{"duplicate lane", func(t *Tariff) {
+			t.Lanes = []LaneRate{{From: "nordic", To: "nordic", Multiplier: 1}, {From: "nordic", To: "nordic", Multiplier: 2}}
+		}, "lanes[1]: duplicate of lanes[0]"},
+		{"validity", func(t *Tariff) { t.ValidFrom = time.Now(); t.ValidUntil = t.ValidFrom.Add(-time.Hour) }, "valid_until"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			tariff := FlatRate()
+			tc.Modify(&tariff)
+
+			err := tariff.Validate()
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tc.ErrMsg))
+		})
+	}
+}
+
+func TestTariffValidity(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		ValidFrom  time.Time
+		ValidUntil time.Time
+	}{
+		{"not yet valid", time.Now().Add(time.Hour), time.Time{}},
+		{"expired", time.Now().Add(-2 * time.Hour), time.Now().Add(-time.Hour)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			tariff := FlatRate()
+			tariff.ValidFrom = tc.ValidFrom
+			tariff.ValidUntil = tc.ValidUntil
+
+			_, err := tariff.ShipmentCost(Shipment{Parcels: []Parcel{{Weight: 42, Quantity: 1}}, From: country(t, "fr"), To: country(t, "fr")})
+			is.Equal(err, ErrTariffNotValid)
+		})
+	}
+}
+
+func TestTariffLanes(t *testing.T) {
+	tariff := FlatRate()
+	tariff.Lanes = []LaneRate{
+		{From: "nordic", To: "nordic", Domestic: true, Multiplier: 0.8},
+		{From: "nordic", To: "nordic", Multiplier: 1.2},
+		{From: "nordic", To: "outside_eu", Multiplier: 3},
+		{From: "within_eu", To: "within_eu", Multiplier: 1.4},
+	}
+	if err := tariff.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Name string
+
+		From string
+		To   string
+
+		WantAmount int64
+		WantLane   string
+		WantFactor string
+	}{
+		{"domestic", "se", "se", 80_00, "nordic:domestic", "lane nordic:domestic"},
+		{"within region", "se", "no", 120_00, "nordic:nordic", "lane nordic:nordic"},
+		{"between regions", "se", "jp", 300_00, "nordic:outside_eu", "lane nordic:outside_eu"},
+		{"domestic falls back to region lane", "fr", "fr", 140_00, "within_eu:domestic", "lane within_eu:within_eu, no rate for lane within_eu:domestic"},
+		{"lane falls back to sender region", "us", "se", 250_00, "outside_eu:nordic", "region outside_eu, no rate for lane outside_eu:nordic"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			got, err := tariff.ShipmentCost(Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, tc.From), To: country(t, tc.To)})
+			is.NoErr(err)
+			is.Equal(got.Amount, money.New(tc.WantAmount, "SEK"))
+			is.Equal(got.Lane.String(), tc.WantLane)
+
+			// Breakdown explains the amount.
+			is.Equal(len(got.Breakdown), 2)
+			is.Equal(got.Breakdown[0].Kind, ItemBase)
+			is.Equal(got.Breakdown[0].Amount, money.New(100_00, "SEK"))
+			is.Equal(got.Breakdown[1].Kind, ItemFactor)
+			is.Equal(got.Breakdown[1].Description, tc.WantFactor)
+			total, err := got.Breakdown.Total()
+			is.NoErr(err)
+			is.Equal(total, got.Amount)
+		})
+	}
+}
+
+func TestTariffVolumetricWeight(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Weight     int
+		Dimensions Dimensions
+		Divisor    int
+
+		WantWeight int
+		WantAmount int64
+	}{
+		{"no dimensions", 5, Dimensions{}, 5000, 5, 100_00},
+		{"actual weight is greater", 30, Dimensions{Length: 50, Width: 40, Height: 30}, 5000, 30, 500_00},
+		{"volumetric weight is greater", 5, Dimensions{Length: 50, Width: 40, Height: 30}, 5000, 12, 300_00},
+		{"volumetric weight is rounded up", 5, Dimensions{Length: 50, Width: 50, Height: 21}, 5000, 11, 300_00},
+		{"other divisor", 5, Dimensions{Length: 50, Width: 40, Height: 30}, 4000, 15, 300_00},
+		{"disabled", 5, Dimensions{Length: 100, Width: 100, Height: 100}, 0, 5, 100_00},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			tariff := FlatRate()
+			tariff.VolumetricDivisor = tc.Divisor
+
+			s := Shipment{Parcels: []Parcel{{Weight: tc.Weight, Dimensions: tc.Dimensions, Quantity: 1}}, From: country(t, "se"), To: country(t, "se")}
+			got, err := tariff.ShipmentCost(s)
+			is.NoErr(err)
+			is.Equal(got.ChargeableWeight, tc.WantWeight)
+			is.Equal(got.Amount, money.New(tc.WantAmount, "SEK"))
+		})
+	}
+
+	t.Run("volumetric weight above heaviest bracket", func(t *testing.T) {
+		is := is.New(t)
+
+		s := Shipment{Parcels: []Parcel{{Weight: 5, Dimensions: Dimensions{Length: 300, Width: 300, Height: 100}, Quantity: 1}}, From: country(t, "se"), To: country(t, "se")}
+		_, err := FlatRate().ShipmentCost(s)
+		is.Equal(err, ErrInvalidWeight)
+	})
+}
+
+func TestTariffParcels(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Parcels     []Parcel
+			ShipmentFee float64
+
+			WantAmount           int64
+			WantChargeableWeight int
+			WantItems            int
+		}{
+			{"single parcel", []Parcel{{Weight: 5, Quantity: 1}}, 0, 100_00, 5, 2},
+			{"quantity", []Parcel{{Weight: 5, Quantity: 3}}, 0, 300_00, 15, 2},
+			{"mixed parcels", []Parcel{{Weight: 5, Quantity: 2}, {Weight: 30, Quantity: 1}}, 0, 700_00, 40, 3},
+			{"volumetric parcel", []Parcel{{Weight: 5, Quantity: 1}, {Weight: 5, Dimensions: Dimensions{Length: 50, Width: 40, Height: 30}, Quantity: 2}}, 0, 700_00, 29, 3},
+			{"shipment fee", []Parcel{{Weight: 5, Quantity: 2}}, 49.5, 249_50, 10, 3},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				tariff := FlatRate()
+				tariff.ShipmentFee = tc.ShipmentFee
+
+				s := Shipment{Parcels: tc.Parcels, From: country(t, "se"), To: country(t, "se")}
+				got, err := tariff.ShipmentCost(s)
+				is.NoErr(err)
+				is.Equal(got.Amount, money.New(tc.WantAmount, "SEK"))
+				is.Equal(got.ChargeableWeight, tc.WantChargeableWeight)
+				is.Equal(len(got.ChargeableWeights), len(tc.Parcels))
+				is.Equal(len(got.Breakdown), tc.WantItems)
+				total, err := got.Breakdown.Total()
+				is.NoErr(err)
+				is.Equal(total, got.Amount)
+			})
+		}
+	})
+
+	t.Run("fee is multiplied by lane", func(t *testing.T) {
+		is := is.New(t)
+
+		tariff := FlatRate()
+		tariff.ShipmentFee = 100
+
+		s := Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "us"), To: country(t, "se")}
+		got, err := tariff.ShipmentCost(s)
+		is.NoErr(err)
+		is.Equal(got.Amount, money.New(2.5*200_00, "SEK"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Parcels []Parcel
+
+			Err error
+		}{
+			{"no parcels", nil, ErrNoParcels},
+			{"zero quantity", []Parcel{{Weight: 5}}, ErrInvalidQuantity},
+			{"one parcel too heavy", []Parcel{{Weight: 5, Quantity: 1}, {Weight: 1001, Quantity: 1}}, ErrInvalidWeight},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				s := Shipment{Parcels: tc.Parcels, From: country(t, "se"), To: country(t, "se")}
+				_, err := FlatRate().ShipmentCost(s)
+				is.Equal(err, tc.Err)
+			})
+		}
+	})
+}
+
+func TestTariffServiceLevels(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			ServiceLevel string
+
+			WantLevel  string
+			WantAmount int64
+			WantItems  int
+		}{
+			{"default", "", ServiceStandard, 150_00, 2},
+			{"economy", ServiceEconomy, ServiceEconomy, 120_00, 3},
+			{"standard", ServiceStandard, ServiceStandard, 150_00, 2},
+			{"express", ServiceExpress, ServiceExpress, 225_00 + 99_00, 4},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				tariff := FlatRate()
+				tariff.Levels[2].Fee = 99
+
+				s := Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "fr"), To: country(t, "se"), ServiceLevel: tc.ServiceLevel}
+				got, err := tariff.ShipmentCost(s)
+				is.NoErr(err)
+				is.Equal(got.ServiceLevel.Name, tc.WantLevel)
+				is.Equal(got.Amount, money.New(tc.WantAmount, "SEK"))
+				is.Equal(len(got.Breakdown), tc.WantItems)
+				total, err := got.Breakdown.Total()
+				is.NoErr(err)
+				is.Equal(total, got.Amount)
+			})
+		}
+	})
+
+	t.Run("not offered", func(t *testing.T) {
+		is := is.New(t)
+
+		tariff := FlatRate()
+		tariff.Levels = tariff.Levels[:2]
+
+		s := Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "fr"), To: country(t, "se"), ServiceLevel: ServiceExpress}
+		_, err := tariff.ShipmentCost(s)
+		is.Equal(err, ErrUnsupportedServiceLevel)
+	})
+
+	t.Run("tariff without service levels", func(t *testing.T) {
+		is := is.New(t)
+
+		tariff := FlatRate()
+		tariff.Levels = nil
+		is.Equal(tariff.ServiceLevels(), []ServiceLevel{{Name: ServiceStandard, Multiplier: 1}})
+
+		s := Shipment{Parcels: []Parcel{{Weight: 5, Quantity: 1}}, From: country(t, "fr"), To: country(t, "se")}
+		got, err := tariff.ShipmentCost(s)
+		is.NoErr(err)
+		is.Equal(got.Amount, money.New(150_00, "SEK"))
+
+		s.ServiceLevel = ServiceEconomy
+		_, err = tariff.ShipmentCost(s)
+		is.Equal(err, ErrUnsupportedServiceLevel)
+	})
+}
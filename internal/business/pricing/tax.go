@@ -0,0 +1,61 @@
+package pricing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johanronkko/quote-service/internal/business/money"
+)
+
+// Tax is the VAT applied to a shipment.
+type Tax struct {
+	// Rate is the applied VAT rate as a fraction, e.g. 0.25 for 25%.
+	Rate float64 `json:"rate"`
+	// Amount is the VAT to pay on top of the net shipment cost.
+	Amount money.Money `json:"amount"`
+	// ReverseCharge is set when the sender is a business that accounts for
+	// the VAT itself.
+	ReverseCharge bool `json:"reverse_charge"`
+	// Country is the alpha-2 code of the country whose VAT rate applies.
+	Country string `json:"country,omitempty"`
+}
+
+// VAT calculates the VAT on the net cost of shipment s. The rules are:
+//
+// Domestic shipments are charged the VAT of the country. Shipments between two
+// EU member states are charged the VAT of the origin country, unless the sender
+// is a business with a VAT number in which case the VAT is reverse charged.
+// Shipments to or from a country outside of the EU are zero-rated.
+func VAT(s Shipment, net money.Money) (Tax, Item) {
+	zero := money.New(0, net.Currency)
+
+	switch {
+	case strings.EqualFold(s.From.Alpha2, s.To.Alpha2) || (s.From.EU && s.To.EU && s.VATNumber == ""):
+		tax := Tax{
+			Rate:    s.From.VATRate,
+			Amount:  net.Mul(s.From.VATRate),
+			Country: strings.ToUpper(s.From.Alpha2),
+		}
+		return tax, Item{
+			Kind:        ItemTax,
+			Description: fmt.Sprintf("VAT %s %g%%", tax.Country, tax.Rate*100),
+			Factor:      tax.Rate,
+			Amount:      tax.Amount,
+		}
+
+	case s.From.EU && s.To.EU:
+		tax := Tax{Amount: zero, ReverseCharge: true}
+		return tax, Item{
+			Kind:        ItemTax,
+			Description: fmt.Sprintf("VAT reverse charged to %s", s.VATNumber),
+			Amount:      tax.Amount,
+		}
+	}
+
+	tax := Tax{Amount: zero}
+	return tax, Item{
+		Kind:        ItemTax,
+		Description: "VAT zero-rated international shipment",
+		Amount:      tax.Amount,
+	}
+}
@@ -0,0 +1,52 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/johanronkko/quote-service/internal/business/money"
+	"github.com/matryer/is"
+)
+
+func TestVAT(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		From      string
+		To        string
+		VATNumber string
+
+		WantRate          float64
+		WantAmount        int64
+		WantReverseCharge bool
+		WantCountry       string
+	}{
+		{"domestic", "se", "se", "", 0.25, 250_00, false, "SE"},
+		{"domestic business", "se", "se", "SE556677889901", 0.25, 250_00, false, "SE"},
+		{"domestic outside EU", "no", "no", "", 0.25, 250_00, false, "NO"},
+		{"within EU", "fr", "de", "", 0.2, 200_00, false, "FR"},
+		{"within EU business", "fr", "de", "FR12345678901", 0, 0, true, ""},
+		{"export", "se", "us", "", 0, 0, false, ""},
+		{"import", "us", "se", "", 0, 0, false, ""},
+		{"EEA outside EU", "no", "se", "", 0, 0, false, ""},
+		{"no VAT", "us", "us", "", 0, 0, false, "US"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			s := Shipment{
+				From:      country(t, tc.From),
+				To:        country(t, tc.To),
+				VATNumber: tc.VATNumber,
+			}
+			tax, item := VAT(s, money.New(1000_00, "SEK"))
+			is.Equal(tax.Rate, tc.WantRate)
+			is.Equal(tax.Amount, money.New(tc.WantAmount, "SEK"))
+			is.Equal(tax.ReverseCharge, tc.WantReverseCharge)
+			is.Equal(tax.Country, tc.WantCountry)
+
+			is.Equal(item.Kind, ItemTax)
+			is.Equal(item.Amount, tax.Amount)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package region
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Loader loads countries from a country catalogue, e.g. a database.
+type Loader interface {
+	// LoadCountries returns the countries of the catalogue, both active and
+	// inactive.
+	LoadCountries(ctx context.Context) ([]Override, error)
+}
+
+// Override adds a country to the embedded dataset, or changes the country in
+// the dataset with the same alpha-2 code. Nil fields keep the values of the
+// country in the dataset, and are zero for countries not in the dataset.
+type Override struct {
+	Alpha2       string
+	Alpha3       *string
+	Name         *string
+	Nordic       *bool
+	EU           *bool
+	EEA          *bool
+	CustomsUnion *bool
+	VATRate      *float64
+	Active       bool
+}
+
+// apply returns c with the fields set by o replaced. Country codes are upper
+// cased like those of the dataset.
+func (o Override) apply(c Country) Country {
+	c.Alpha2 = strings.ToUpper(o.Alpha2)
+	if o.Alpha3 != nil {
+		c.Alpha3 = strings.ToUpper(*o.Alpha3)
+	}
+	if o.Name != nil {
+		c.Name = *o.Name
+	}
+	if o.Nordic != nil {
+		c.Nordic = *o.Nordic
+	}
+	if o.EU != nil {
+		c.EU = *o.EU
+	}
+	if o.EEA != nil {
+		c.EEA = *o.EEA
+	}
+	if o.CustomsUnion != nil {
+		c.CustomsUnion = *o.CustomsUnion
+	}
+	if o.VATRate != nil {
+		c.VATRate = *o.VATRate
+	}
+	c.Active = o.Active
+	return c
+}
+
+// Cache is an in-memory country catalogue. It starts out with the embedded ISO
+// 3166-1 dataset, and countries returned by the loader are added on top of it
+// when calling Refresh. A country from the loader overrides the one in the
+// dataset with the same alpha-2 code, and inactive countries are removed. This
+// makes adding or deactivating a country a data change rather than a code
+// change.
+type Cache struct {
+	loader Loader
+
+	mu        sync.RWMutex
+	countries map[string]Country
+}
+
+// NewCache constructs a Cache backed by loader. A nil loader means only the
+// embedded dataset is used.
+func NewCache(loader Loader) *Cache {
+	return &Cache{
+		loader:    loader,
+		countries: merge(nil),
+	}
+}
+
+// Refresh rebuilds the catalogue from the embedded dataset and the countries
+// returned by the loader. The catalogue is left untouched if loading fails.
+func (c *Cache) Refresh(ctx context.Context) error {
+	var loaded []Override
+	if c.loader != nil {
+		var err error
+		if loaded, err = c.loader.LoadCountries(ctx); err != nil {
+			return fmt.Errorf("loading countries: %w", err)
+		}
+	}
+
+	countries := merge(loaded)
+
+	c.mu.Lock()
+	c.countries = countries
+	c.mu.Unlock()
+	return nil
+}
+
+// Country returns the country with country code ccode. Errors if invalid
+// country code.
+func (c *Cache) Country(ccode string) (Country, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	country, ok := c.countries[strings.ToLower(ccode)]
+	if !ok {
+		return Country{}, ErrUnsupportedCountryCode
+	}
+	return country, nil
+}
+
+// From returns region given country code. Errors if invalid country code.
+func (c *Cache) From(ccode string) (Region, error) {
+	country, err := c.Country(ccode)
+	if err != nil {
+		return 0, err
+	}
+	return country.Region(), nil
+}
+
+// merge returns the active countries of the embedded dataset overridden by
+// loaded, keyed by lowercase alpha-2 code.
+func merge(loaded []Override) map[string]Country {
+	m := make(map[string]Country, len(countries))
+	for _, country := range countries {
+		m[strings.ToLower(country.Alpha2)] = country
+	}
+	for _, o := range loaded {
+		ccode := strings.ToLower(o.Alpha2)
+		if !o.Active {
+			delete(m, ccode)
+			continue
+		}
+		m[ccode] = o.apply(m[ccode])
+	}
+	return m
+}
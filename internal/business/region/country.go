@@ -0,0 +1,110 @@
+package region
+
+import (
+	// Used to embed iso3166-1.csv into the countriesDoc variable.
+	_ "embed"
+
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//go:embed iso3166-1.csv
+var countriesDoc string
+
+// countries is the parsed ISO 3166-1 dataset.
+var countries []Country
+
+func init() {
+	var err error
+	countries, err = parseCountries(countriesDoc)
+	if err != nil {
+		panic(fmt.Sprintf("parsing embedded ISO 3166-1 dataset: %s", err))
+	}
+}
+
+// Country is a country in the ISO 3166-1 catalogue together with the trade
+// memberships relevant for shipping.
+type Country struct {
+	Alpha2 string `json:"alpha2"`
+	Alpha3 string `json:"alpha3"`
+	Name   string `json:"name"`
+
+	// Nordic is set for the Nordic countries and their autonomous territories.
+	Nordic bool `json:"nordic"`
+	// EU is set for member states of the European Union.
+	EU bool `json:"eu"`
+	// EEA is set for members of the European Economic Area.
+	EEA bool `json:"eea"`
+	// CustomsUnion is set for members of the EU customs union.
+	CustomsUnion bool `json:"customs_union"`
+
+	// VATRate is the standard VAT rate of the country as a fraction, e.g.
+	// 0.25 for 25%. Zero if unknown or if the country has no VAT.
+	VATRate float64 `json:"vat_rate"`
+
+	// Active reports whether shipments to and from the country are supported.
+	Active bool `json:"active"`
+}
+
+// Region returns the region of the country derived from its memberships.
+// Nordic countries are classified as Nordic even if they are EU members.
+func (c Country) Region() Region {
+	switch {
+	case c.Nordic:
+		return Nordic
+	case c.EU:
+		return WithinEU
+	}
+	return OutsideEU
+}
+
+// Countries returns the embedded ISO 3166-1 dataset ordered by alpha-2 code.
+// Every country in the dataset is active.
+func Countries() []Country {
+	cs := make([]Country, len(countries))
+	copy(cs, countries)
+	return cs
+}
+
+// parseCountries parses the CSV formatted ISO 3166-1 dataset.
+func parseCountries(s string) ([]Country, error) {
+	records, err := csv.NewReader(strings.NewReader(s)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no header")
+	}
+
+	var cs []Country
+	for i, rec := range records[1:] {
+		line := i + 2
+		if len(rec) != 8 {
+			return nil, fmt.Errorf("line %d: expected 8 fields, got %d", line, len(rec))
+		}
+		var flags [4]bool
+		for j := range flags {
+			if flags[j], err = strconv.ParseBool(rec[3+j]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		vat, err := strconv.ParseFloat(rec[7], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		cs = append(cs, Country{
+			Alpha2:       rec[0],
+			Alpha3:       rec[1],
+			Name:         rec[2],
+			Nordic:       flags[0],
+			EU:           flags[1],
+			EEA:          flags[2],
+			CustomsUnion: flags[3],
+			VATRate:      vat,
+			Active:       true,
+		})
+	}
+	return cs, nil
+}
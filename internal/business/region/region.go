@@ -3,6 +3,7 @@ package region
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 )
 
@@ -23,58 +24,31 @@ const (
 	OutsideEU        = 2.5
 )
 
-// From returns region given country code. Errors if invalid country code.
-func From(ccode string) (Region, error) {
-	r, ok := regions[strings.ToLower(ccode)]
-	if !ok {
-		return 0, ErrUnsupportedCountryCode
+// String returns the name of the region.
+func (r Region) String() string {
+	switch r {
+	case Nordic:
+		return "nordic"
+	case WithinEU:
+		return "within_eu"
+	case OutsideEU:
+		return "outside_eu"
 	}
-	return r, nil
+	return "unknown"
 }
 
-// Regions are hard coded. In the future we could instead do the country code to
-// region mapping via an API call to a centralized database, externally or
-// internally.
-var regions map[string]Region
-
-func init() {
-	regions = map[string]Region{
-		"sv": Nordic, // Sweden
-		"no": Nordic, // Norway
-		"dk": Nordic, // Denmark
-		"fi": Nordic, // Finland
-
-		"fr": WithinEU, // France
-		"de": WithinEU, // Germany
-		"nl": WithinEU, // Netherlands
-		"it": WithinEU, // Italy
-		"pt": WithinEU, // Portugal
-		"at": WithinEU, // Austria
-		"be": WithinEU, // Belgium
-		"lv": WithinEU, // Latvia
-		"bg": WithinEU, // Bulgaria
-		"lt": WithinEU, // Lithuania
-		"hr": WithinEU, // Croatia
-		"lu": WithinEU, // Luxembourg
-		"cy": WithinEU, // Cyprus
-		"mt": WithinEU, // Malta
-		"cz": WithinEU, // Czechia
-		"pl": WithinEU, // Poland
-		"ee": WithinEU, // Estonia
-		"ro": WithinEU, // Romania
-		"sk": WithinEU, // Slovakia
-		"si": WithinEU, // Slovenia
-		"gr": WithinEU, // Greece
-		"es": WithinEU, // Spain
-		"hu": WithinEU, // Hungary
-		"ie": WithinEU, // Ireland
+// All returns every supported region.
+func All() []Region {
+	return []Region{Nordic, WithinEU, OutsideEU}
+}
 
-		"us": OutsideEU, // United States of America
-		"ca": OutsideEU, // Canada
-		"cn": OutsideEU, // China
-		"jp": OutsideEU, // Japan
-		"th": OutsideEU, // Thailand
-		"br": OutsideEU, // Brazil
-		"ar": OutsideEU, // Argentina
+// Parse returns the region with the given name. Names are the ones returned by
+// Region.String.
+func Parse(name string) (Region, error) {
+	for _, r := range All() {
+		if r.String() == strings.ToLower(name) {
+			return r, nil
+		}
 	}
+	return 0, fmt.Errorf("unknown region %q", name)
 }
@@ -1,13 +1,113 @@
 package region_test
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	. "github.com/johanronkko/quote-service/internal/business/region"
 	"github.com/matryer/is"
 )
 
-func TestFrom(t *testing.T) {
+type loader struct {
+	countries []Override
+	err       error
+}
+
+func (l *loader) LoadCountries(ctx context.Context) ([]Override, error) {
+	return l.countries, l.err
+}
+
+func TestCountries(t *testing.T) {
+	is := is.New(t)
+
+	countries := Countries()
+	is.Equal(len(countries), 249)
+
+	alpha2 := map[string]bool{}
+	alpha3 := map[string]bool{}
+	var eu, eea int
+	for _, c := range countries {
+		is.Equal(len(c.Alpha2), 2)
+		is.Equal(len(c.Alpha3), 3)
+		is.Equal(c.Alpha2, strings.ToUpper(c.Alpha2))
+		is.True(c.Name != "")
+		is.True(c.Active)
+		is.True(!alpha2[c.Alpha2]) // duplicate alpha-2 code
+		is.True(!alpha3[c.Alpha3]) // duplicate alpha-3 code
+		alpha2[c.Alpha2] = true
+		alpha3[c.Alpha3] = true
+
+		if c.EU {
+			eu++
+			is.True(c.EEA)          // EU members are EEA members
+			is.True(c.CustomsUnion) // EU members are in the customs union
+		}
+		if c.EEA {
+			eea++
+		}
+	}
+	is.Equal(eu, 27)
+	is.Equal(eea, 30)
+}
+
+func TestCacheFrom(t *testing.T) {
+	c := NewCache(nil)
+
+	t.Run("every country", func(t *testing.T) {
+		// The 5 Nordic countries and their autonomous territories.
+		nordic := map[string]bool{
+			"DK": true, "FI": true, "IS": true, "NO": true, "SE": true,
+			"AX": true, "FO": true, "GL": true,
+		}
+		// The 27 EU member states.
+		eu := map[string]bool{
+			"AT": true, "BE": true, "BG": true, "CY": true, "CZ": true, "DE": true,
+			"DK": true, "EE": true, "ES": true, "FI": true, "FR": true, "GR": true,
+			"HR": true, "HU": true, "IE": true, "IT": true, "LT": true, "LU": true,
+			"LV": true, "MT": true, "NL": true, "PL": true, "PT": true, "RO": true,
+			"SE": true, "SI": true, "SK": true,
+		}
+		is.New(t).Equal(len(eu), 27)
+
+		seen := map[string]bool{}
+		for _, country := range Countries() {
+			seen[country.Alpha2] = true
+			t.Run(country.Alpha2, func(t *testing.T) {
+				is := is.New(t)
+
+				want := Region(OutsideEU)
+				switch {
+				case nordic[country.Alpha2]:
+					want = Nordic
+				case eu[country.Alpha2]:
+					want = WithinEU
+				}
+				is.Equal(country.Nordic, nordic[country.Alpha2])
+				is.Equal(country.EU, eu[country.Alpha2])
+
+				got, err := c.From(country.Alpha2)
+				is.NoErr(err)
+				is.Equal(got, want)
+
+				got, err = c.From(strings.ToLower(country.Alpha2))
+				is.NoErr(err)
+				is.Equal(got, want)
+			})
+		}
+		for code := range nordic {
+			if !seen[code] {
+				t.Errorf("%s: missing from the dataset", code)
+			}
+		}
+		for code := range eu {
+			if !seen[code] {
+				t.Errorf("%s: missing from the dataset", code)
+			}
+		}
+	})
+
 	t.Run("valid", func(t *testing.T) {
 		cases := []struct {
 			Name string
@@ -15,16 +115,22 @@ func TestFrom(t *testing.T) {
 			CountryCode string
 			Want        Region
 		}{
-			{"nordic", "sv", Nordic},
+			{"nordic", "se", Nordic},
+			{"nordic outside EU", "no", Nordic},
+			{"nordic outside EEA", "fo", Nordic},
 			{"within EU", "fr", WithinEU},
 			{"outside EU", "jp", OutsideEU},
+			{"EEA outside EU", "li", OutsideEU},
+			{"customs union outside EU", "tr", OutsideEU},
+			{"former member", "gb", OutsideEU},
+			{"el salvador", "sv", OutsideEU},
 			{"uppercase", "CA", OutsideEU},
 		}
 		for _, tc := range cases {
 			t.Run(tc.Name, func(t *testing.T) {
 				is := is.New(t)
 
-				got, err := From(tc.CountryCode)
+				got, err := c.From(tc.CountryCode)
 				is.NoErr(err)
 
 				is.Equal(got, tc.Want)
@@ -45,9 +151,78 @@ func TestFrom(t *testing.T) {
 			t.Run(tc.Name, func(t *testing.T) {
 				is := is.New(t)
 
-				_, err := From(tc.CountryCode)
+				_, err := c.From(tc.CountryCode)
 				is.Equal(err.Error(), ErrUnsupportedCountryCode.Error())
 			})
 		}
 	})
 }
+
+func TestCacheRefresh(t *testing.T) {
+	is := is.New(t)
+
+	l := &loader{}
+	c := NewCache(l)
+
+	// The embedded dataset is used until refreshed.
+	_, err := c.From("xk")
+	is.Equal(err, ErrUnsupportedCountryCode)
+	got, err := c.From("fr")
+	is.NoErr(err)
+	is.Equal(got, Region(WithinEU))
+
+	// Countries added to the catalogue are picked up on refresh, and inactive
+	// countries are removed.
+	alpha3, name, yes, vat := "xkx", "Kosovo", true, 0.2
+	l.countries = []Override{
+		{Alpha2: "xk", Alpha3: &alpha3, Name: &name, Active: true},
+		{Alpha2: "fr", Active: false},
+		{Alpha2: "no", EU: &yes, VATRate: &vat, Active: true},
+	}
+	is.NoErr(c.Refresh(context.Background()))
+	got, err = c.From("XK")
+	is.NoErr(err)
+	is.Equal(got, Region(OutsideEU))
+	kosovo, err := c.Country("xk")
+	is.NoErr(err)
+	is.Equal(kosovo.Alpha2, "XK")
+	is.Equal(kosovo.Alpha3, "XKX")
+	_, err = c.From("fr")
+	is.Equal(err, ErrUnsupportedCountryCode)
+
+	// Overrides only change the fields they set.
+	norway, err := c.Country("no")
+	is.NoErr(err)
+	is.True(norway.EU)
+	is.Equal(norway.VATRate, 0.2)
+	is.Equal(norway.Name, "Norway")
+	is.True(norway.Nordic)
+	is.True(norway.EEA)
+
+	// A failed refresh keeps the previous catalogue.
+	l.err = errors.New("some error")
+	is.True(c.Refresh(context.Background()) != nil)
+	_, err = c.From("xk")
+	is.NoErr(err)
+
+	// Countries removed from the catalogue fall back to the embedded dataset.
+	l.countries, l.err = nil, nil
+	is.NoErr(c.Refresh(context.Background()))
+	_, err = c.From("xk")
+	is.Equal(err, ErrUnsupportedCountryCode)
+	_, err = c.From("fr")
+	is.NoErr(err)
+}
+
+func TestParse(t *testing.T) {
+	is := is.New(t)
+
+	for _, r := range All() {
+		got, err := Parse(r.String())
+		is.NoErr(err)
+		is.Equal(got, r)
+	}
+
+	_, err := Parse("mars")
+	is.True(err != nil)
+}
@@ -0,0 +1,95 @@
+package validate
+
+import (
+	// Used to embed address-formats.csv into the addressFormatsDoc variable.
+	_ "embed"
+
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strings"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/johanronkko/quote-service/internal/business/address"
+)
+
+//go:embed address-formats.csv
+var addressFormatsDoc string
+
+// addressFormats are the address rules of the countries in the embedded
+// dataset by country code.
+var addressFormats map[string]addressFormat
+
+// defaultAddressFormat is the address rule of countries not in the dataset.
+var defaultAddressFormat = addressFormat{PostalCode: postalCodeRegex, Required: map[string]bool{"city": true}}
+
+// addressFormat is the address rule of a country. PostalCode matches the
+// postal codes of the country, and Required holds the JSON names of the
+// fields an address in the country must have.
+type addressFormat struct {
+	PostalCode *regexp.Regexp
+	Required   map[string]bool
+}
+
+// parseAddressFormats parses the CSV formatted address rules dataset. Postal
+// code patterns match regardless of case, and an empty pattern accepts postal
+// codes of the generic format of the postalcode tag.
+func parseAddressFormats(s string) (map[string]addressFormat, error) {
+	records, err := csv.NewReader(strings.NewReader(s)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no header")
+	}
+
+	formats := make(map[string]addressFormat)
+	for i, rec := range records[1:] {
+		line := i + 2
+		if len(rec) != 3 {
+			return nil, fmt.Errorf("line %d: expected 3 fields, got %d", line, len(rec))
+		}
+		f := addressFormat{PostalCode: postalCodeRegex, Required: make(map[string]bool)}
+		if rec[1] != "" {
+			if f.PostalCode, err = regexp.Compile("^(?i:" + rec[1] + ")$"); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		for _, field := range strings.Fields(rec[2]) {
+			if field != "postal_code" && field != "city" && field != "region" {
+				return nil, fmt.Errorf("line %d: unknown field %q", line, field)
+			}
+			f.Required[field] = true
+		}
+		formats[rec[0]] = f
+	}
+	return formats, nil
+}
+
+// validateAddress checks an address.Address against the rules of its country.
+// Errors are reported on the field breaking the rule.
+func validateAddress(sl validator.StructLevel) {
+	a := sl.Current().Interface().(address.Address)
+
+	f, ok := addressFormats[a.CountryCode]
+	if !ok {
+		f = defaultAddressFormat
+	}
+	fields := []struct {
+		Name       string
+		StructName string
+		Value      string
+	}{
+		{"postal_code", "PostalCode", a.PostalCode},
+		{"city", "City", a.City},
+		{"region", "Region", a.Region},
+	}
+	for _, field := range fields {
+		if f.Required[field.Name] && field.Value == "" {
+			sl.ReportError(field.Value, field.Name, field.StructName, "required", "")
+		}
+	}
+	if a.PostalCode != "" && !f.PostalCode.MatchString(a.PostalCode) {
+		sl.ReportError(a.PostalCode, "postal_code", "PostalCode", "postalcode", a.CountryCode)
+	}
+}
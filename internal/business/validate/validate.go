@@ -4,6 +4,7 @@ package validate
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"regexp"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	validator "github.com/go-playground/validator/v10"
 	en_translations "github.com/go-playground/validator/v10/translations/en"
 	"github.com/google/uuid"
+	"github.com/johanronkko/quote-service/internal/business/address"
 )
 
 // validate holds the settings and caches for validating request struct values.
@@ -25,6 +27,18 @@ var translator *ut.UniversalTranslator
 // [[:alpha:]]?
 var personNameRegex = regexp.MustCompile("^[[:upper:]]([[:alpha:]]|[[:punct:]]|[[:space:]]){0,29}$")
 
+// currencyRegex matches the format of ISO 4217 currency codes. Whether the
+// currency is supported is decided by the business logic.
+var currencyRegex = regexp.MustCompile("^[A-Z]{3}$")
+
+// vatNumberRegex matches the format of VAT numbers: a two letter country
+// prefix followed by 2 to 13 characters.
+var vatNumberRegex = regexp.MustCompile("^[A-Z]{2}[0-9A-Z+*]{2,13}$")
+
+// postalCodeRegex matches the format of postal codes: 2 to 10 letters and
+// digits, optionally separated by spaces or dashes.
+var postalCodeRegex = regexp.MustCompile("^[0-9A-Za-z][0-9A-Za-z -]{0,8}[0-9A-Za-z]$")
+
 func init() {
 
 	// Instantiate the validator for use.
@@ -34,6 +48,25 @@ func init() {
 		return personNameRegex.MatchString(fl.Field().String())
 	})
 
+	_ = validate.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return currencyRegex.MatchString(fl.Field().String())
+	})
+
+	_ = validate.RegisterValidation("vatnumber", func(fl validator.FieldLevel) bool {
+		return vatNumberRegex.MatchString(fl.Field().String())
+	})
+
+	_ = validate.RegisterValidation("postalcode", func(fl validator.FieldLevel) bool {
+		return postalCodeRegex.MatchString(fl.Field().String())
+	})
+
+	// Check addresses against the rules of their country.
+	var err error
+	if addressFormats, err = parseAddressFormats(addressFormatsDoc); err != nil {
+		panic(fmt.Sprintf("parsing embedded address formats dataset: %s", err))
+	}
+	validate.RegisterStructValidation(validateAddress, address.Address{})
+
 	// Instantiate the english locale for the validator library.
 	enLocale := en.New()
 
@@ -87,10 +120,12 @@ func Check(val interface{}) error {
 		// Accept-Language header if you intend to support multiple languages.
 		lang, _ := translator.GetTranslator("en")
 
+		// Fields are named by their path from the validated model, e.g.
+		// to.address.postal_code, so errors point at the exact field.
 		var fields FieldErrors
 		for _, verror := range verrors {
 			field := FieldError{
-				Field: verror.Field(),
+				Field: verror.Namespace()[strings.Index(verror.Namespace(), ".")+1:],
 				Error: verror.Translate(lang),
 			}
 
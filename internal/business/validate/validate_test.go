@@ -1,9 +1,11 @@
 package validate_test
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
+	"github.com/johanronkko/quote-service/internal/business/address"
 	"github.com/johanronkko/quote-service/internal/business/tests"
 	"github.com/johanronkko/quote-service/internal/business/validate"
 	"github.com/matryer/is"
@@ -56,3 +58,178 @@ func TestPersonNameTag(t *testing.T) {
 		}
 	})
 }
+
+type currencyStruct struct {
+	Currency string `json:"currency" validate:"currency"`
+}
+
+func TestCurrencyTag(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		is := is.New(t)
+
+		err := validate.Check(currencyStruct{"EUR"})
+		is.NoErr(err)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			Currency string
+		}{
+			{"lowercase", "eur"},
+			{"too short", "EU"},
+			{"too long", "EURO"},
+			{"empty", ""},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				err := validate.Check(currencyStruct{tc.Currency})
+				is.True(err != nil)
+			})
+		}
+	})
+}
+
+type vatNumberStruct struct {
+	VATNumber string `json:"vat_number" validate:"vatnumber"`
+}
+
+func TestVATNumberTag(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			VATNumber string
+		}{
+			{"swedish", "SE556677889901"},
+			{"letters", "ESX1234567X"},
+			{"min length", "DK12"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				err := validate.Check(vatNumberStruct{tc.VATNumber})
+				is.NoErr(err)
+			})
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			VATNumber string
+		}{
+			{"no country prefix", "556677889901"},
+			{"lowercase", "se556677889901"},
+			{"spaces", "SE 5566 7788 9901"},
+			{"too long", "SE55667788990123"},
+			{"empty", ""},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				err := validate.Check(vatNumberStruct{tc.VATNumber})
+				is.True(err != nil)
+			})
+		}
+	})
+}
+
+type postalCodeStruct struct {
+	PostalCode string `json:"postal_code" validate:"postalcode"`
+}
+
+func TestPostalCodeTag(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			PostalCode string
+		}{
+			{"swedish", "114 55"},
+			{"norwegian", "9170"},
+			{"british", "SW1A 1AA"},
+			{"dash", "00-950"},
+			{"min length", "12"},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				err := validate.Check(postalCodeStruct{tc.PostalCode})
+				is.NoErr(err)
+			})
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cases := []struct {
+			Name string
+
+			PostalCode string
+		}{
+			{"too short", "1"},
+			{"too long", "12345678901"},
+			{"leading space", " 11455"},
+			{"symbols", "114*55"},
+			{"empty", ""},
+		}
+		for _, tc := range cases {
+			t.Run(tc.Name, func(t *testing.T) {
+				is := is.New(t)
+
+				err := validate.Check(postalCodeStruct{tc.PostalCode})
+				is.True(err != nil)
+			})
+		}
+	})
+}
+
+type addressStruct struct {
+	To *address.Address `json:"to"`
+}
+
+func TestAddress(t *testing.T) {
+	cases := []struct {
+		Name string
+
+		Address address.Address
+
+		Fields []string // Fields with errors.
+	}{
+		{"swedish", address.Address{Lines: []string{"Vasagatan 5B"}, PostalCode: "411 24", City: "Göteborg", CountryCode: "SE"}, nil},
+		{"american", address.Address{Lines: []string{"1 Main St", "Apt 4"}, PostalCode: "55434-1234", City: "Blaine", Region: "MN", CountryCode: "US"}, nil},
+		{"postal code not required", address.Address{Lines: []string{"1 Queen's Road"}, City: "Hong Kong", CountryCode: "HK"}, nil},
+		{"country without rule", address.Address{Lines: []string{"Rua 1"}, PostalCode: "1000", City: "Maputo", CountryCode: "MZ"}, nil},
+		{"lower case british postal code", address.Address{Lines: []string{"10 Downing St"}, PostalCode: "sw1a 2aa", City: "London", CountryCode: "GB"}, nil},
+		{"lower case dutch postal code", address.Address{Lines: []string{"Damrak 1"}, PostalCode: "1012 lg", City: "Amsterdam", CountryCode: "NL"}, nil},
+		{"bad swedish postal code", address.Address{Lines: []string{"Vasagatan 5B"}, PostalCode: "4112", City: "Göteborg", CountryCode: "SE"}, []string{"to.postal_code"}},
+		{"missing state", address.Address{Lines: []string{"1 Main St"}, PostalCode: "55434", City: "Blaine", CountryCode: "US"}, []string{"to.region"}},
+		{"missing postal code and city", address.Address{Lines: []string{"Karl Johans gate 1"}, CountryCode: "NO"}, []string{"to.postal_code", "to.city"}},
+		{"missing city without rule", address.Address{Lines: []string{"Rua 1"}, CountryCode: "MZ"}, []string{"to.city"}},
+		{"empty line", address.Address{Lines: []string{"Vasagatan 5B", ""}, PostalCode: "411 24", City: "Göteborg", CountryCode: "SE"}, []string{"to.lines[1]"}},
+		{"no lines", address.Address{PostalCode: "411 24", City: "Göteborg", CountryCode: "SE"}, []string{"to.lines"}},
+		{"only blank lines", address.Address{Lines: []string{" "}, PostalCode: "411 24", City: "Göteborg", CountryCode: "SE"}.Normalize(), []string{"to.lines"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			is := is.New(t)
+
+			err := validate.Check(addressStruct{&tc.Address})
+			if tc.Fields == nil {
+				is.NoErr(err)
+				return
+			}
+			var ferrors validate.FieldErrors
+			is.True(errors.As(err, &ferrors))
+			var fields []string
+			for _, ferror := range ferrors {
+				fields = append(fields, ferror.Field)
+			}
+			is.Equal(fields, tc.Fields)
+		})
+	}
+}